@@ -0,0 +1,77 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package affected
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/affected"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func NewDefaultCmd() *cobra.Command {
+	return NewCmd(ioutils.DefaultStreams, bazel.WorkspaceFromWd)
+}
+
+func NewCmd(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "affected [target patterns...]",
+		Short: "Print the targets affected by changes since a git revision",
+		Long: `Prints the targets that may be affected by the files that changed since a git revision.
+
+The changed files are those that differ between the working tree, including untracked files,
+and the merge base of --base and HEAD. Each changed file is mapped to its package and to the
+source file target it is, and the affected targets are the reverse dependencies of these within
+the given target patterns, which default to //... . Patterns starting with - are excluded.
+
+Some changes widen the scope conservatively:
+
+- A changed BUILD file affects every target of its package, and an added or deleted one also
+  affects the parent package.
+- A changed .bzl file affects every target of the packages that load it.
+- A deleted file affects every target of its package.
+- A change to MODULE.bazel, WORKSPACE, .bazelrc, .bazelversion or a lockfile such as
+  MODULE.bazel.lock, go.sum or pnpm-lock.yaml affects every target.
+
+Targets tagged manual are never printed. With --tests_only, only test targets are printed, which
+makes it possible to test just what a pull request touches.
+
+In addition to flags listed below, flags accepted by the 'bazel query' command are also accepted.`,
+		Example: `# Print the targets affected by the changes on this branch
+
+% aspect affected --base=origin/main
+
+# Test only what a pull request touches
+
+% aspect affected --base=origin/main --tests_only > affected_tests.txt
+% [ -s affected_tests.txt ] && aspect test --target_pattern_file=affected_tests.txt`,
+		GroupID: "aspect",
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			affected.New(streams, bzl).Run,
+		),
+	}
+
+	affected.AddFlags(cmd.Flags())
+
+	return cmd
+}
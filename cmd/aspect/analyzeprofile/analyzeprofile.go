@@ -42,13 +42,30 @@ The profile is commonly written to ` + "`$(bazel info output_base)/command.profi
 after a build command completes.
 You can use the ` + "`--profile=<file>`" + ` flag to supply an alternative path where the profile is written.
 
-This command just dumps profile data to stdout. To inspect a profile you may want to use a GUI
-instead, such as the ` + "`chrome//:tracing`" + ` interface built into Chromium / Google Chrome, or
-[ui.perfetto.dev](https://ui.perfetto.dev).
+JSON trace profiles, which Bazel writes by default, are analyzed by Aspect CLI. It reports the
+total wall time, the time spent in each build phase, the critical path, the slowest actions,
+mnemonics and targets, and how busy the Skyframe worker threads were. Use ` + "`--format=json`" + `
+to post-process the analysis with scripts.
 
-By default, a summary of the analysis is printed.  For post-processing
-with scripts, the ` + "`--dump=raw`" + ` option is recommended, causing this
-command to dump profile data in easily-parsed format.`,
+With ` + "`--compare <baseline> <candidate>`" + `, two JSON trace profiles are lined up to show how
+the wall time, the time of each phase and mnemonic, and the duration of each action changed,
+and which actions on the critical path got slower. Use it to confirm that a change such as a
+toolchain upgrade or a new bazelrc flag really sped up the build.
+
+Profiles in Bazel's legacy binary format, and any invocation with Bazel flags such as
+` + "`--dump=raw`" + `, are passed through to ` + "`bazel analyze-profile`" + `.
+
+To inspect a profile interactively you may want to use a GUI instead, such as the
+` + "`chrome//:tracing`" + ` interface built into Chromium / Google Chrome, or
+[ui.perfetto.dev](https://ui.perfetto.dev).`,
+		Example: `# Summarize the profile of the last build
+% aspect analyze-profile $(bazel info output_base)/command.profile.gz
+
+# Print the 25 slowest actions, mnemonics and targets as JSON
+% aspect analyze-profile --format=json --top=25 /tmp/profile.json.gz
+
+# Compare the profile of a build before and after a toolchain upgrade
+% aspect analyze-profile --compare before.profile.gz after.profile.gz`,
 		GroupID: "built-in",
 		RunE: interceptors.Run(
 			[]interceptors.Interceptor{
@@ -58,5 +75,9 @@ command to dump profile data in easily-parsed format.`,
 		),
 	}
 
+	cmd.Flags().Bool(analyzeprofile.CompareFlagName, false, "Compare a baseline profile with a candidate profile")
+	cmd.Flags().String(analyzeprofile.FormatFlagName, "text", "The output format of the analysis: text or json")
+	cmd.Flags().Int(analyzeprofile.TopFlagName, 10, "The number of slowest actions, mnemonics and targets, or of the largest changes with --compare, to report; 0 reports all of them")
+
 	return cmd
 }
@@ -39,8 +39,10 @@ func NewAQueryCommand(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
 Read [the Bazel aquery documentation](https://bazel.build/query/aquery)
 
 Aspect CLI introduces the second form, where in place of an expression, you can give a preset query name.
-Some preset queries also accept parameters, such as labels of targets, which can be provided as arguments.
-If they are absent and the session is interactive, the user will be prompted to supply these.`,
+Some preset queries also accept parameters, such as labels of targets, which can be provided as arguments,
+either in order or by name such as --targetA=//a. If they are absent and the session is interactive,
+the user will be prompted to supply these, otherwise the command fails and names the missing parameter.
+See 'aspect help query' for how to define preset queries.`,
 		Example: `# Get the action graph generated while building //src/target_a
 $ aspect aquery '//src/target_a'
 
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aquerydiff
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/aquerydiff"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func NewDefaultCmd() *cobra.Command {
+	return NewCmd(ioutils.DefaultStreams, bazel.WorkspaceFromWd)
+}
+
+func NewCmd(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "aquery-diff <expression>",
+		Short: "Compare the action graph of two revisions",
+		Long: `Compares the action graph of the given query expression at two points in time.
+
+Actions are matched by their owner label, mnemonic and primary output. Actions that were
+added or removed are listed, along with the changed command line arguments, environment
+variables and inputs of the actions that exist in both action graphs. This makes it possible
+to review the effect of a toolchain or ruleset upgrade without reading aquery proto dumps.
+
+--before and --after each take either a git revision, which is checked out in a temporary
+git worktree to run the query, or an action graph snapshot file. --after defaults to the
+working tree. Snapshots are written with --save and may also be the output of
+'bazel aquery --output=proto' or '--output=jsonproto'.
+
+Bazel aquery flags such as --config are applied to both sides of the comparison.`,
+		Example: `# Compare the action graph of //app/... in the working tree with the main branch
+% aspect aquery-diff //app/... --before=main
+
+# Save a snapshot before upgrading a toolchain, then compare with it afterwards
+% aspect aquery-diff 'deps(//app)' --save=before.pb
+% aspect aquery-diff 'deps(//app)' --before=before.pb`,
+		GroupID: "aspect",
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			aquerydiff.New(streams, bzl).Run,
+		),
+	}
+
+	aquerydiff.AddFlags(cmd.Flags())
+
+	return cmd
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bes
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/bes"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func NewDefaultCmd() *cobra.Command {
+	return NewCmd(ioutils.DefaultStreams)
+}
+
+func NewCmd(streams ioutils.Streams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bes <subcommand>",
+		Short: "Manage build event stream uploads",
+		Long: `Tools to work with the build event stream that Aspect CLI forwards to the BES backends
+configured with --bes_backend.`,
+		GroupID: "aspect",
+	}
+
+	cmd.AddCommand(NewUploadPendingCmd(streams))
+
+	return cmd
+}
+
+func NewUploadPendingCmd(streams ioutils.Streams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upload-pending",
+		Short: "Upload build events left behind by failed BES uploads",
+		Long: `Uploads the build event journals that earlier invocations could not deliver to their BES backend.
+
+Journals are only written when spooling is enabled in the Aspect CLI config.yaml:
+
+bes:
+  spool: true
+
+Each journal is resumed after the last sequence number that the backend acknowledged and is
+deleted once fully uploaded. Headers are never written to disk, so pass any credentials the
+backend needs with --bes_header or --remote_header. So that the credentials of one backend are
+never sent to another, headers require --bes_backend when journals are pending for several backends.`,
+		Example: `# Upload every pending journal
+% aspect bes upload-pending --remote_header=x-buildbuddy-api-key=$BUILDBUDDY_API_KEY
+
+# Only upload the journals recorded for a single backend
+% aspect bes upload-pending --bes_backend=grpcs://remote.buildbuddy.io --remote_header=x-buildbuddy-api-key=$BUILDBUDDY_API_KEY`,
+		Args: cobra.NoArgs,
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			bes.NewUploadPending(streams).Run,
+		),
+	}
+
+	cmd.Flags().String("bes_backend", "", "Only upload the journals recorded for this BES backend")
+	cmd.Flags().StringArray("bes_header", []string{}, "A header in the form 'name=value' to send to the BES backend; may be repeated")
+	cmd.Flags().StringArray("remote_header", []string{}, "A header in the form 'name=value' to send to the BES backend; may be repeated")
+
+	return cmd
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/aspect-build/aspect-cli/pkg/aspect/build"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/hints"
 	"github.com/aspect-build/aspect-cli/pkg/interceptors"
@@ -46,7 +47,7 @@ func NewCmd(
 	pluginSystem system.PluginSystem,
 	bzl bazel.Bazel,
 ) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "build <target patterns>",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Build the specified targets",
@@ -75,15 +76,23 @@ To create non-default outputs, consider using the ` + "`--output_groups`" + ` fl
 
 The target pattern may be further filtered using the flag
 [--build_tag_filters](https://bazel.build/reference/command-line-reference#flag--build_tag_filters)
+
+Pass --watch to build the targets again whenever one of their source files, BUILD files or .bzl
+files changes, until interrupted with Ctrl+C.
 `,
 		GroupID: "common",
 		RunE: interceptors.Run(
 			[]interceptors.Interceptor{
 				flags.FlagsInterceptor(streams),
+				watch.Interceptor(streams, bzl),
 				pluginSystem.BESBackendInterceptor(),
 				pluginSystem.BuildHooksInterceptor(streams),
 			},
 			build.New(streams, hstreams, bzl).Run,
 		),
 	}
+
+	cmd.Flags().Bool(watch.FlagName, false, "Build the targets again whenever one of their source files changes")
+
+	return cmd
 }
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checkdeps
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/checkdeps"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func NewDefaultCmd() *cobra.Command {
+	return NewCmd(ioutils.DefaultStreams, bazel.WorkspaceFromWd)
+}
+
+func NewCmd(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check-deps [target patterns...]",
+		Short: "Check the dependency graph against layering rules",
+		Long: `Checks that targets only depend on what the dependency rules in .aspect/cli/config.yaml allow.
+
+Each rule applies to the targets that match its 'from' patterns:
+
+- 'deny' lists targets that they must not depend on, directly or transitively.
+- 'allow' lists the only targets that they may depend on directly. Targets in the same package
+  or that also match 'from' are always allowed.
+
+Patterns are labels such as //lib:core, packages such as //lib:all, package trees such as //lib/...
+or @repo//..., and tags such as tag:ui.
+
+    check_deps:
+      rules:
+        - name: ui must not reach the server
+          from: tag:ui
+          deny: //server/...
+        - from: //lib/core/...
+          allow:
+            - //lib/core/...
+            - "@com_github_google_uuid//..."
+
+The rules are evaluated against the 'bazel query' graph of the given target patterns and their
+dependencies, which defaults to //... . Patterns starting with - are excluded. Each violation is
+printed with the shortest path from the offending target to the forbidden dependency, and the
+command exits with code 115 when any rule is violated.
+
+In addition to flags listed below, flags accepted by the 'bazel query' command are also accepted.`,
+		Example: `# Check every target in the main repository
+
+% aspect check-deps
+
+//app/web:web violates "ui must not reach the server":
+    //app/web:web
+    -> //app/web/api:client
+    -> //server/db:db
+
+# Check the targets under //app, except the legacy ones
+
+% aspect check-deps //app/... -//app/legacy/...`,
+		GroupID: "aspect",
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			checkdeps.New(streams, bzl).Run,
+		),
+	}
+}
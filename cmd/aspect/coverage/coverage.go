@@ -45,7 +45,7 @@ func NewCmd(
 	pluginSystem system.PluginSystem,
 	bzl bazel.Bazel,
 ) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "coverage --combined_report=<value> <target pattern> [<target pattern> ...]",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Same as 'test', but also generates a code coverage report.",
@@ -62,15 +62,40 @@ failed tests - only passing tests are reported.
 Read [the Bazel coverage documentation](https://bazel.build/configure/coverage) on gathering code coverage data.
 
 See 'aspect help target-syntax' for details and examples on how to specify targets.
+
+Once the tests finish, the coverage data of this invocation is read from the combined report, or
+from the coverage.dat of each test when there is no combined report, and the line and branch
+coverage is printed per package. Pass --coverage:report=file for a row per source file, or
+--coverage:report=none to only check the thresholds. Pass
+--coverage:diff_base to also report the coverage of the lines changed since a git revision, along
+with the changed lines that the tests didn't cover.
+
+Minimum coverage percentages can be set in the Aspect CLI config.yaml:
+
+coverage:
+  min:
+    lines: 80
+    branches: 60
+    changed_lines: 90
+
+A single number sets the minimum line coverage. When the coverage is below a minimum, or no
+coverage data reaches Aspect CLI to check it, the command fails with exit code 116. Pass
+--remote_download_outputs=all when building without the bytes so that the coverage data is
+downloaded.
 `,
 		GroupID: "built-in",
 		RunE: interceptors.Run(
 			[]interceptors.Interceptor{
 				flags.FlagsInterceptor(streams),
-				pluginSystem.BESBackendInterceptor(),
+				pluginSystem.BESBackendInterceptor(coverage.NeedsBESBackend),
 				pluginSystem.TestHooksInterceptor(streams),
 			},
 			coverage.New(streams, hstreams, bzl).Run,
 		),
 	}
+
+	cmd.Flags().String(coverage.ReportFlagName, "package", "The coverage table printed once the tests finish: package, file or none")
+	cmd.Flags().String(coverage.DiffBaseFlagName, "", "Also report the coverage of the lines changed since this git revision")
+
+	return cmd
 }
@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aspect-build/aspect-cli/pkg/aspect/cquery"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/interceptors"
@@ -43,11 +44,20 @@ Note that cquery is especially powerful as the graph can be processed by a purpo
 written in Starlark. Read [the Bazel cquery output format documentation](https://bazel.build/query/cquery#output-format-definition).
 
 Aspect CLI introduces the second form, where in place of an expression, you can give a preset query name.
-Some preset queries also accept parameters, such as labels of targets, which can be provided as arguments.
-If they are absent and the session is interactive, the user will be prompted to supply these.
+Some preset queries also accept parameters, such as labels of targets, which can be provided as arguments,
+either in order or by name such as --targetA=//a. If they are absent and the session is interactive,
+the user will be prompted to supply these, otherwise the command fails and names the missing parameter.
+See 'aspect help query' for how to define preset queries.
+
+With --graph, the dependency graph of the query result is rendered instead: dot for Graphviz,
+mermaid for pasting into pull requests, or html for a self-contained page where targets can be
+searched and collapsed. --graph_depth limits the graph to the targets closest to its top, and
+--graph_exclude_external leaves out targets in external repositories.
 
 Read [the Bazel cquery documentation](https://bazel.build/query/cquery)
 `,
+		Example: `# Write the deps of //app, two levels deep, to a page that can be searched in a browser
+$ aspect cquery 'deps(//app)' --graph=html --graph_depth=2 --graph_exclude_external > deps.html`,
 		// Note, we should cquery in the "common" commands rather than query, because most users
 		// ought to use cquery most of the time.
 		GroupID: "common",
@@ -59,5 +69,7 @@ Read [the Bazel cquery documentation](https://bazel.build/query/cquery)
 		),
 	}
 
+	graph.AddFlags(cmd.Flags())
+
 	return cmd
 }
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/history"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/test"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system"
+)
+
+func NewDefaultCmd(pluginSystem system.PluginSystem) *cobra.Command {
+	return NewCmd(ioutils.DefaultStreams, pluginSystem, bazel.WorkspaceFromWd)
+}
+
+func NewCmd(streams ioutils.Streams, pluginSystem system.PluginSystem, bzl bazel.Bazel) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history <subcommand>",
+		Short: "Browse and inspect past invocations",
+		Long: `Aspect CLI can record the build event stream of every build, test, run and coverage invocation
+to a local history store so that past invocations can be inspected and replayed.
+
+Recording is off by default since it starts a BES backend for every invocation, through which the
+build events are forwarded to any --bes_backend of the user. The values of --bes_header and
+--remote_header, and any value redacted by the bes.rules that apply to every backend, are never
+recorded. Recording and retention are configured in the Aspect CLI config.yaml:
+
+history:
+  enabled: true
+  max_invocations: 100
+  max_age: 720h`,
+		GroupID: "aspect",
+	}
+
+	cmd.AddCommand(NewFlakesCmd(streams, bzl))
+	cmd.AddCommand(NewListCmd(streams))
+	cmd.AddCommand(NewShowCmd(streams))
+	cmd.AddCommand(NewRerunCmd(streams, pluginSystem, bzl))
+
+	return cmd
+}
+
+func NewListCmd(streams ioutils.Streams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recorded invocations",
+		Long:  "Lists the recorded invocations, most recent first, with their duration, action cache hit rate and outcome.",
+		Example: `# List the last 20 invocations
+% aspect history list
+
+# List every recorded test invocation
+% aspect history list --command=test --limit=0`,
+		Args: cobra.NoArgs,
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			history.NewList(streams).Run,
+		),
+	}
+
+	cmd.Flags().Int("limit", 20, "The maximum number of invocations to list; 0 lists every invocation")
+	cmd.Flags().String("command", "", "Only list invocations of this Bazel command")
+
+	return cmd
+}
+
+func NewShowCmd(streams ioutils.Streams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <invocation id>",
+		Short: "Show the details of a recorded invocation",
+		Long: `Shows the command line, the targets that were built, the failed actions with their stderr and the
+test results of a recorded invocation.
+
+The invocation id may be abbreviated to any unambiguous prefix.`,
+		Example: `% aspect history show 0c4b7a1e`,
+		Args:    cobra.ExactArgs(1),
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			history.NewShow(streams).Run,
+		),
+	}
+}
+
+func NewRerunCmd(streams ioutils.Streams, pluginSystem system.PluginSystem, bzl bazel.Bazel) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rerun <invocation id>",
+		Short: "Replay the command line of a recorded invocation",
+		Long: `Runs the exact canonical command line that Bazel reported for a recorded invocation, from the
+working directory it was originally run in.
+
+The canonical command line includes every option that was expanded from bazelrc files, so the
+replay ignores the current bazelrc files.
+
+The invocation id may be abbreviated to any unambiguous prefix.`,
+		Example: `% aspect history rerun 0c4b7a1e`,
+		Args:    cobra.ExactArgs(1),
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+				pluginSystem.BESBackendInterceptor(),
+			},
+			history.NewRerun(streams, bzl).Run,
+		),
+	}
+}
+
+func NewFlakesCmd(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
+	return &cobra.Command{
+		Use:   "flakes",
+		Short: "List the tests that were flaky in recorded invocations",
+		Long: `Lists the tests of the current workspace that both passed and failed on the same inputs in one of
+the invocations recorded to the local history, most recently flaky first.
+
+Bazel only runs a test more than once on the same inputs when it is retried with
+--flaky_test_attempts or run several times with --runs_per_test, so only those invocations can
+reveal a flaky test.`,
+		Args: cobra.NoArgs,
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			test.NewFlakes(streams, bzl).Run,
+		),
+	}
+}
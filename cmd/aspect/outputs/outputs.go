@@ -43,7 +43,24 @@ You can optionally provide an extra argument, which is a filter on the mnemonic.
 
 'ExecutableHash' is a special value for the mnemonic. This combines the ExecutableSymlink and
 SourceSymlinkManifest mnemonics, then hashes the outputs of these two. This provides a good hash
-for an executable target to determine if it has changed.`,
+for an executable target to determine if it has changed. The digests of hashed files are cached
+in the Aspect cache directory and reused while the path, inode, modification time and size of
+a file are unchanged, which keeps hashing large runfiles trees fast on every build. Pass
+--hash_cache=false to hash every file again.
+
+Use --output=json, jsonl or csv for output that scripts can parse reliably, including paths
+with spaces. Each output file is reported with its label, mnemonic, path and configuration,
+and with --file_size also its size in bytes. With 'ExecutableHash', each label is reported
+with its hash and the files that were hashed. Results are sorted by label and path.
+
+To decide what to redeploy, write the hashes to a manifest with --manifest and pass an older
+manifest with --compare-to. Only the labels whose hash changed, was added or was removed are
+printed, and the command exits with code 114 when any label changed.
+
+With --stage, the matched outputs are copied into a directory for upload instead of printed,
+so CI pipelines don't need to hardcode bazel-bin paths that change with the configuration.
+Runfiles trees are dereferenced next to their executable, and a manifest.json with the label,
+mnemonic, source, size and sha256 of every staged file is written to the directory.`,
 		Example: `# Show all outputs of the //cli/pro target, which is a go_binary:
 
 % aspect outputs //cli/pro
@@ -67,7 +84,19 @@ bazel-out/k8-fastbuild/bin/cli/pro/pro_/pro
 ExecutableSymlink bazel-out/darwin-fastbuild/bin/cli/release
 SourceSymlinkManifest bazel-out/darwin-fastbuild/bin/cli/release.runfiles_manifest
 SymlinkTree bazel-out/darwin-fastbuild/bin/cli/release.runfiles/MANIFEST
-Middleman bazel-out/darwin-fastbuild/internal/_middlemen/cli_Srelease-runfiles`,
+Middleman bazel-out/darwin-fastbuild/internal/_middlemen/cli_Srelease-runfiles
+
+# Write the outputs of //cli/pro with their configuration and size as JSON
+
+% aspect outputs //cli/pro --output=json --file_size
+
+# List the executables that changed since the last release and save the hashes for the next one
+
+% aspect outputs 'attr("tags", "\bdeliverable\b", //...)' ExecutableHash --compare-to=release.json --manifest=next.json
+
+# Collect the executables of //cli/... with their runfiles for upload
+
+% aspect outputs //cli/... ExecutableHash --stage=dist`,
 		GroupID: "aspect",
 		RunE: interceptors.Run(
 			[]interceptors.Interceptor{
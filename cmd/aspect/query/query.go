@@ -20,6 +20,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aspect-build/aspect-cli/pkg/aspect/query"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/interceptors"
@@ -39,7 +40,47 @@ func NewQueryCommand(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
 Note that this ignores the current configuration. Most users should use cquery instead,
 unless you have a specific need to query the unconfigured graph.
 
-Read [the Bazel query documentation](https://bazel.build/query/quickstart)`,
+Read [the Bazel query documentation](https://bazel.build/query/quickstart)
+
+Aspect CLI introduces the second form, where in place of an expression, you can give a preset query name.
+Some preset queries also accept parameters, such as labels of targets, which can be provided as arguments,
+either in order or by name such as --targetA=//a. If they are absent and the session is interactive,
+the user will be prompted to supply these, otherwise the command fails and names the missing parameter.
+
+Preset queries are defined under query.presets in the Aspect CLI config. Each parameter may declare a
+type of string, label, target_pattern or integer, a default and a description, and a preset may set
+flags which are passed to Bazel before those on the command line:
+
+    query:
+      presets:
+        rdeps:
+          description: Get the targets that depend on a target
+          query: rdeps(?universe, ?target, ?depth)
+          verb: query
+          flags: ["--output=label_kind"]
+          parameters:
+            universe:
+              type: target_pattern
+              default: //...
+            target:
+              type: label
+              description: the target to find the dependents of
+            depth:
+              type: integer
+              default: 1
+
+With --graph, the dependency graph of the query result is rendered instead: dot for Graphviz,
+mermaid for pasting into pull requests, or html for a self-contained page where targets can be
+searched and collapsed. --graph_depth limits the graph to the targets closest to its top, and
+--graph_exclude_external leaves out targets in external repositories.`,
+		Example: `# Find why //a depends on //b, prompting for the targets
+$ aspect query why
+
+# Find why //a depends on //b without prompting, for example on CI
+$ aspect query why --targetA=//a --targetB=//b
+
+# Explain to reviewers why //a depends on //b with a Mermaid diagram
+$ aspect query why --targetA=//a --targetB=//b --graph=mermaid`,
 		// Note: we list query in the "built-in" rather than "common" group because most users should
 		// use cquery most of the time.
 		GroupID: "built-in",
@@ -51,5 +92,7 @@ Read [the Bazel query documentation](https://bazel.build/query/quickstart)`,
 		),
 	}
 
+	graph.AddFlags(cmd.Flags())
+
 	return cmd
 }
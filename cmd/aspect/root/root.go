@@ -25,10 +25,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/aspect-build/aspect-cli/buildinfo"
+	"github.com/aspect-build/aspect-cli/cmd/aspect/affected"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/analyzeprofile"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/aquery"
+	"github.com/aspect-build/aspect-cli/cmd/aspect/aquerydiff"
+	"github.com/aspect-build/aspect-cli/cmd/aspect/bes"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/build"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/canonicalizeflags"
+	"github.com/aspect-build/aspect-cli/cmd/aspect/checkdeps"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/clean"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/config"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/configure"
@@ -38,6 +42,7 @@ import (
 	"github.com/aspect-build/aspect-cli/cmd/aspect/dump"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/fetch"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/help"
+	"github.com/aspect-build/aspect-cli/cmd/aspect/history"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/info"
 	init_ "github.com/aspect-build/aspect-cli/cmd/aspect/init"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/license"
@@ -54,6 +59,7 @@ import (
 	"github.com/aspect-build/aspect-cli/cmd/aspect/test"
 	vendor "github.com/aspect-build/aspect-cli/cmd/aspect/vend"
 	"github.com/aspect-build/aspect-cli/cmd/aspect/version"
+	"github.com/aspect-build/aspect-cli/cmd/aspect/whyrebuilt"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
@@ -138,10 +144,14 @@ func NewCmd(
 
 	// ### Child commands
 	// IMPORTANT: when adding a new command, also update the COMMAND_LIST list in /docs/command_list.bzl
+	cmd.AddCommand(affected.NewDefaultCmd())
 	cmd.AddCommand(analyzeprofile.NewDefaultCmd())
 	cmd.AddCommand(aquery.NewDefaultCmd())
+	cmd.AddCommand(aquerydiff.NewDefaultCmd())
+	cmd.AddCommand(bes.NewDefaultCmd())
 	cmd.AddCommand(build.NewDefaultCmd(pluginSystem))
 	cmd.AddCommand(canonicalizeflags.NewDefaultCmd())
+	cmd.AddCommand(checkdeps.NewDefaultCmd())
 	cmd.AddCommand(clean.NewDefaultCmd())
 	cmd.AddCommand(config.NewDefaultCmd())
 	cmd.AddCommand(coverage.NewDefaultCmd(pluginSystem))
@@ -149,6 +159,7 @@ func NewCmd(
 	cmd.AddCommand(dump.NewDefaultCmd())
 	cmd.AddCommand(fetch.NewDefaultCmd())
 	cmd.AddCommand(docs.NewDefaultCmd())
+	cmd.AddCommand(history.NewDefaultCmd(pluginSystem))
 	cmd.AddCommand(info.NewDefaultCmd())
 	cmd.AddCommand(init_.NewDefaultCmd())
 	cmd.AddCommand(mobileinstall.NewDefaultCmd())
@@ -162,6 +173,7 @@ func NewCmd(
 	cmd.AddCommand(test.NewDefaultCmd(pluginSystem))
 	cmd.AddCommand(vendor.NewDefaultCmd())
 	cmd.AddCommand(version.NewDefaultCmd())
+	cmd.AddCommand(whyrebuilt.NewDefaultCmd())
 	cmd.AddCommand(outputs.NewDefaultCmd())
 	cmd.SetHelpCommand(help.NewCmd())
 
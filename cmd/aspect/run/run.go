@@ -19,8 +19,10 @@ package run
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/aspect-build/aspect-cli/pkg/aspect/presets"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/run"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/hints"
 	"github.com/aspect-build/aspect-cli/pkg/interceptors"
@@ -45,7 +47,7 @@ func NewCmd(
 	pluginSystem system.PluginSystem,
 	bzl bazel.Bazel,
 ) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "run [--run_under=command-prefix] <target> -- [args for program ...]",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Build a single target and run it with the given arguments",
@@ -74,16 +76,65 @@ Use the [--run_under](https://bazel.build/docs/user-manual#run_under) flag with
 Another common approach if the program's code is in your repo (first-party) is to check for the
 presence of ` + "`BUILD_WORKSPACE_DIRECTORY`" + ` in the environment, then change the working
 directory of the process. You'd typically do this at the very beginning of the program execution.
+
+To run several targets at the same time, such as the services of an application, pass
+--run:multi: ` + "`aspect run --run:multi //svc/a //svc/b //svc/c`" + `. Without it, the arguments after the
+first target are passed to its program. The targets are built with ` + "`bazel build`" + `, then the
+script of each target is written by a ` + "`bazel run --script_path`" + ` per target, so N targets take
+N+1 Bazel invocations. Only the build sends its events to plugins and --bes_backend, and the runs
+analyze the targets again when the bazelrc has run options that change the configuration. The
+programs then run in parallel, without stdin. Each line of their output is prefixed with the label
+of its target. When one of them fails, the others get SIGTERM, and are killed if they don't exit
+within 5 seconds, unless --run:keep_running is set. The command exits with the exit code of the
+first program that failed. Arguments can't be passed to the programs.
+
+Pass --watch to rebuild the target whenever one of its source files, BUILD files or .bzl files
+changes, until interrupted with Ctrl+C. The program keeps running while the target is rebuilt, and
+is restarted once the rebuild succeeds: it gets SIGTERM, and is killed if it doesn't exit within 5
+seconds. Programs that can reload themselves can instead be told about rebuilds, with the protocol
+of ibazel, by tagging the target ` + "`ibazel_notify_changes`" + `. They are started with
+` + "`IBAZEL_NOTIFY_CHANGES=y`" + ` in their environment, and get the line ` + "`IBAZEL_BUILD_STARTED`" + ` on stdin
+when a rebuild starts, followed by ` + "`IBAZEL_BUILD_COMPLETED SUCCESS`" + ` or
+` + "`IBAZEL_BUILD_COMPLETED FAILURE`" + ` when it finishes.
+
+Named presets can be defined under run.presets in the Aspect CLI config, and run with
+` + "`aspect run @<preset name>`" + `, or ` + "`aspect run @`" + ` to select one interactively. A preset sets the
+targets, the flags passed to Bazel before those on the command line, the environment of the program
+from env and env files of KEY=VALUE lines relative to the workspace root, the arguments of the
+program, and optionally another run preset to run to completion first, such as a database migration.
+The targets of a preset with several targets run at the same time, as with --run:multi. More flags
+and program arguments may follow the preset name:
+
+    run:
+      presets:
+        dev-stack:
+          description: Run the services of the application against a local database
+          targets: ["//svc/api", "//svc/web"]
+          flags: ["--config=dev"]
+          env: ["LOG_LEVEL=debug"]
+          env_files: [".env.dev"]
+          before: migrate
+        migrate:
+          targets: ["//db:migrate"]
+          args: ["--to=latest"]
 `,
 		GroupID:               "common",
 		DisableFlagsInUseLine: true,
 		RunE: interceptors.Run(
 			[]interceptors.Interceptor{
 				flags.FlagsInterceptor(streams),
+				presets.New(streams, bzl, "run", true).Interceptor,
+				watch.Interceptor(streams, bzl),
 				pluginSystem.BESBackendInterceptor(),
 				pluginSystem.RunHooksInterceptor(streams),
 			},
 			run.New(streams, hstreams, bzl).Run,
 		),
 	}
+
+	cmd.Flags().Bool(run.MultiFlagName, false, "Run every target given at the same time, rather than passing the arguments after the first target to its program")
+	cmd.Flags().Bool(run.KeepRunningFlagName, false, "With --run:multi, keep the other programs running when one of them fails")
+	cmd.Flags().Bool(watch.FlagName, false, "Rebuild and restart the program whenever one of the source files of the target changes")
+
+	return cmd
 }
@@ -19,8 +19,10 @@ package test
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/aspect-build/aspect-cli/pkg/aspect/presets"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/test"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/hints"
 	"github.com/aspect-build/aspect-cli/pkg/interceptors"
@@ -45,7 +47,7 @@ func NewCmd(
 	pluginSystem system.PluginSystem,
 	bzl bazel.Bazel,
 ) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "test [--build_tests_only] <target pattern> [<target pattern> ...]",
 		Args:  cobra.MinimumNArgs(1),
 		Short: "Build the specified targets and run all test targets among them",
@@ -71,15 +73,78 @@ defaults for 'build' from your .bazelrc.  If you don't use .bazelrc,
 don't forget to pass all your 'build' options to 'test' too.
 
 See 'aspect help target-syntax' for details and examples on how to specify targets.
+
+When history recording is enabled, test results are recorded to the local history, see
+'aspect history'. Run 'aspect history flakes' to list the tests that were flaky in recorded
+invocations. To be warned when a test that is known to be flaky fails, enable the warning in the
+Aspect CLI config.yaml:
+
+test:
+  warn_known_flaky: true
+
+To rerun only the tests that failed in the last recorded test invocation, pass --retry-failed. Add
+--retry-failed:test_filter to also restrict go_test, java_test and cc_test targets to the failed
+test cases with --test_filter. Bazel applies the same --test_filter to every test, so it is only
+passed when all failed tests are of the same kind.
+
+When tests fail, the end of the test.log of each failed test is printed once the tests finish,
+grouped by target, so there is no need to open the logs under bazel-testlogs by hand. Only the
+last failed attempt of each shard is shown. By default the logs are only printed when Aspect CLI
+already receives the build events of the invocation, for plugins or the local history; pass
+--failed-logs to always print them, which starts a BES backend for the invocation. Pass
+--failed-logs:lines to change how many lines are printed, --failed-logs:test_cases to print the
+failed test cases and their messages from test.xml instead, or --failed-logs=false to turn this
+off. The logs aren't printed again when --test_output already prints them.
+
+To give CI a single test report, pass --junit-report to merge the test.xml files of every test
+attempt of the invocation into one JUnit XML file, and --html-report to also write a static HTML
+summary. Only the results of this invocation are included, unlike a glob of bazel-testlogs, which
+also picks up stale results of earlier runs. The name of each test suite is prefixed with the label
+of its target and, when there are several, the run, shard and attempt. Attempts without a test.xml
+are reported as a single test case named after the target.
+
+Pass --watch to run the tests again whenever one of their source files, BUILD files or .bzl files
+changes, until interrupted with Ctrl+C.
+
+Named presets of tests can be defined under test.presets in the Aspect CLI config, and run with
+` + "`aspect test @<preset name>`" + `, or ` + "`aspect test @`" + ` to select one interactively. A preset sets the
+targets, the flags passed to Bazel before those on the command line, the environment of the tests
+from env and env files of KEY=VALUE lines relative to the workspace root, the arguments of the
+tests, and optionally another test preset to run first. More flags and target patterns may follow
+the preset name:
+
+    test:
+      presets:
+        integration:
+          description: Run the integration tests against a local database
+          targets: ["//services/..."]
+          flags: ["--test_tag_filters=integration"]
+          env: ["DATABASE_URL=postgres://localhost:5432/test"]
+          env_files: [".env.test"]
+          args: ["--verbose"]
+          before: unit
 `,
 		GroupID: "common",
 		RunE: interceptors.Run(
 			[]interceptors.Interceptor{
 				flags.FlagsInterceptor(streams),
-				pluginSystem.BESBackendInterceptor(),
+				presets.New(streams, bzl, "test", true).Interceptor,
+				watch.Interceptor(streams, bzl),
+				pluginSystem.BESBackendInterceptor(test.NeedsBESBackend),
 				pluginSystem.TestHooksInterceptor(streams),
 			},
 			test.New(streams, hstreams, bzl).Run,
 		),
 	}
+
+	cmd.Flags().Bool(test.RetryFailedFlagName, false, "Rerun only the tests whose overall status was FAILED, TIMEOUT or FLAKY in the last recorded test invocation")
+	cmd.Flags().Bool(test.RetryFailedTestFilterFlagName, false, "With --retry-failed, pass the failed test cases from the test.xml files of the last invocation to --test_filter where the test runner supports it")
+	cmd.Flags().Bool(test.FailedLogsFlagName, true, "When tests fail, print the end of the test.log of each failed test, grouped by target; given explicitly, starts a BES backend if none is running")
+	cmd.Flags().Int(test.FailedLogsLinesFlagName, 50, "The number of lines printed from the end of each failed test's log, or of each failed test case with --failed-logs:test_cases")
+	cmd.Flags().Bool(test.FailedLogsTestCasesFlagName, false, "Print the failed test cases from test.xml rather than the end of test.log when they are known")
+	cmd.Flags().String(test.JUnitReportFlagName, "", "Merge the test.xml files of every test attempt into a single JUnit XML report at this path")
+	cmd.Flags().String(test.HTMLReportFlagName, "", "Write a static HTML summary of the test results to this path")
+	cmd.Flags().Bool(watch.FlagName, false, "Run the tests again whenever one of their source files changes")
+
+	return cmd
 }
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package whyrebuilt
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/whyrebuilt"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func NewDefaultCmd() *cobra.Command {
+	return NewCmd(ioutils.DefaultStreams, bazel.WorkspaceFromWd)
+}
+
+func NewCmd(streams ioutils.Streams, bzl bazel.Bazel) *cobra.Command {
+	return &cobra.Command{
+		Use:   "why-rebuilt [<invocation a>] [<invocation b>]",
+		Short: "Explain why targets rebuilt by comparing two invocations",
+		Long: `Compares two invocations from the local history and prints the differences that cause Bazel to
+execute actions again: changed options, changed configurations and changed stable workspace status
+keys. It also prints how many actions were executed rather than served from a cache.
+
+Invocation b defaults to the latest invocation recorded in the current workspace and invocation a
+to the invocation recorded before b in the same workspace. Invocation ids may be abbreviated to any
+unambiguous prefix; run 'aspect history list' to find them.
+
+Successful actions are only reported individually when the build ran with
+--build_event_publish_all_actions.`,
+		Example: `# Explain what changed between the last two builds
+% aspect why-rebuilt
+
+# Compare a specific invocation with the latest one
+% aspect why-rebuilt 0c4b7a1e`,
+		GroupID: "aspect",
+		Args:    cobra.MaximumNArgs(2),
+		RunE: interceptors.Run(
+			[]interceptors.Interceptor{
+				flags.FlagsInterceptor(streams),
+			},
+			whyrebuilt.New(streams, bzl).Run,
+		),
+	}
+}
@@ -2,10 +2,14 @@
 This module contains the list of top-level commands from the aspect CLI.
 """
 COMMAND_LIST = [
+    "affected",
     "analyze-profile",
     "aquery",
+    "aquery-diff",
+    "bes",
     "build",
     "canonicalize-flags",
+    "check-deps",
     "clean",
     "config",
     "configure",
@@ -13,6 +17,7 @@ COMMAND_LIST = [
     "cquery",
     "docs",
     "fetch",
+    "history",
     "info",
     "init",
     "license",
@@ -25,4 +30,5 @@ COMMAND_LIST = [
     "shutdown",
     "test",
     "version",
+    "why-rebuilt",
 ]
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package affected
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+const (
+	BaseFlagName      = "base"
+	TestsOnlyFlagName = "tests_only"
+)
+
+type Affected struct {
+	ioutils.Streams
+	bzl bazel.Bazel
+
+	// changedFiles lists the files that changed in the workspace since the base revision.
+	changedFiles func(workspaceRoot string, base string) ([]change, error)
+}
+
+func New(streams ioutils.Streams, bzl bazel.Bazel) *Affected {
+	return &Affected{
+		Streams:      streams,
+		bzl:          bzl,
+		changedFiles: changedFiles,
+	}
+}
+
+func AddFlags(flagSet *pflag.FlagSet) {
+	flagSet.String(BaseFlagName, "", "The git revision to compare the working tree with, such as the target branch of a pull request")
+	flagSet.Bool(TestsOnlyFlagName, false, "Only print the affected test targets")
+}
+
+func (runner *Affected) Run(_ context.Context, cmd *cobra.Command, args []string) error {
+	var base string
+	var testsOnly bool
+	var flagSet *pflag.FlagSet
+	if cmd != nil {
+		flagSet = cmd.Flags()
+		base, _ = cmd.Flags().GetString(BaseFlagName)
+		testsOnly, _ = cmd.Flags().GetBool(TestsOnlyFlagName)
+	}
+	if base == "" {
+		return fmt.Errorf("--%s is required", BaseFlagName)
+	}
+	args = bazel.RemoveFlags("query", flagSet, args, BaseFlagName, TestsOnlyFlagName)
+
+	// Subtracted patterns, such as -//legacy/..., look like short flags to SeparateBazelFlags.
+	var subtracted []string
+	args = slices.DeleteFunc(args, func(arg string) bool {
+		if strings.HasPrefix(arg, "-/") || strings.HasPrefix(arg, "-@") {
+			subtracted = append(subtracted, arg)
+			return true
+		}
+		return false
+	})
+	patterns, bazelFlags, err := bazel.SeparateBazelFlags("query", args)
+	if err != nil {
+		return err
+	}
+	patterns = universePatterns(append(patterns, subtracted...))
+
+	workspaceRoot := runner.bzl.WorkspaceRoot()
+	changes, err := runner.changedFiles(workspaceRoot, base)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(runner.Stderr, "%d files changed since %s\n", len(changes), base)
+
+	s := classify(workspaceRoot, changes)
+	if s.empty() {
+		return nil
+	}
+
+	var affected string
+	if s.everything {
+		fmt.Fprintf(runner.Stderr, "The workspace configuration or a lockfile changed, so every target is affected\n")
+		affected = universe(patterns)
+	} else {
+		changed, err := runner.changedTargets(s, patterns, bazelFlags)
+		if err != nil {
+			return err
+		}
+		if len(changed) == 0 {
+			return nil
+		}
+		affected = fmt.Sprintf("rdeps(%s, %s)", universe(patterns), strings.Join(changed, " + "))
+	}
+
+	selected := "kind(rule, $affected)"
+	if testsOnly {
+		selected = "tests($affected)"
+	}
+	// Targets tagged manual are skipped by wildcards, so they aren't affected either.
+	query := fmt.Sprintf(`let affected = %s in %s except attr("tags", "\bmanual\b", $affected)`, affected, selected)
+
+	bazelCmd := []string{"query"}
+	bazelCmd = append(bazelCmd, bazelFlags...)
+	bazelCmd = append(bazelCmd, "--output=label", query)
+	return runner.bzl.RunCommand(runner.Streams, nil, bazelCmd...)
+}
+
+// changedTargets returns the target patterns of what changed: every target of the changed
+// packages and of the packages that load a changed .bzl file, and the changed source files.
+func (runner *Affected) changedTargets(s *scope, patterns []string, bazelFlags []string) ([]string, error) {
+	packages := slices.Clone(s.packages)
+
+	if len(s.bzlFiles) > 0 {
+		// rbuildfiles is only available to queries over a fixed universe.
+		query := fmt.Sprintf("rbuildfiles(%s)", strings.Join(quoteAll(s.bzlFiles), ", "))
+		labels, err := runner.queryLabels(append(slices.Clone(bazelFlags), "--universe_scope="+strings.Join(patterns, ","), "--order_output=no"), query)
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			pkg, name, _ := strings.Cut(strings.TrimPrefix(label, "//"), ":")
+			if slices.Contains(buildFiles, name) && !slices.Contains(packages, pkg) {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+
+	changed := make([]string, 0, len(packages)+len(s.sources))
+	for _, pkg := range packages {
+		changed = append(changed, quote(fmt.Sprintf("//%s:*", pkg)))
+	}
+
+	if len(s.sources) > 0 {
+		// Changed files that aren't used by any target, such as a README, aren't targets.
+		var sourcePackages []string
+		for _, label := range s.sources {
+			pkg, _, _ := strings.Cut(label, ":")
+			if wildcard := quote(pkg + ":*"); !slices.Contains(sourcePackages, wildcard) {
+				sourcePackages = append(sourcePackages, wildcard)
+			}
+		}
+		query := fmt.Sprintf(`kind("source file", %s)`, strings.Join(sourcePackages, " + "))
+		labels, err := runner.queryLabels(bazelFlags, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range s.sources {
+			if slices.Contains(labels, label) {
+				changed = append(changed, quote(label))
+			}
+		}
+	}
+
+	return changed, nil
+}
+
+func (runner *Affected) queryLabels(bazelFlags []string, query string) ([]string, error) {
+	var stdout bytes.Buffer
+	streams := ioutils.Streams{Stdin: runner.Stdin, Stdout: &stdout, Stderr: runner.Stderr}
+	bazelCmd := []string{"query"}
+	bazelCmd = append(bazelCmd, bazelFlags...)
+	bazelCmd = append(bazelCmd, "--output=label", query)
+	if err := runner.bzl.RunCommand(streams, nil, bazelCmd...); err != nil {
+		return nil, err
+	}
+	return strings.Fields(stdout.String()), nil
+}
+
+// universePatterns returns the target patterns of the universe, which default to all targets in
+// the main repository. Patterns starting with - are subtracted, as they are on the command line of
+// `bazel build`.
+func universePatterns(patterns []string) []string {
+	if len(patterns) == 0 || strings.HasPrefix(patterns[0], "-") {
+		return append([]string{"//..."}, patterns...)
+	}
+	return patterns
+}
+
+// universe returns the query expression of the universe patterns.
+func universe(patterns []string) string {
+	var b strings.Builder
+	for i, p := range patterns {
+		if negative, ok := strings.CutPrefix(p, "-"); ok {
+			fmt.Fprintf(&b, " - %s", negative)
+		} else if i > 0 {
+			fmt.Fprintf(&b, " + %s", p)
+		} else {
+			b.WriteString(p)
+		}
+	}
+	return b.String()
+}
+
+func quote(word string) string {
+	return `"` + word + `"`
+}
+
+func quoteAll(words []string) []string {
+	result := make([]string, len(words))
+	for i, w := range words {
+		result[i] = quote(w)
+	}
+	return result
+}
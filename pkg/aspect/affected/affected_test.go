@@ -0,0 +1,234 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package affected
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// workspace creates a workspace with the given files.
+func workspace(t *testing.T, files ...string) string {
+	root := t.TempDir()
+	for _, f := range files {
+		p := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func affectedCmd(args ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "affected"}
+	AddFlags(cmd.Flags())
+	if err := cmd.ParseFlags(args); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func TestParseNameStatus(t *testing.T) {
+	t.Run("parses added, modified and deleted files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		changes, err := parseNameStatus("A\x00app/new file.go\x00M\x00app/main.go\x00D\x00app/old.go\x00")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changes).To(Equal([]change{
+			{path: "app/new file.go", added: true},
+			{path: "app/main.go"},
+			{path: "app/old.go", deleted: true},
+		}))
+	})
+
+	t.Run("parses an empty diff", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		changes, err := parseNameStatus("")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changes).To(BeEmpty())
+	})
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Run("lists committed, uncommitted and untracked changes in the workspace", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		repo := workspace(t, "ws/app/BUILD", "ws/app/main.go", "ws/app/old.go", "other/file.txt")
+		run := func(args ...string) {
+			cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+			cmd.Dir = repo
+			out, err := cmd.CombinedOutput()
+			g.Expect(err).NotTo(HaveOccurred(), string(out))
+		}
+		run("init", "--quiet")
+		run("add", "-A")
+		run("commit", "--quiet", "-m", "base")
+		run("branch", "base")
+		g.Expect(os.WriteFile(filepath.Join(repo, "ws/app/main.go"), []byte("changed"), 0644)).To(Succeed())
+		run("commit", "--quiet", "-am", "change")
+		g.Expect(os.Remove(filepath.Join(repo, "ws/app/old.go"))).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(repo, "ws/app/new.go"), []byte("new"), 0644)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(repo, "other/file.txt"), []byte("changed"), 0644)).To(Succeed())
+
+		changes, err := changedFiles(filepath.Join(repo, "ws"), "base")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changes).To(ConsistOf(
+			change{path: "app/main.go"},
+			change{path: "app/old.go", deleted: true},
+			change{path: "app/new.go", added: true},
+		))
+	})
+}
+
+func TestClassify(t *testing.T) {
+	t.Run("maps files to their packages", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		root := workspace(t, "app/BUILD.bazel", "app/lib/BUILD", "tools/BUILD")
+		s := classify(root, []change{
+			{path: "README.md"},
+			{path: "app/main.go"},
+			{path: "app/lib/internal/util.go"},
+			{path: "app/old.go", deleted: true},
+			{path: "tools/BUILD"},
+			{path: "tools/defs.bzl"},
+			{path: "app/clock.go"},
+		})
+		g.Expect(s.everything).To(BeFalse())
+		g.Expect(s.packages).To(Equal([]string{"app", "tools"}))
+		g.Expect(s.sources).To(Equal([]string{"//app:main.go", "//app/lib:internal/util.go", "//app:clock.go"}))
+		g.Expect(s.bzlFiles).To(Equal([]string{"tools/defs.bzl"}))
+	})
+
+	t.Run("includes the parent package of added and deleted BUILD files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		root := workspace(t, "BUILD", "app/lib/BUILD")
+		s := classify(root, []change{{path: "app/lib/BUILD", added: true}})
+		g.Expect(s.packages).To(Equal([]string{"app/lib", ""}))
+	})
+
+	t.Run("affects everything when the workspace configuration or a lockfile changes", func(t *testing.T) {
+		for _, f := range []string{"MODULE.bazel", "MODULE.bazel.lock", ".bazelrc", "deps/go.MODULE.bazel", "web/pnpm-lock.yaml", "requirements_lock.txt", "go.sum", "Cargo.lock"} {
+			g := NewGomegaWithT(t)
+
+			g.Expect(classify(t.TempDir(), []change{{path: f}}).everything).To(BeTrue(), f)
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	writeOutput := func(out string) func(ioutils.Streams, *string, ...string) error {
+		return func(streams ioutils.Streams, _ *string, _ ...string) error {
+			_, err := streams.Stdout.Write([]byte(out))
+			return err
+		}
+	}
+
+	t.Run("queries the reverse dependencies of the changes", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		root := workspace(t, "app/BUILD", "lib/BUILD", "tools/BUILD")
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return(root)
+		gomock.InOrder(
+			bzl.EXPECT().
+				RunCommand(gomock.Any(), nil, "query", "--universe_scope=//...,-//legacy/...", "--order_output=no", "--output=label", `rbuildfiles("tools/defs.bzl")`).
+				DoAndReturn(writeOutput("//lib:BUILD\n//tools:defs.bzl\n")),
+			bzl.EXPECT().
+				RunCommand(gomock.Any(), nil, "query", "--output=label", `kind("source file", "//app:*")`).
+				DoAndReturn(writeOutput("//app:main.go\n")),
+			bzl.EXPECT().
+				RunCommand(gomock.Any(), nil, "query", "--output=label",
+					`let affected = rdeps(//... - //legacy/..., "//lib:*" + "//app:main.go") in tests($affected) except attr("tags", "\bmanual\b", $affected)`).
+				Return(nil),
+		)
+
+		var stderr strings.Builder
+		runner := New(ioutils.Streams{Stderr: &stderr}, bzl)
+		runner.changedFiles = func(workspaceRoot string, base string) ([]change, error) {
+			g.Expect(workspaceRoot).To(Equal(root))
+			g.Expect(base).To(Equal("origin/main"))
+			return []change{{path: "app/main.go"}, {path: "app/README.md"}, {path: "tools/defs.bzl"}}, nil
+		}
+		err := runner.Run(context.Background(), affectedCmd("--base=origin/main", "--tests_only"), []string{"-//legacy/..."})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stderr.String()).To(Equal("3 files changed since origin/main\n"))
+	})
+
+	t.Run("queries every target when the workspace configuration changes", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return(t.TempDir())
+		bzl.EXPECT().
+			RunCommand(gomock.Any(), nil, "query", "--output=label",
+				`let affected = //app/... in kind(rule, $affected) except attr("tags", "\bmanual\b", $affected)`).
+			Return(nil)
+
+		runner := New(ioutils.Streams{Stderr: &strings.Builder{}}, bzl)
+		runner.changedFiles = func(string, string) ([]change, error) {
+			return []change{{path: "MODULE.bazel"}}, nil
+		}
+		g.Expect(runner.Run(context.Background(), affectedCmd("--base=main"), []string{"//app/..."})).To(Succeed())
+	})
+
+	t.Run("prints nothing when no target is affected", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return(t.TempDir())
+
+		runner := New(ioutils.Streams{Stderr: &strings.Builder{}}, bzl)
+		runner.changedFiles = func(string, string) ([]change, error) {
+			return []change{{path: "README.md"}}, nil
+		}
+		g.Expect(runner.Run(context.Background(), affectedCmd("--base=main"), nil)).To(Succeed())
+	})
+
+	t.Run("requires a base revision", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		err := New(ioutils.Streams{}, nil).Run(context.Background(), affectedCmd(), nil)
+		g.Expect(err).To(MatchError("--base is required"))
+	})
+}
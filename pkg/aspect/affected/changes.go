@@ -0,0 +1,213 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package affected
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// change is a file that differs from the base revision, relative to the workspace root.
+type change struct {
+	path    string
+	added   bool
+	deleted bool
+}
+
+// workspaceFiles configure the whole workspace, so a change to any of them may affect every target.
+var workspaceFiles = []string{
+	".bazelignore",
+	".bazelrc",
+	".bazelversion",
+	"MODULE.bazel",
+	"WORKSPACE",
+	"WORKSPACE.bazel",
+	"WORKSPACE.bzlmod",
+}
+
+// Lockfiles pin the external dependencies of the workspace. They are usually read by module
+// extensions and repository rules rather than by targets, so a change to one of them may also
+// affect every target.
+var (
+	lockfiles       = []string{"go.mod", "go.sum", "maven_install.json"}
+	lockfilePattern = regexp.MustCompile(`(\.lock|[-_.]lock\.[a-z]+)$`)
+)
+
+var buildFiles = []string{"BUILD", "BUILD.bazel"}
+
+// scope is what the changes may affect.
+type scope struct {
+	// everything is set when a change may affect every target.
+	everything bool
+	// packages whose BUILD files changed or that lost source files.
+	packages []string
+	// sources are the labels of the changed files, which may or may not be source file targets.
+	sources []string
+	// bzlFiles are the changed .bzl files, relative to the workspace root.
+	bzlFiles []string
+}
+
+func (s *scope) empty() bool {
+	return !s.everything && len(s.packages) == 0 && len(s.sources) == 0 && len(s.bzlFiles) == 0
+}
+
+// changedFiles returns the files in the workspace that differ between the merge base of base and
+// HEAD and the working tree, including uncommitted and untracked files.
+func changedFiles(workspaceRoot string, base string) ([]change, error) {
+	mergeBase, err := git(workspaceRoot, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := git(workspaceRoot, "diff", "--name-status", "--no-renames", "--relative", "-z", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := parseNameStatus(diff)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(workspaceRoot, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(untracked, "\x00") {
+		if p != "" {
+			changes = append(changes, change{path: p, added: true})
+		}
+	}
+	return changes, nil
+}
+
+// parseNameStatus parses the output of `git diff --name-status -z`, which alternates between the
+// status and the path of each file.
+func parseNameStatus(out string) ([]change, error) {
+	out = strings.TrimSuffix(out, "\x00")
+	if out == "" {
+		return nil, nil
+	}
+	fields := strings.Split(out, "\x00")
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("failed to parse git diff output %q", out)
+	}
+	changes := make([]change, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		changes = append(changes, change{
+			path:    fields[i+1],
+			added:   fields[i] == "A",
+			deleted: fields[i] == "D",
+		})
+	}
+	return changes, nil
+}
+
+// classify maps the changes to the packages, source files and .bzl files they touch.
+func classify(workspaceRoot string, changes []change) *scope {
+	s := &scope{}
+	seen := make(map[string]bool)
+	addPackage := func(pkg string) {
+		if !seen[pkg] {
+			seen[pkg] = true
+			s.packages = append(s.packages, pkg)
+		}
+	}
+
+	for _, c := range changes {
+		p := filepath.ToSlash(c.path)
+		dir, name := path.Split(p)
+		dir = strings.TrimSuffix(dir, "/")
+
+		switch {
+		case dir == "" && slices.Contains(workspaceFiles, name), strings.HasSuffix(name, ".MODULE.bazel"):
+			s.everything = true
+		case slices.Contains(lockfiles, name), lockfilePattern.MatchString(name):
+			s.everything = true
+		case slices.Contains(buildFiles, name):
+			if pkg, ok := owningPackage(workspaceRoot, dir); ok {
+				addPackage(pkg)
+			}
+			// An added or deleted BUILD file moves files between the package and its parent.
+			if (c.added || c.deleted) && dir != "" {
+				if pkg, ok := owningPackage(workspaceRoot, parentDir(dir)); ok {
+					addPackage(pkg)
+				}
+			}
+		case strings.HasSuffix(name, ".bzl"):
+			// The packages that loaded a deleted .bzl file must have changed as well.
+			if !c.deleted {
+				s.bzlFiles = append(s.bzlFiles, p)
+			}
+		default:
+			pkg, ok := owningPackage(workspaceRoot, dir)
+			if !ok {
+				continue
+			}
+			if c.deleted {
+				// A deleted file is no longer a target, but the rules that used it are in its
+				// package.
+				addPackage(pkg)
+				continue
+			}
+			name := strings.TrimPrefix(strings.TrimPrefix(p, pkg), "/")
+			s.sources = append(s.sources, fmt.Sprintf("//%s:%s", pkg, name))
+		}
+	}
+	return s
+}
+
+// owningPackage returns the package of the files in dir, which is the closest directory with a
+// BUILD file.
+func owningPackage(workspaceRoot string, dir string) (string, bool) {
+	for {
+		for _, f := range buildFiles {
+			if info, err := os.Stat(filepath.Join(workspaceRoot, filepath.FromSlash(dir), f)); err == nil && !info.IsDir() {
+				return dir, true
+			}
+		}
+		if dir == "" {
+			return "", false
+		}
+		dir = parentDir(dir)
+	}
+}
+
+// parentDir returns the parent of a directory relative to the workspace root, which is the empty
+// string for the root.
+func parentDir(dir string) string {
+	if parent := path.Dir(dir); parent != "." {
+		return parent
+	}
+	return ""
+}
+
+func git(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,306 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyzeprofile
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	phaseMarkerCategory       = "build phase marker"
+	criticalPathCategory      = "critical path component"
+	actionProcessingCategory  = "action processing"
+	skyframeEvaluatorPrefix   = "skyframe-evaluator"
+	unknownMnemonic           = "(unknown)"
+	criticalPathActionPrefix  = "action '"
+	criticalPathActionSuffix  = "'"
+	durationJSONUnitMillisecs = float64(time.Millisecond)
+)
+
+// Duration is a time.Duration that is encoded to JSON as milliseconds.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(d)/durationJSONUnitMillisecs, 'f', -1, 64)), nil
+}
+
+// Phase is the time spent in one of the phases of a build.
+type Phase struct {
+	Name     string   `json:"name"`
+	Duration Duration `json:"duration_ms"`
+}
+
+// Action is a single action that was executed during the build.
+type Action struct {
+	Description string   `json:"description"`
+	Mnemonic    string   `json:"mnemonic"`
+	Target      string   `json:"target,omitempty"`
+	Duration    Duration `json:"duration_ms"`
+}
+
+// ActionGroup is the total time of the actions with the same mnemonic or
+// target.
+type ActionGroup struct {
+	Name    string   `json:"name"`
+	Count   int      `json:"count"`
+	Total   Duration `json:"total_ms"`
+	Average Duration `json:"average_ms"`
+	Slowest Duration `json:"slowest_ms"`
+}
+
+// ThreadUtilization is the fraction of the build that a thread spent doing
+// work.
+type ThreadUtilization struct {
+	Name        string   `json:"name"`
+	Busy        Duration `json:"busy_ms"`
+	Utilization float64  `json:"utilization"`
+}
+
+// Utilization summarizes how busy the Skyframe worker threads were.
+type Utilization struct {
+	Threads   int                  `json:"threads"`
+	Average   float64              `json:"average"`
+	PerThread []*ThreadUtilization `json:"per_thread"`
+}
+
+// Analysis is the result of analyzing a JSON trace profile.
+type Analysis struct {
+	Profile      string         `json:"profile"`
+	WallTime     Duration       `json:"wall_time_ms"`
+	Phases       []*Phase       `json:"phases"`
+	CriticalPath []*Action      `json:"critical_path"`
+	CriticalTime Duration       `json:"critical_path_ms"`
+	Actions      int            `json:"actions"`
+	Slowest      []*Action      `json:"slowest_actions"`
+	Mnemonics    []*ActionGroup `json:"mnemonics"`
+	Targets      []*ActionGroup `json:"targets"`
+	Workers      *Utilization   `json:"worker_utilization"`
+
+	// allActions has every action of the profile, which a comparison of two
+	// profiles needs but which is too large to report.
+	allActions []*Action
+}
+
+// analyze computes the critical path, phase durations, slowest actions and
+// worker thread utilization of a profile. Only the top n slowest actions,
+// mnemonics and targets are kept; n <= 0 keeps all of them.
+func analyze(p *profile, n int) *Analysis {
+	a := &Analysis{Profile: p.path}
+
+	var start, end time.Duration = -1, 0
+	for _, e := range p.events {
+		if start < 0 || e.start() < start {
+			start = e.start()
+		}
+		if e.end() > end {
+			end = e.end()
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	a.WallTime = Duration(end - start)
+
+	a.Phases = phases(p, end)
+	actions, byDescription := actions(p)
+	a.allActions = actions
+	a.Actions = len(actions)
+	a.CriticalPath, a.CriticalTime = criticalPath(p, byDescription)
+
+	a.Slowest = append([]*Action{}, actions...)
+	sort.SliceStable(a.Slowest, func(i, j int) bool { return a.Slowest[i].Duration > a.Slowest[j].Duration })
+	a.Slowest = top(a.Slowest, n)
+	a.Mnemonics = top(groupActions(actions, func(a *Action) string { return a.Mnemonic }), n)
+	a.Targets = top(groupActions(actions, func(a *Action) string { return a.Target }), n)
+	a.Workers = utilization(p, end-start)
+	return a
+}
+
+func top[T any](s []T, n int) []T {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// phases returns the build phases in order. Each phase lasts from its marker
+// until the marker of the next phase or the end of the profile.
+func phases(p *profile, end time.Duration) []*Phase {
+	markers := []*traceEvent{}
+	for _, e := range p.events {
+		if e.Cat == phaseMarkerCategory {
+			markers = append(markers, e)
+		}
+	}
+	sort.SliceStable(markers, func(i, j int) bool { return markers[i].Ts < markers[j].Ts })
+	phases := []*Phase{}
+	for i, m := range markers {
+		phaseEnd := end
+		if i+1 < len(markers) {
+			phaseEnd = markers[i+1].start()
+		}
+		phases = append(phases, &Phase{Name: m.Name, Duration: Duration(phaseEnd - m.start())})
+	}
+	return phases
+}
+
+// actions returns the executed actions and indexes them by description so
+// that critical path components can be matched with them.
+func actions(p *profile) ([]*Action, map[string]*Action) {
+	actions := []*Action{}
+	byDescription := map[string]*Action{}
+	for _, e := range p.events {
+		if e.Cat != actionProcessingCategory || e.Ph != "X" {
+			continue
+		}
+		mnemonic := e.Args.Mnemonic
+		if mnemonic == "" {
+			mnemonic = unknownMnemonic
+		}
+		action := &Action{
+			Description: e.Name,
+			Mnemonic:    mnemonic,
+			Target:      e.Args.Target,
+			Duration:    Duration(e.duration()),
+		}
+		actions = append(actions, action)
+		byDescription[e.Name] = action
+	}
+	return actions, byDescription
+}
+
+func criticalPath(p *profile, byDescription map[string]*Action) ([]*Action, Duration) {
+	components := []*traceEvent{}
+	for _, e := range p.events {
+		if e.Cat == criticalPathCategory {
+			components = append(components, e)
+		}
+	}
+	sort.SliceStable(components, func(i, j int) bool { return components[i].Ts < components[j].Ts })
+	path := []*Action{}
+	var total time.Duration
+	for _, e := range components {
+		description := strings.TrimSuffix(strings.TrimPrefix(e.Name, criticalPathActionPrefix), criticalPathActionSuffix)
+		component := &Action{
+			Description: description,
+			Mnemonic:    unknownMnemonic,
+			Duration:    Duration(e.duration()),
+		}
+		if action, ok := byDescription[description]; ok {
+			component.Mnemonic = action.Mnemonic
+			component.Target = action.Target
+		}
+		path = append(path, component)
+		total += e.duration()
+	}
+	return path, Duration(total)
+}
+
+// groupActions sums the actions by key, slowest group first.
+func groupActions(actions []*Action, key func(*Action) string) []*ActionGroup {
+	groups := map[string]*ActionGroup{}
+	for _, a := range actions {
+		k := key(a)
+		if k == "" {
+			continue
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &ActionGroup{Name: k}
+			groups[k] = g
+		}
+		g.Count++
+		g.Total += a.Duration
+		if a.Duration > g.Slowest {
+			g.Slowest = a.Duration
+		}
+	}
+	result := make([]*ActionGroup, 0, len(groups))
+	for _, g := range groups {
+		g.Average = g.Total / Duration(g.Count)
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// utilization computes the fraction of the wall time that each Skyframe
+// evaluator thread was busy. Nested events are only counted once.
+func utilization(p *profile, wallTime time.Duration) *Utilization {
+	intervals := map[threadID][][2]time.Duration{}
+	for _, e := range p.events {
+		if e.Ph != "X" || e.Dur <= 0 {
+			continue
+		}
+		id := threadID{e.Pid, e.Tid}
+		if !strings.HasPrefix(p.threadNames[id], skyframeEvaluatorPrefix) {
+			continue
+		}
+		intervals[id] = append(intervals[id], [2]time.Duration{e.start(), e.end()})
+	}
+
+	u := &Utilization{PerThread: []*ThreadUtilization{}}
+	if wallTime <= 0 {
+		return u
+	}
+	var sum float64
+	for id, spans := range intervals {
+		sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+		var busy, coveredUntil time.Duration
+		for _, s := range spans {
+			if s[0] > coveredUntil {
+				coveredUntil = s[0]
+			}
+			if s[1] > coveredUntil {
+				busy += s[1] - coveredUntil
+				coveredUntil = s[1]
+			}
+		}
+		t := &ThreadUtilization{
+			Name:        p.threadNames[id],
+			Busy:        Duration(busy),
+			Utilization: float64(busy) / float64(wallTime),
+		}
+		u.PerThread = append(u.PerThread, t)
+		sum += t.Utilization
+	}
+	// Threads that never did any work are idle for the whole build.
+	for id, name := range p.threadNames {
+		if _, ok := intervals[id]; !ok && strings.HasPrefix(name, skyframeEvaluatorPrefix) {
+			u.PerThread = append(u.PerThread, &ThreadUtilization{Name: name})
+		}
+	}
+	sort.Slice(u.PerThread, func(i, j int) bool {
+		if u.PerThread[i].Utilization != u.PerThread[j].Utilization {
+			return u.PerThread[i].Utilization > u.PerThread[j].Utilization
+		}
+		return u.PerThread[i].Name < u.PerThread[j].Name
+	})
+	u.Threads = len(u.PerThread)
+	if u.Threads > 0 {
+		u.Average = sum / float64(u.Threads)
+	}
+	return u
+}
@@ -18,12 +18,24 @@ package analyzeprofile
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
+const (
+	CompareFlagName = "compare"
+	FormatFlagName  = "format"
+	TopFlagName     = "top"
+)
+
+// aspectFlags are the flags handled by Aspect CLI rather than Bazel.
+var aspectFlags = []string{CompareFlagName, FormatFlagName, TopFlagName}
+
 type AnalyzeProfile struct {
 	ioutils.Streams
 	bzl bazel.Bazel
@@ -36,8 +48,114 @@ func New(streams ioutils.Streams, bzl bazel.Bazel) *AnalyzeProfile {
 	}
 }
 
-func (runner *AnalyzeProfile) Run(ctx context.Context, _ *cobra.Command, args []string) error {
-	bazelCmd := []string{"analyze-profile"}
-	bazelCmd = append(bazelCmd, args...)
-	return runner.bzl.RunCommand(runner.Streams, nil, bazelCmd...)
+func (runner *AnalyzeProfile) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	format := textFormat
+	top := 10
+	compare := false
+	var flagSet *pflag.FlagSet
+	if cmd != nil {
+		flagSet = cmd.Flags()
+		compare, _ = cmd.Flags().GetBool(CompareFlagName)
+		if f, _ := cmd.Flags().GetString(FormatFlagName); f != "" {
+			format = f
+		}
+		if n, err := cmd.Flags().GetInt(TopFlagName); err == nil {
+			top = n
+		}
+	}
+	if format != textFormat && format != jsonFormat {
+		return fmt.Errorf("invalid --%s %q: must be %q or %q", FormatFlagName, format, textFormat, jsonFormat)
+	}
+	args = bazel.RemoveFlags("analyze-profile", flagSet, args, aspectFlags...)
+
+	if compare {
+		return runner.compare(args, format, top)
+	}
+
+	native, err := isNative(args)
+	if err != nil {
+		return err
+	}
+	if !native {
+		// Bazel's legacy binary profiles and its --dump option are only
+		// understood by Bazel itself.
+		bazelCmd := []string{"analyze-profile"}
+		bazelCmd = append(bazelCmd, args...)
+		return runner.bzl.RunCommand(runner.Streams, nil, bazelCmd...)
+	}
+
+	analyses := make([]*Analysis, 0, len(args))
+	for _, path := range args {
+		p, err := readProfile(path)
+		if err != nil {
+			return err
+		}
+		analyses = append(analyses, analyze(p, top))
+	}
+
+	if format == jsonFormat {
+		if len(analyses) == 1 {
+			return writeJSON(runner.Stdout, analyses[0])
+		}
+		return writeJSON(runner.Stdout, analyses)
+	}
+	for i, a := range analyses {
+		if i > 0 {
+			fmt.Fprintln(runner.Stdout)
+		}
+		a.writeText(runner.Stdout)
+	}
+	return nil
+}
+
+func (runner *AnalyzeProfile) compare(args []string, format string, top int) error {
+	if len(args) != 2 {
+		return fmt.Errorf("--%s requires a baseline and a candidate profile", CompareFlagName)
+	}
+	analyses := make([]*Analysis, 0, len(args))
+	for _, path := range args {
+		ok, err := isJSONProfile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read profile: %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("%s is not a JSON trace profile; only JSON trace profiles can be compared", path)
+		}
+		p, err := readProfile(path)
+		if err != nil {
+			return err
+		}
+		analyses = append(analyses, analyze(p, 0))
+	}
+
+	c := compareAnalyses(analyses[0], analyses[1], top)
+	if format == jsonFormat {
+		return writeJSON(runner.Stdout, c)
+	}
+	c.writeText(runner.Stdout)
+	return nil
+}
+
+// isNative reports whether the profiles can be analyzed without Bazel, which
+// is the case when no Bazel flags are given and every profile is a JSON trace
+// profile.
+func isNative(args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return false, nil
+		}
+	}
+	for _, path := range args {
+		ok, err := isJSONProfile(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read profile: %w", err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
 }
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyzeprofile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// testProfile is a one second build in which //:a is on the critical path.
+const testProfile = `{"otherData":{"build_id":"1234"},"traceEvents":[
+{"name":"thread_name","ph":"M","pid":1,"tid":1,"args":{"name":"skyframe-evaluator-0"}},
+{"name":"thread_name","ph":"M","pid":1,"tid":2,"args":{"name":"skyframe-evaluator-1"}},
+{"name":"thread_name","ph":"M","pid":1,"tid":3,"args":{"name":"Critical Path"}},
+{"name":"Launch Blaze","cat":"build phase marker","ph":"i","ts":0,"pid":1,"tid":1},
+{"name":"Execute actions","cat":"build phase marker","ph":"i","ts":100000,"pid":1,"tid":1},
+{"name":"Compiling a.cc","cat":"action processing","ph":"X","ts":100000,"dur":500000,"pid":1,"tid":1,"args":{"mnemonic":"CppCompile","target":"//:a"}},
+{"name":"Linking a","cat":"action processing","ph":"X","ts":600000,"dur":400000,"pid":1,"tid":1,"args":{"mnemonic":"CppLink","target":"//:a"}},
+{"name":"Compiling b.cc","cat":"action processing","ph":"X","ts":100000,"dur":200000,"pid":1,"tid":2,"args":{"mnemonic":"CppCompile","target":"//:b"}},
+{"name":"action 'Compiling a.cc'","cat":"critical path component","ph":"X","ts":100000,"dur":500000,"pid":1,"tid":3},
+{"name":"action 'Linking a'","cat":"critical path component","ph":"X","ts":600000,"dur":400000,"pid":1,"tid":3}
+]}`
+
+func writeProfile(t *testing.T, name string, contents string, compress bool) string {
+	path := filepath.Join(t.TempDir(), name)
+	var b bytes.Buffer
+	if compress {
+		w := gzip.NewWriter(&b)
+		w.Write([]byte(contents))
+		w.Close()
+	} else {
+		b.WriteString(contents)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadProfile(t *testing.T) {
+	t.Run("reads gzip compressed profiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, err := readProfile(writeProfile(t, "command.profile.gz", testProfile, true))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(p.events).To(HaveLen(7))
+		g.Expect(p.threadNames).To(HaveLen(3))
+	})
+
+	t.Run("reads the JSON array format", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, err := readProfile(writeProfile(t, "profile.json", `[{"name":"Linking a","cat":"action processing","ph":"X","ts":1,"dur":2}]`, false))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(p.events).To(HaveLen(1))
+	})
+
+	t.Run("reads profiles of interrupted builds", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		truncated := testProfile[:strings.Index(testProfile, `{"name":"Linking a"`)]
+		p, err := readProfile(writeProfile(t, "profile.json", truncated, false))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(p.events).To(HaveLen(3))
+	})
+
+	t.Run("detects JSON profiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ok, err := isJSONProfile(writeProfile(t, "command.profile.gz", testProfile, true))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ok).To(BeTrue())
+		ok, err = isJSONProfile(writeProfile(t, "command.profile", "\x11\x22\x33\x44binary", false))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(ok).To(BeFalse())
+	})
+}
+
+func TestAnalyze(t *testing.T) {
+	g := NewGomegaWithT(t)
+	p, err := readProfile(writeProfile(t, "profile.json", testProfile, false))
+	g.Expect(err).NotTo(HaveOccurred())
+	a := analyze(p, 10)
+
+	t.Run("computes the wall time and phases", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(time.Duration(a.WallTime)).To(Equal(time.Second))
+		g.Expect(a.Phases).To(Equal([]*Phase{
+			{Name: "Launch Blaze", Duration: Duration(100 * time.Millisecond)},
+			{Name: "Execute actions", Duration: Duration(900 * time.Millisecond)},
+		}))
+	})
+
+	t.Run("matches the critical path with actions", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(time.Duration(a.CriticalTime)).To(Equal(900 * time.Millisecond))
+		g.Expect(a.CriticalPath).To(HaveLen(2))
+		g.Expect(*a.CriticalPath[0]).To(Equal(Action{
+			Description: "Compiling a.cc",
+			Mnemonic:    "CppCompile",
+			Target:      "//:a",
+			Duration:    Duration(500 * time.Millisecond),
+		}))
+	})
+
+	t.Run("ranks the slowest actions, mnemonics and targets", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(a.Actions).To(Equal(3))
+		g.Expect(a.Slowest[0].Description).To(Equal("Compiling a.cc"))
+		g.Expect(a.Mnemonics[0].Name).To(Equal("CppCompile"))
+		g.Expect(a.Mnemonics[0].Count).To(Equal(2))
+		g.Expect(time.Duration(a.Mnemonics[0].Total)).To(Equal(700 * time.Millisecond))
+		g.Expect(a.Targets[0].Name).To(Equal("//:a"))
+		g.Expect(time.Duration(a.Targets[0].Total)).To(Equal(900 * time.Millisecond))
+
+		g.Expect(analyze(p, 1).Slowest).To(HaveLen(1))
+	})
+
+	t.Run("computes worker thread utilization", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(a.Workers.Threads).To(Equal(2))
+		g.Expect(a.Workers.PerThread[0].Utilization).To(BeNumerically("~", 0.9, 0.001))
+		g.Expect(a.Workers.PerThread[1].Utilization).To(BeNumerically("~", 0.2, 0.001))
+		g.Expect(a.Workers.Average).To(BeNumerically("~", 0.55, 0.001))
+	})
+}
+
+func analyzeCmd(t *testing.T, args ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "analyze-profile"}
+	cmd.Flags().Bool(CompareFlagName, false, "")
+	cmd.Flags().String(FormatFlagName, "text", "")
+	cmd.Flags().Int(TopFlagName, 10, "")
+	if err := cmd.ParseFlags(args); err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+func TestAnalyzeProfile(t *testing.T) {
+	t.Run("prints the analysis as tables", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path := writeProfile(t, "command.profile.gz", testProfile, true)
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, nil)
+		err := runner.Run(context.Background(), analyzeCmd(t), []string{path})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stdout.String()).To(ContainSubstring("Wall time: 1s"))
+		g.Expect(stdout.String()).To(ContainSubstring("Critical path (900ms):"))
+		g.Expect(stdout.String()).To(MatchRegexp(`500ms\s+CppCompile\s+//:a\s+Compiling a.cc`))
+		g.Expect(stdout.String()).To(MatchRegexp(`2\s+55.0%\s+90.0%\s+20.0%`))
+	})
+
+	t.Run("prints the analysis as JSON", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path := writeProfile(t, "command.profile.gz", testProfile, true)
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, nil)
+		err := runner.Run(context.Background(), analyzeCmd(t, "--format=json"), []string{"--format=json", path})
+		g.Expect(err).NotTo(HaveOccurred())
+		var result map[string]interface{}
+		g.Expect(json.Unmarshal([]byte(stdout.String()), &result)).To(Succeed())
+		g.Expect(result["wall_time_ms"]).To(BeNumerically("==", 1000))
+		g.Expect(result["critical_path_ms"]).To(BeNumerically("==", 900))
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		runner := New(ioutils.Streams{}, nil)
+		err := runner.Run(context.Background(), analyzeCmd(t, "--format=xml"), []string{"profile.json"})
+		g.Expect(err).To(MatchError(ContainSubstring("invalid --format")))
+	})
+}
+
+func TestStripFlags(t *testing.T) {
+	g := NewGomegaWithT(t)
+	args := []string{"--format", "json", "--top=5", "--compare", "--dump=raw", "a.profile"}
+	g.Expect(bazel.RemoveFlags("analyze-profile", analyzeCmd(t).Flags(), args, aspectFlags...)).
+		To(Equal([]string{"--dump=raw", "a.profile"}))
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyzeprofile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// Change is the difference of a duration between the baseline and the
+// candidate profile.
+type Change struct {
+	Name      string   `json:"name,omitempty"`
+	Baseline  Duration `json:"baseline_ms"`
+	Candidate Duration `json:"candidate_ms"`
+	Delta     Duration `json:"delta_ms"`
+}
+
+func newChange(name string, baseline, candidate Duration) *Change {
+	return &Change{Name: name, Baseline: baseline, Candidate: candidate, Delta: candidate - baseline}
+}
+
+// ActionChange is the difference in duration of an action that was executed
+// in both profiles.
+type ActionChange struct {
+	Change
+	Mnemonic string `json:"mnemonic"`
+	Target   string `json:"target,omitempty"`
+}
+
+// Comparison lines up the analyses of a baseline and a candidate profile.
+type Comparison struct {
+	Baseline  string `json:"baseline"`
+	Candidate string `json:"candidate"`
+
+	WallTime     *Change   `json:"wall_time"`
+	CriticalPath *Change   `json:"critical_path"`
+	Phases       []*Change `json:"phases"`
+	Mnemonics    []*Change `json:"mnemonics"`
+
+	// Actions are the actions executed in both profiles whose duration changed
+	// the most.
+	Actions        []*ActionChange `json:"actions"`
+	AddedActions   int             `json:"added_actions"`
+	RemovedActions int             `json:"removed_actions"`
+
+	// SlowerCriticalPath are the actions on the critical path of the
+	// candidate that took longer than in the baseline.
+	SlowerCriticalPath []*ActionChange `json:"slower_critical_path"`
+}
+
+// compareAnalyses compares the analyses of two profiles that were analyzed
+// without a limit. Only the top n mnemonics and actions that changed the most
+// are kept; n <= 0 keeps all of them.
+func compareAnalyses(baseline, candidate *Analysis, n int) *Comparison {
+	c := &Comparison{
+		Baseline:     baseline.Profile,
+		Candidate:    candidate.Profile,
+		WallTime:     newChange("", baseline.WallTime, candidate.WallTime),
+		CriticalPath: newChange("", baseline.CriticalTime, candidate.CriticalTime),
+	}
+
+	// Phases are kept in the order in which they happen.
+	phases := map[string]*Change{}
+	c.Phases = []*Change{}
+	for _, p := range baseline.Phases {
+		change := newChange(p.Name, p.Duration, 0)
+		phases[p.Name] = change
+		c.Phases = append(c.Phases, change)
+	}
+	for _, p := range candidate.Phases {
+		change, ok := phases[p.Name]
+		if !ok {
+			change = newChange(p.Name, 0, 0)
+			phases[p.Name] = change
+			c.Phases = append(c.Phases, change)
+		}
+		change.Candidate += p.Duration
+		change.Delta = change.Candidate - change.Baseline
+	}
+
+	mnemonics := map[string]*Change{}
+	for _, g := range baseline.Mnemonics {
+		mnemonics[g.Name] = newChange(g.Name, g.Total, 0)
+	}
+	for _, g := range candidate.Mnemonics {
+		change, ok := mnemonics[g.Name]
+		if !ok {
+			change = newChange(g.Name, 0, 0)
+			mnemonics[g.Name] = change
+		}
+		change.Candidate = g.Total
+		change.Delta = change.Candidate - change.Baseline
+	}
+	c.Mnemonics = make([]*Change, 0, len(mnemonics))
+	for _, change := range mnemonics {
+		c.Mnemonics = append(c.Mnemonics, change)
+	}
+	sortChanges(c.Mnemonics, func(i int) *Change { return c.Mnemonics[i] })
+	c.Mnemonics = top(c.Mnemonics, n)
+
+	baselineActions := actionsByDescription(baseline.allActions)
+	candidateActions := actionsByDescription(candidate.allActions)
+	c.Actions = []*ActionChange{}
+	for description, a := range candidateActions {
+		b, ok := baselineActions[description]
+		if !ok {
+			c.AddedActions++
+			continue
+		}
+		c.Actions = append(c.Actions, newActionChange(b, a))
+	}
+	for description := range baselineActions {
+		if _, ok := candidateActions[description]; !ok {
+			c.RemovedActions++
+		}
+	}
+	sortChanges(c.Actions, func(i int) *Change { return &c.Actions[i].Change })
+	c.Actions = top(c.Actions, n)
+
+	c.SlowerCriticalPath = []*ActionChange{}
+	for _, a := range candidate.CriticalPath {
+		if b, ok := baselineActions[a.Description]; ok && a.Duration > b.Duration {
+			c.SlowerCriticalPath = append(c.SlowerCriticalPath, newActionChange(b, a))
+		}
+	}
+	sort.SliceStable(c.SlowerCriticalPath, func(i, j int) bool {
+		return c.SlowerCriticalPath[i].Delta > c.SlowerCriticalPath[j].Delta
+	})
+	return c
+}
+
+func newActionChange(baseline, candidate *Action) *ActionChange {
+	return &ActionChange{
+		Change:   *newChange(candidate.Description, baseline.Duration, candidate.Duration),
+		Mnemonic: candidate.Mnemonic,
+		Target:   candidate.Target,
+	}
+}
+
+// actionsByDescription indexes actions by their description, which is the
+// only way to line up the actions of two profiles. Actions with the same
+// description, such as those of a test that ran several times, are summed.
+func actionsByDescription(actions []*Action) map[string]*Action {
+	result := map[string]*Action{}
+	for _, a := range actions {
+		if existing, ok := result[a.Description]; ok {
+			existing.Duration += a.Duration
+			continue
+		}
+		copy := *a
+		result[a.Description] = &copy
+	}
+	return result
+}
+
+// sortChanges sorts by the size of the change, largest first, whether the
+// duration went up or down.
+func sortChanges[T any](s []T, change func(int) *Change) {
+	abs := func(d Duration) Duration {
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.SliceStable(s, func(i, j int) bool {
+		a, b := change(i), change(j)
+		if abs(a.Delta) != abs(b.Delta) {
+			return abs(a.Delta) > abs(b.Delta)
+		}
+		return a.Name < b.Name
+	})
+}
+
+func formatChange(c *Change) string {
+	sign := "+"
+	if c.Delta < 0 {
+		sign = "-"
+	}
+	magnitude := c.Delta
+	if magnitude < 0 {
+		magnitude = -magnitude
+	}
+	if c.Baseline <= 0 {
+		return sign + formatDuration(magnitude)
+	}
+	return fmt.Sprintf("%s%s (%s%.1f%%)", sign, formatDuration(magnitude), sign, 100*float64(magnitude)/float64(c.Baseline))
+}
+
+// writeText prints the comparison as human readable tables.
+func (c *Comparison) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Baseline:  %s\n", c.Baseline)
+	fmt.Fprintf(w, "Candidate: %s\n\n", c.Candidate)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tBASELINE\tCANDIDATE\tCHANGE")
+	writeChange(tw, "Wall time", c.WallTime)
+	writeChange(tw, "Critical path", c.CriticalPath)
+	tw.Flush()
+
+	if len(c.Phases) > 0 {
+		fmt.Fprintf(w, "\nPhases:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  PHASE\tBASELINE\tCANDIDATE\tCHANGE")
+		for _, p := range c.Phases {
+			writeChange(tw, "  "+p.Name, p)
+		}
+		tw.Flush()
+	}
+
+	if len(c.Mnemonics) > 0 {
+		fmt.Fprintf(w, "\nMnemonics:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  MNEMONIC\tBASELINE\tCANDIDATE\tCHANGE")
+		for _, m := range c.Mnemonics {
+			writeChange(tw, "  "+m.Name, m)
+		}
+		tw.Flush()
+	}
+
+	if len(c.Actions) > 0 {
+		fmt.Fprintf(w, "\nActions (%d added, %d removed):\n", c.AddedActions, c.RemovedActions)
+		writeActionChanges(w, c.Actions)
+	}
+
+	if len(c.SlowerCriticalPath) > 0 {
+		fmt.Fprintf(w, "\nCritical path actions that got slower:\n")
+		writeActionChanges(w, c.SlowerCriticalPath)
+	}
+}
+
+func writeChange(w io.Writer, name string, c *Change) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, formatDuration(c.Baseline), formatDuration(c.Candidate), formatChange(c))
+}
+
+func writeActionChanges(w io.Writer, changes []*ActionChange) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  BASELINE\tCANDIDATE\tCHANGE\tMNEMONIC\tTARGET\tDESCRIPTION")
+	for _, a := range changes {
+		target := a.Target
+		if target == "" {
+			target = "-"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n", formatDuration(a.Baseline), formatDuration(a.Candidate), formatChange(&a.Change), a.Mnemonic, target, a.Name)
+	}
+	tw.Flush()
+}
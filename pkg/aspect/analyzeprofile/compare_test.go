@@ -0,0 +1,117 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyzeprofile
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// candidateProfile is testProfile with a slower link of //:a, a faster compile
+// of //:b and a new action.
+var candidateProfile = strings.NewReplacer(
+	`"ts":600000,"dur":400000`, `"ts":600000,"dur":600000`,
+	`"ts":100000,"dur":200000`, `"ts":100000,"dur":100000`,
+	`{"name":"action 'Compiling a.cc'"`, `{"name":"Generating c","cat":"action processing","ph":"X","ts":300000,"dur":1000,"pid":1,"tid":2,"args":{"mnemonic":"Genrule","target":"//:c"}},
+{"name":"action 'Compiling a.cc'"`,
+).Replace(testProfile)
+
+func TestCompare(t *testing.T) {
+	g := NewGomegaWithT(t)
+	baseline, err := readProfile(writeProfile(t, "baseline.json", testProfile, false))
+	g.Expect(err).NotTo(HaveOccurred())
+	candidate, err := readProfile(writeProfile(t, "candidate.json", candidateProfile, false))
+	g.Expect(err).NotTo(HaveOccurred())
+	c := compareAnalyses(analyze(baseline, 0), analyze(candidate, 0), 10)
+
+	t.Run("compares the wall time and phases", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(time.Duration(c.WallTime.Delta)).To(Equal(200 * time.Millisecond))
+		g.Expect(time.Duration(c.CriticalPath.Delta)).To(Equal(200 * time.Millisecond))
+		g.Expect(c.Phases).To(HaveLen(2))
+		g.Expect(c.Phases[0].Name).To(Equal("Launch Blaze"))
+		g.Expect(c.Phases[0].Delta).To(BeZero())
+		g.Expect(time.Duration(c.Phases[1].Delta)).To(Equal(200 * time.Millisecond))
+	})
+
+	t.Run("compares mnemonics by the size of the change", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(c.Mnemonics).To(HaveLen(3))
+		g.Expect(c.Mnemonics[0].Name).To(Equal("CppLink"))
+		g.Expect(time.Duration(c.Mnemonics[0].Delta)).To(Equal(200 * time.Millisecond))
+		g.Expect(c.Mnemonics[1].Name).To(Equal("CppCompile"))
+		g.Expect(time.Duration(c.Mnemonics[1].Delta)).To(Equal(-100 * time.Millisecond))
+	})
+
+	t.Run("lines up actions by description", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(c.AddedActions).To(Equal(1))
+		g.Expect(c.RemovedActions).To(Equal(0))
+		g.Expect(c.Actions).To(HaveLen(3))
+		g.Expect(c.Actions[0].Name).To(Equal("Linking a"))
+		g.Expect(c.Actions[1].Name).To(Equal("Compiling b.cc"))
+		g.Expect(c.Actions[2].Delta).To(BeZero())
+	})
+
+	t.Run("flags critical path actions that got slower", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(c.SlowerCriticalPath).To(HaveLen(1))
+		g.Expect(c.SlowerCriticalPath[0].Name).To(Equal("Linking a"))
+		g.Expect(c.SlowerCriticalPath[0].Target).To(Equal("//:a"))
+	})
+
+	t.Run("prints the comparison", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		baselinePath := writeProfile(t, "baseline.json", testProfile, false)
+		candidatePath := writeProfile(t, "candidate.json.gz", candidateProfile, true)
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, nil)
+		err := runner.Run(context.Background(), analyzeCmd(t, "--compare"), []string{"--compare", baselinePath, candidatePath})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stdout.String()).To(MatchRegexp(`Wall time\s+1s\s+1.2s\s+\+200ms \(\+20.0%\)`))
+		g.Expect(stdout.String()).To(MatchRegexp(`CppCompile\s+700ms\s+600ms\s+-100ms \(-14.3%\)`))
+		g.Expect(stdout.String()).To(ContainSubstring("Critical path actions that got slower:"))
+	})
+
+	t.Run("prints the comparison as JSON", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		baselinePath := writeProfile(t, "baseline.json", testProfile, false)
+		candidatePath := writeProfile(t, "candidate.json", candidateProfile, false)
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, nil)
+		err := runner.Run(context.Background(), analyzeCmd(t, "--compare", "--format=json"), []string{baselinePath, candidatePath})
+		g.Expect(err).NotTo(HaveOccurred())
+		var result map[string]interface{}
+		g.Expect(json.Unmarshal([]byte(stdout.String()), &result)).To(Succeed())
+		g.Expect(result["wall_time"]).To(HaveKeyWithValue("delta_ms", BeNumerically("==", 200)))
+		g.Expect(result["slower_critical_path"]).To(HaveLen(1))
+	})
+
+	t.Run("requires two profiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		runner := New(ioutils.Streams{}, nil)
+		err := runner.Run(context.Background(), analyzeCmd(t, "--compare"), []string{"baseline.json"})
+		g.Expect(err).To(MatchError(ContainSubstring("requires a baseline and a candidate profile")))
+	})
+}
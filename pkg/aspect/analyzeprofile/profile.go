@@ -0,0 +1,194 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyzeprofile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// traceEvent is an event of a JSON trace profile in the Chrome trace event
+// format that Bazel writes with --profile. See
+// https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU.
+type traceEvent struct {
+	Name string    `json:"name"`
+	Cat  string    `json:"cat"`
+	Ph   string    `json:"ph"`
+	Ts   float64   `json:"ts"`
+	Dur  float64   `json:"dur"`
+	Pid  int       `json:"pid"`
+	Tid  int       `json:"tid"`
+	Args traceArgs `json:"args"`
+}
+
+type traceArgs struct {
+	// Name is set on thread_name metadata events.
+	Name     string `json:"name"`
+	Target   string `json:"target"`
+	Mnemonic string `json:"mnemonic"`
+}
+
+func (e *traceEvent) start() time.Duration {
+	return microseconds(e.Ts)
+}
+
+func (e *traceEvent) duration() time.Duration {
+	return microseconds(e.Dur)
+}
+
+func (e *traceEvent) end() time.Duration {
+	return microseconds(e.Ts + e.Dur)
+}
+
+func microseconds(us float64) time.Duration {
+	return time.Duration(us * float64(time.Microsecond))
+}
+
+type threadID struct {
+	pid, tid int
+}
+
+// profile is a parsed JSON trace profile.
+type profile struct {
+	path        string
+	events      []*traceEvent
+	threadNames map[threadID]string
+}
+
+// isJSONProfile reports whether path is a JSON trace profile, optionally
+// gzip compressed, rather than a profile in Bazel's legacy binary format.
+func isJSONProfile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	r, err := decompress(f)
+	if err != nil {
+		return false, nil
+	}
+	b := make([]byte, 64)
+	n, _ := io.ReadFull(r, b)
+	b = bytes.TrimSpace(b[:n])
+	return len(b) > 0 && (b[0] == '{' || b[0] == '['), nil
+}
+
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+// readProfile parses the JSON trace profile at path. The trace events are
+// decoded one at a time since profiles of large builds can be hundreds of
+// megabytes.
+func readProfile(path string) (*profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+	defer f.Close()
+	r, err := decompress(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile %s: %w", path, err)
+	}
+
+	p := &profile{path: path, threadNames: map[threadID]string{}}
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	switch tok {
+	case json.Delim('['):
+		// The JSON array format only has the trace events.
+		if err := p.decodeEvents(dec); err != nil {
+			return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				if isTruncated(err) {
+					break
+				}
+				return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+			}
+			if key != "traceEvents" {
+				var skip json.RawMessage
+				if err := dec.Decode(&skip); err != nil {
+					return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+				}
+				continue
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+			}
+			if err := p.decodeEvents(dec); err != nil {
+				return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a JSON trace profile", path)
+	}
+	return p, nil
+}
+
+// decodeEvents decodes the elements of the trace event array up to and
+// including its closing bracket.
+func (p *profile) decodeEvents(dec *json.Decoder) error {
+	for dec.More() {
+		e := &traceEvent{}
+		if err := dec.Decode(e); err != nil {
+			if isTruncated(err) {
+				return nil
+			}
+			return err
+		}
+		if e.Ph == "M" {
+			if e.Name == "thread_name" {
+				p.threadNames[threadID{e.Pid, e.Tid}] = e.Args.Name
+			}
+			continue
+		}
+		p.events = append(p.events, e)
+	}
+	if _, err := dec.Token(); err != nil && !isTruncated(err) {
+		return err
+	}
+	return nil
+}
+
+// isTruncated reports whether err is caused by the end of a profile that
+// Bazel didn't finish writing, which happens when a build is interrupted.
+func isTruncated(err error) bool {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Error() == "unexpected end of JSON input"
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
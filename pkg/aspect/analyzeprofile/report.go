@@ -0,0 +1,116 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package analyzeprofile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+)
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func formatDuration(d Duration) string {
+	return time.Duration(d).Round(time.Millisecond).String()
+}
+
+func formatPercent(d, total Duration) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(d)/float64(total))
+}
+
+// writeText prints the analysis as human readable tables.
+func (a *Analysis) writeText(w io.Writer) {
+	fmt.Fprintf(w, "Profile:   %s\n", a.Profile)
+	fmt.Fprintf(w, "Wall time: %s\n", formatDuration(a.WallTime))
+	fmt.Fprintf(w, "Actions:   %d\n", a.Actions)
+
+	if len(a.Phases) > 0 {
+		fmt.Fprintf(w, "\nPhases:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  PHASE\tDURATION\tSHARE")
+		for _, p := range a.Phases {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", p.Name, formatDuration(p.Duration), formatPercent(p.Duration, a.WallTime))
+		}
+		tw.Flush()
+	}
+
+	if len(a.CriticalPath) > 0 {
+		fmt.Fprintf(w, "\nCritical path (%s):\n", formatDuration(a.CriticalTime))
+		writeActions(w, a.CriticalPath)
+	}
+
+	if len(a.Slowest) > 0 {
+		fmt.Fprintf(w, "\nSlowest actions:\n")
+		writeActions(w, a.Slowest)
+	}
+
+	if len(a.Mnemonics) > 0 {
+		fmt.Fprintf(w, "\nSlowest mnemonics:\n")
+		writeActionGroups(w, "MNEMONIC", a.Mnemonics)
+	}
+
+	if len(a.Targets) > 0 {
+		fmt.Fprintf(w, "\nSlowest targets:\n")
+		writeActionGroups(w, "TARGET", a.Targets)
+	}
+
+	if a.Workers != nil && a.Workers.Threads > 0 {
+		fmt.Fprintf(w, "\nWorker thread utilization:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  THREADS\tAVERAGE\tBUSIEST\tIDLEST")
+		busiest := a.Workers.PerThread[0]
+		idlest := a.Workers.PerThread[len(a.Workers.PerThread)-1]
+		fmt.Fprintf(tw, "  %d\t%.1f%%\t%.1f%%\t%.1f%%\n", a.Workers.Threads, 100*a.Workers.Average, 100*busiest.Utilization, 100*idlest.Utilization)
+		tw.Flush()
+	}
+}
+
+func writeActions(w io.Writer, actions []*Action) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  DURATION\tMNEMONIC\tTARGET\tDESCRIPTION")
+	for _, a := range actions {
+		target := a.Target
+		if target == "" {
+			target = "-"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", formatDuration(a.Duration), a.Mnemonic, target, a.Description)
+	}
+	tw.Flush()
+}
+
+func writeActionGroups(w io.Writer, header string, groups []*ActionGroup) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  %s\tACTIONS\tTOTAL\tAVERAGE\tSLOWEST\n", header)
+	for _, g := range groups {
+		fmt.Fprintf(tw, "  %s\t%d\t%s\t%s\t%s\n", g.Name, g.Count, formatDuration(g.Total), formatDuration(g.Average), formatDuration(g.Slowest))
+	}
+	tw.Flush()
+}
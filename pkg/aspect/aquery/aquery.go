@@ -69,18 +69,20 @@ func (runner *AQuery) Run(ctx context.Context, cmd *cobra.Command, args []string
 		return shared.GetPrettyError(cmd, err)
 	}
 
-	command, query, runReplacements, err := shared.SelectQuery(cmd.CalledAs(), presets, runner.Presets, presetNames, runner.Streams, nonFlags, flags, runner.Select)
+	command, query, preset, err := shared.SelectQuery(cmd.CalledAs(), presets, runner.Presets, presetNames, runner.Streams, nonFlags, flags, runner.Select)
 	if err != nil {
 		return shared.GetPrettyError(cmd, err)
 	}
 
-	if runReplacements {
-		query, err = shared.ReplacePlaceholders(query, nonFlags, runner.Prompt)
+	if preset != nil {
+		var otherFlags []string
+		query, otherFlags, err = shared.ExpandPreset(preset, runner.Streams, nonFlags, shared.IsInteractive(cmd, runner.IsInteractive), runner.Prompt)
 		if err != nil {
 			return shared.GetPrettyError(cmd, err)
 		}
 
-		return shared.RunQuery(runner.Bzl, command, runner.Streams, append(flags, query))
+		bazelFlags := append(append(append([]string{}, preset.Flags...), flags...), otherFlags...)
+		return shared.RunQuery(runner.Bzl, command, runner.Streams, append(bazelFlags, query))
 	} else {
 		return shared.RunQuery(runner.Bzl, command, runner.Streams, args)
 	}
@@ -0,0 +1,210 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aquerydiff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+const (
+	BeforeFlagName = "before"
+	AfterFlagName  = "after"
+	SaveFlagName   = "save"
+)
+
+type AQueryDiff struct {
+	ioutils.Streams
+	bzl bazel.Bazel
+
+	// newBazel creates the Bazel of a git worktree in which an earlier revision
+	// is checked out.
+	newBazel func(workspaceRoot string) bazel.Bazel
+}
+
+func New(streams ioutils.Streams, bzl bazel.Bazel) *AQueryDiff {
+	return &AQueryDiff{
+		Streams:  streams,
+		bzl:      bzl,
+		newBazel: bazel.New,
+	}
+}
+
+func AddFlags(flagSet *pflag.FlagSet) {
+	flagSet.String(BeforeFlagName, "", "The git revision or action graph snapshot to compare against")
+	flagSet.String(AfterFlagName, "", "The git revision or action graph snapshot to compare; defaults to the working tree")
+	flagSet.String(SaveFlagName, "", "Save the action graph of --after to this file for a later comparison")
+}
+
+func (runner *AQueryDiff) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	var before, after, save string
+	var flagSet *pflag.FlagSet
+	if cmd != nil {
+		flagSet = cmd.Flags()
+		before, _ = cmd.Flags().GetString(BeforeFlagName)
+		after, _ = cmd.Flags().GetString(AfterFlagName)
+		save, _ = cmd.Flags().GetString(SaveFlagName)
+	}
+	args = bazel.RemoveFlags("aquery", flagSet, args, BeforeFlagName, AfterFlagName, SaveFlagName)
+	nonBazelFlags, bazelFlags, err := bazel.SeparateBazelFlags("aquery", args)
+	if err != nil {
+		return err
+	}
+	if before == "" && save == "" {
+		return fmt.Errorf("--%s is required unless the action graph is only saved with --%s", BeforeFlagName, SaveFlagName)
+	}
+
+	// The query is empty when it is given with --query_file.
+	var query string
+	switch len(nonBazelFlags) {
+	case 0:
+		hasQueryFile := false
+		for _, f := range bazelFlags {
+			if strings.HasPrefix(f, "--query_file") {
+				hasQueryFile = true
+			}
+		}
+		if !hasQueryFile {
+			return fmt.Errorf("a query expression is required as the first argument to aquery-diff")
+		}
+	case 1:
+		query = nonBazelFlags[0]
+	default:
+		return fmt.Errorf("expecting a single query expression but got %v arguments", len(nonBazelFlags))
+	}
+
+	afterGraph, err := runner.actionGraph(after, query, bazelFlags)
+	if err != nil {
+		return err
+	}
+	if save != "" {
+		if err := writeSnapshot(save, afterGraph); err != nil {
+			return err
+		}
+		fmt.Fprintf(runner.Stderr, "Saved the action graph of %d actions to %s\n", len(afterGraph.Actions), save)
+	}
+	if before == "" {
+		return nil
+	}
+	beforeGraph, err := runner.actionGraph(before, query, bazelFlags)
+	if err != nil {
+		return err
+	}
+
+	diffActionGraphs(parseActionGraph(beforeGraph), parseActionGraph(afterGraph)).write(runner.Stdout)
+	return nil
+}
+
+// actionGraph returns the action graph of a snapshot file, of a git revision
+// or, when source is empty, of the working tree.
+func (runner *AQueryDiff) actionGraph(source string, query string, bazelFlags []string) (*analysis.ActionGraphContainer, error) {
+	if source == "" {
+		return runner.bzl.AQuery(query, bazelFlags)
+	}
+	if info, err := os.Stat(source); err == nil && !info.IsDir() {
+		return readSnapshot(source)
+	}
+	return runner.actionGraphAtRevision(source, query, bazelFlags)
+}
+
+// actionGraphAtRevision runs the query in a temporary git worktree in which
+// rev is checked out, leaving the working tree untouched.
+func (runner *AQueryDiff) actionGraphAtRevision(rev string, query string, bazelFlags []string) (*analysis.ActionGraphContainer, error) {
+	workspaceRoot := runner.bzl.WorkspaceRoot()
+	commit, err := git(workspaceRoot, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither an action graph snapshot nor a git revision", rev)
+	}
+	gitRoot, err := git(workspaceRoot, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	// The workspace may be in a subdirectory of the git repository.
+	rel, err := filepath.Rel(gitRoot, workspaceRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.MkdirTemp("", "aspect-aquery-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	worktree := filepath.Join(tmp, "worktree")
+	if _, err := git(workspaceRoot, "worktree", "add", "--detach", worktree, commit); err != nil {
+		return nil, err
+	}
+	defer git(workspaceRoot, "worktree", "remove", "--force", worktree)
+
+	fmt.Fprintf(runner.Stderr, "Querying the action graph at %s\n", rev)
+	wd := filepath.Join(worktree, rel)
+	bzl := runner.newBazel(wd)
+	// The worktree has its own output base; don't leave its server running.
+	defer bzl.RunCommand(ioutils.Streams{}, &wd, "shutdown")
+	return aquery(bzl, wd, query, bazelFlags)
+}
+
+// aquery runs `bazel aquery` in wd like bazel.Bazel.AQuery does in the working
+// directory.
+func aquery(bzl bazel.Bazel, wd string, query string, bazelFlags []string) (*analysis.ActionGraphContainer, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"aquery"}
+	cmd = append(cmd, bazelFlags...)
+	cmd = append(cmd, "--output=proto")
+	if query != "" {
+		cmd = append(cmd, "--", query)
+	}
+	if err := bzl.RunCommand(ioutils.Streams{Stdout: &stdout, Stderr: &stderr}, &wd, cmd...); err != nil {
+		var exitErr *aspecterrors.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to run aquery: %w\nstderr:\n%s", err, stderr.String())
+		}
+		return nil, fmt.Errorf("failed to run aquery: %w", err)
+	}
+	agc := &analysis.ActionGraphContainer{}
+	if err := proto.Unmarshal(stdout.Bytes(), agc); err != nil {
+		return nil, fmt.Errorf("failed to run Bazel aquery: parsing ActionGraphContainer: %w", err)
+	}
+	return agc, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
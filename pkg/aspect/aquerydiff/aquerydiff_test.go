@@ -0,0 +1,306 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aquerydiff
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// graphBuilder builds action graphs the way Bazel encodes them, with paths
+// stored as a trie of path fragments and inputs as nested sets.
+type graphBuilder struct {
+	agc       *analysis.ActionGraphContainer
+	fragments map[string]uint32
+	artifacts map[string]uint32
+	targets   map[string]uint32
+	nextID    uint32
+}
+
+func newGraphBuilder() *graphBuilder {
+	return &graphBuilder{
+		agc:       &analysis.ActionGraphContainer{},
+		fragments: map[string]uint32{},
+		artifacts: map[string]uint32{},
+		targets:   map[string]uint32{},
+	}
+}
+
+func (b *graphBuilder) id() uint32 {
+	b.nextID++
+	return b.nextID
+}
+
+func (b *graphBuilder) fragment(path string) uint32 {
+	if id, ok := b.fragments[path]; ok {
+		return id
+	}
+	var parent uint32
+	label := path
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		parent = b.fragment(path[:i])
+		label = path[i+1:]
+	}
+	id := b.id()
+	b.fragments[path] = id
+	b.agc.PathFragments = append(b.agc.PathFragments, &analysis.PathFragment{Id: id, Label: label, ParentId: parent})
+	return id
+}
+
+func (b *graphBuilder) artifact(path string) uint32 {
+	if id, ok := b.artifacts[path]; ok {
+		return id
+	}
+	id := b.id()
+	b.artifacts[path] = id
+	b.agc.Artifacts = append(b.agc.Artifacts, &analysis.Artifact{Id: id, PathFragmentId: b.fragment(path)})
+	return id
+}
+
+func (b *graphBuilder) target(label string) uint32 {
+	if id, ok := b.targets[label]; ok {
+		return id
+	}
+	id := b.id()
+	b.targets[label] = id
+	b.agc.Targets = append(b.agc.Targets, &analysis.Target{Id: id, Label: label})
+	return id
+}
+
+// depSet puts the last input into a transitive set of the others.
+func (b *graphBuilder) depSet(inputs []string) uint32 {
+	set := &analysis.DepSetOfFiles{Id: b.id()}
+	for i, input := range inputs {
+		if i == len(inputs)-1 && i > 0 {
+			nested := &analysis.DepSetOfFiles{Id: b.id(), DirectArtifactIds: []uint32{b.artifact(input)}}
+			b.agc.DepSetOfFiles = append(b.agc.DepSetOfFiles, nested)
+			set.TransitiveDepSetIds = append(set.TransitiveDepSetIds, nested.Id)
+			continue
+		}
+		set.DirectArtifactIds = append(set.DirectArtifactIds, b.artifact(input))
+	}
+	b.agc.DepSetOfFiles = append(b.agc.DepSetOfFiles, set)
+	return set.Id
+}
+
+func (b *graphBuilder) action(label, mnemonic, output string, args []string, env map[string]string, inputs ...string) *graphBuilder {
+	a := &analysis.Action{
+		TargetId:        b.target(label),
+		Mnemonic:        mnemonic,
+		Arguments:       args,
+		OutputIds:       []uint32{b.artifact(output)},
+		PrimaryOutputId: b.artifact(output),
+		InputDepSetIds:  []uint32{b.depSet(inputs)},
+	}
+	for k, v := range env {
+		a.EnvironmentVariables = append(a.EnvironmentVariables, &analysis.KeyValuePair{Key: k, Value: v})
+	}
+	b.agc.Actions = append(b.agc.Actions, a)
+	return b
+}
+
+func beforeGraph() *analysis.ActionGraphContainer {
+	return newGraphBuilder().
+		action("//app:lib", "GoCompilePkg", "bazel-out/k8-fastbuild/bin/app/lib.a",
+			[]string{"compile", "-trimpath", "-o", "lib.a"},
+			map[string]string{"GOOS": "linux", "CGO_ENABLED": "1"},
+			"app/lib.go", "external/go_sdk/pkg/linux_amd64/fmt.a").
+		action("//app:bin", "GoLink", "bazel-out/k8-fastbuild/bin/app/bin",
+			[]string{"link", "-o", "bin", "lib.a"}, nil,
+			"bazel-out/k8-fastbuild/bin/app/lib.a").
+		action("//app:gen", "Genrule", "bazel-out/k8-fastbuild/bin/app/gen.txt",
+			[]string{"/bin/bash", "-c", "echo hi"}, nil).
+		agc
+}
+
+func afterGraph() *analysis.ActionGraphContainer {
+	return newGraphBuilder().
+		action("//app:lib", "GoCompilePkg", "bazel-out/k8-fastbuild/bin/app/lib.a",
+			[]string{"compile", "-trimpath", "-pgo", "-o", "lib.a"},
+			map[string]string{"GOOS": "linux", "CGO_ENABLED": "0", "GOAMD64": "v3"},
+			"app/lib.go", "external/go_sdk_1_22/pkg/linux_amd64/fmt.a").
+		action("//app:bin", "GoLink", "bazel-out/k8-fastbuild/bin/app/bin",
+			[]string{"link", "lib.a", "-o", "bin"}, nil,
+			"bazel-out/k8-fastbuild/bin/app/lib.a").
+		action("//app:test", "TestRunner", "bazel-out/k8-fastbuild/testlogs/app/test/test.log",
+			[]string{"test.sh"}, nil).
+		agc
+}
+
+func TestParseActionGraph(t *testing.T) {
+	g := NewGomegaWithT(t)
+	actions := parseActionGraph(beforeGraph())
+	g.Expect(actions).To(HaveLen(3))
+
+	lib := actions[actionKey{"//app:lib", "GoCompilePkg", "bazel-out/k8-fastbuild/bin/app/lib.a"}]
+	g.Expect(lib).NotTo(BeNil())
+	g.Expect(lib.arguments).To(Equal([]string{"compile", "-trimpath", "-o", "lib.a"}))
+	g.Expect(lib.env).To(Equal(map[string]string{"GOOS": "linux", "CGO_ENABLED": "1"}))
+	g.Expect(lib.inputs).To(Equal([]string{"app/lib.go", "external/go_sdk/pkg/linux_amd64/fmt.a"}))
+}
+
+func TestDiffActionGraphs(t *testing.T) {
+	d := diffActionGraphs(parseActionGraph(beforeGraph()), parseActionGraph(afterGraph()))
+
+	t.Run("reports added and removed actions", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(d.added).To(HaveLen(1))
+		g.Expect(d.added[0].label).To(Equal("//app:test"))
+		g.Expect(d.removed).To(HaveLen(1))
+		g.Expect(d.removed[0].label).To(Equal("//app:gen"))
+		g.Expect(d.unchanged).To(Equal(0))
+	})
+
+	t.Run("reports changed arguments, env and inputs", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(d.changed).To(HaveLen(2))
+		lib, link := d.changed[0], d.changed[1]
+
+		g.Expect(lib.key.label).To(Equal("//app:lib"))
+		g.Expect(lib.addedArguments).To(Equal([]string{"-pgo"}))
+		g.Expect(lib.removedArguments).To(BeEmpty())
+		g.Expect(lib.env).To(Equal([]envChange{
+			{name: "CGO_ENABLED", before: "1", after: "0"},
+			{name: "GOAMD64", after: "v3", added: true},
+		}))
+		g.Expect(lib.removedInputs).To(Equal([]string{"external/go_sdk/pkg/linux_amd64/fmt.a"}))
+		g.Expect(lib.addedInputs).To(Equal([]string{"external/go_sdk_1_22/pkg/linux_amd64/fmt.a"}))
+
+		g.Expect(link.key.label).To(Equal("//app:bin"))
+		g.Expect(link.argumentsReordered).To(BeTrue())
+	})
+
+	t.Run("prints the diff", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var out strings.Builder
+		d.write(&out)
+		g.Expect(out.String()).To(ContainSubstring("  + TestRunner //app:test bazel-out/k8-fastbuild/testlogs/app/test/test.log\n"))
+		g.Expect(out.String()).To(ContainSubstring("  - Genrule //app:gen bazel-out/k8-fastbuild/bin/app/gen.txt\n"))
+		g.Expect(out.String()).To(ContainSubstring("        ~ CGO_ENABLED: 1 -> 0\n"))
+		g.Expect(out.String()).To(ContainSubstring("        + -pgo\n"))
+		g.Expect(out.String()).To(HaveSuffix("1 added, 1 removed, 2 changed, 0 unchanged actions\n"))
+	})
+}
+
+func TestDiffMultiset(t *testing.T) {
+	g := NewGomegaWithT(t)
+	removed, added := diffMultiset([]string{"-c", "a", "-c", "b"}, []string{"-c", "b", "c"})
+	g.Expect(removed).To(Equal([]string{"a", "-c"}))
+	g.Expect(added).To(Equal([]string{"c"}))
+}
+
+func TestSnapshot(t *testing.T) {
+	t.Run("round trips through --save", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path := filepath.Join(t.TempDir(), "graph.pb")
+		g.Expect(writeSnapshot(path, beforeGraph())).To(Succeed())
+		agc, err := readSnapshot(path)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(parseActionGraph(agc)).To(Equal(parseActionGraph(beforeGraph())))
+	})
+
+	t.Run("reads jsonproto output", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		b, err := protojson.Marshal(beforeGraph())
+		g.Expect(err).NotTo(HaveOccurred())
+		path := filepath.Join(t.TempDir(), "graph.json")
+		g.Expect(os.WriteFile(path, b, 0644)).To(Succeed())
+		agc, err := readSnapshot(path)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(agc.Actions).To(HaveLen(3))
+	})
+}
+
+func TestAQueryDiff(t *testing.T) {
+	diffCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "aquery-diff"}
+		AddFlags(cmd.Flags())
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return cmd
+	}
+
+	t.Run("compares a snapshot with the working tree", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		snapshot := filepath.Join(t.TempDir(), "before.pb")
+		g.Expect(writeSnapshot(snapshot, beforeGraph())).To(Succeed())
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().AQuery("//app/...", []string{}).Return(afterGraph(), nil)
+
+		var stdout, stderr strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout, Stderr: &stderr}, bzl)
+		err := runner.Run(context.Background(), diffCmd("--before="+snapshot), []string{"//app/..."})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stdout.String()).To(ContainSubstring("Changed actions (2):"))
+	})
+
+	t.Run("saves a snapshot", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		snapshot := filepath.Join(t.TempDir(), "after.pb")
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().AQuery("//app/...", []string{}).Return(afterGraph(), nil)
+
+		var stdout, stderr strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout, Stderr: &stderr}, bzl)
+		err := runner.Run(context.Background(), diffCmd("--save", snapshot), []string{"//app/..."})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stdout.String()).To(BeEmpty())
+		g.Expect(stderr.String()).To(ContainSubstring("Saved the action graph of 3 actions"))
+		g.Expect(snapshot).To(BeAnExistingFile())
+	})
+
+	t.Run("requires something to compare against", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		runner := New(ioutils.Streams{}, nil)
+		err := runner.Run(context.Background(), diffCmd(), []string{"//app/..."})
+		g.Expect(err).To(MatchError(ContainSubstring("--before is required")))
+	})
+
+	t.Run("rejects unknown revisions", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().AQuery("//app/...", []string{}).Return(afterGraph(), nil)
+		bzl.EXPECT().WorkspaceRoot().Return(t.TempDir())
+
+		runner := New(ioutils.Streams{}, bzl)
+		err := runner.Run(context.Background(), diffCmd("--before=does-not-exist"), []string{"//app/..."})
+		g.Expect(err).To(MatchError("does-not-exist is neither an action graph snapshot nor a git revision"))
+	})
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aquerydiff
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+)
+
+// envChange is a change of an environment variable of an action.
+type envChange struct {
+	name    string
+	before  string
+	after   string
+	added   bool
+	removed bool
+}
+
+// actionDiff is the difference between two versions of the same action.
+type actionDiff struct {
+	key                actionKey
+	removedArguments   []string
+	addedArguments     []string
+	argumentsReordered bool
+	env                []envChange
+	removedInputs      []string
+	addedInputs        []string
+}
+
+func (d *actionDiff) empty() bool {
+	return len(d.removedArguments) == 0 && len(d.addedArguments) == 0 && !d.argumentsReordered &&
+		len(d.env) == 0 && len(d.removedInputs) == 0 && len(d.addedInputs) == 0
+}
+
+// graphDiff is the difference between two action graphs.
+type graphDiff struct {
+	added     []*action
+	removed   []*action
+	changed   []*actionDiff
+	unchanged int
+}
+
+func (d *graphDiff) empty() bool {
+	return len(d.added) == 0 && len(d.removed) == 0 && len(d.changed) == 0
+}
+
+// diffActionGraphs matches the actions of two action graphs by owner label,
+// mnemonic and primary output and reports how they differ.
+func diffActionGraphs(before, after map[actionKey]*action) *graphDiff {
+	d := &graphDiff{}
+	for key, a := range after {
+		b, ok := before[key]
+		if !ok {
+			d.added = append(d.added, a)
+			continue
+		}
+		if changed := diffActions(b, a); !changed.empty() {
+			d.changed = append(d.changed, changed)
+		} else {
+			d.unchanged++
+		}
+	}
+	for key, b := range before {
+		if _, ok := after[key]; !ok {
+			d.removed = append(d.removed, b)
+		}
+	}
+	sortActions(d.added)
+	sortActions(d.removed)
+	sort.Slice(d.changed, func(i, j int) bool { return d.changed[i].key.String() < d.changed[j].key.String() })
+	return d
+}
+
+func sortActions(actions []*action) {
+	sort.Slice(actions, func(i, j int) bool { return actions[i].key().String() < actions[j].key().String() })
+}
+
+func diffActions(before, after *action) *actionDiff {
+	d := &actionDiff{key: after.key()}
+
+	// Arguments are compared as multisets since a single inserted flag would
+	// otherwise make every following argument differ.
+	d.removedArguments, d.addedArguments = diffMultiset(before.arguments, after.arguments)
+	if len(d.removedArguments) == 0 && len(d.addedArguments) == 0 && !slices.Equal(before.arguments, after.arguments) {
+		d.argumentsReordered = true
+	}
+
+	for name, value := range before.env {
+		if afterValue, ok := after.env[name]; !ok {
+			d.env = append(d.env, envChange{name: name, before: value, removed: true})
+		} else if afterValue != value {
+			d.env = append(d.env, envChange{name: name, before: value, after: afterValue})
+		}
+	}
+	for name, value := range after.env {
+		if _, ok := before.env[name]; !ok {
+			d.env = append(d.env, envChange{name: name, after: value, added: true})
+		}
+	}
+	sort.Slice(d.env, func(i, j int) bool { return d.env[i].name < d.env[j].name })
+
+	d.removedInputs, d.addedInputs = diffMultiset(before.inputs, after.inputs)
+	return d
+}
+
+// diffMultiset returns the elements that were removed from and added to a
+// list, in the order in which they appear.
+func diffMultiset(before, after []string) (removed []string, added []string) {
+	counts := make(map[string]int, len(before))
+	for _, s := range before {
+		counts[s]++
+	}
+	for _, s := range after {
+		if counts[s] > 0 {
+			counts[s]--
+		} else {
+			added = append(added, s)
+		}
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		if counts[before[i]] > 0 {
+			counts[before[i]]--
+			removed = append(removed, before[i])
+		}
+	}
+	for i, j := 0, len(removed)-1; i < j; i, j = i+1, j-1 {
+		removed[i], removed[j] = removed[j], removed[i]
+	}
+	return removed, added
+}
+
+func (d *graphDiff) write(w io.Writer) {
+	if len(d.added) > 0 {
+		fmt.Fprintf(w, "Added actions (%d):\n", len(d.added))
+		for _, a := range d.added {
+			fmt.Fprintf(w, "  + %s\n", a.key())
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.removed) > 0 {
+		fmt.Fprintf(w, "Removed actions (%d):\n", len(d.removed))
+		for _, a := range d.removed {
+			fmt.Fprintf(w, "  - %s\n", a.key())
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.changed) > 0 {
+		fmt.Fprintf(w, "Changed actions (%d):\n", len(d.changed))
+		for _, c := range d.changed {
+			fmt.Fprintf(w, "  ~ %s\n", c.key)
+			if len(c.removedArguments) > 0 || len(c.addedArguments) > 0 || c.argumentsReordered {
+				fmt.Fprintln(w, "      arguments:")
+				writeLines(w, "-", c.removedArguments)
+				writeLines(w, "+", c.addedArguments)
+				if c.argumentsReordered {
+					fmt.Fprintln(w, "        (reordered)")
+				}
+			}
+			if len(c.env) > 0 {
+				fmt.Fprintln(w, "      env:")
+				for _, e := range c.env {
+					switch {
+					case e.added:
+						fmt.Fprintf(w, "        + %s=%s\n", e.name, e.after)
+					case e.removed:
+						fmt.Fprintf(w, "        - %s=%s\n", e.name, e.before)
+					default:
+						fmt.Fprintf(w, "        ~ %s: %s -> %s\n", e.name, e.before, e.after)
+					}
+				}
+			}
+			if len(c.removedInputs) > 0 || len(c.addedInputs) > 0 {
+				fmt.Fprintln(w, "      inputs:")
+				writeLines(w, "-", c.removedInputs)
+				writeLines(w, "+", c.addedInputs)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d added, %d removed, %d changed, %d unchanged actions\n", len(d.added), len(d.removed), len(d.changed), d.unchanged)
+}
+
+func writeLines(w io.Writer, prefix string, lines []string) {
+	for _, l := range lines {
+		fmt.Fprintf(w, "        %s %s\n", prefix, l)
+	}
+}
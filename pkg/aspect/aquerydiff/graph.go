@@ -0,0 +1,202 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package aquerydiff
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+)
+
+// action is an action of an action graph with its artifacts resolved to paths
+// so that actions of different action graphs can be compared.
+type action struct {
+	label         string
+	mnemonic      string
+	primaryOutput string
+	arguments     []string
+	env           map[string]string
+	inputs        []string
+}
+
+// actionKey identifies the same action across two action graphs.
+type actionKey struct {
+	label         string
+	mnemonic      string
+	primaryOutput string
+}
+
+func (a *action) key() actionKey {
+	return actionKey{a.label, a.mnemonic, a.primaryOutput}
+}
+
+func (k actionKey) String() string {
+	return fmt.Sprintf("%s %s %s", k.mnemonic, k.label, k.primaryOutput)
+}
+
+// parseActionGraph resolves the actions of an action graph.
+func parseActionGraph(agc *analysis.ActionGraphContainer) map[actionKey]*action {
+	paths := newPathResolver(agc)
+	targets := make(map[uint32]string, len(agc.Targets))
+	for _, t := range agc.Targets {
+		targets[t.Id] = t.Label
+	}
+	depSets := make(map[uint32]*analysis.DepSetOfFiles, len(agc.DepSetOfFiles))
+	for _, d := range agc.DepSetOfFiles {
+		depSets[d.Id] = d
+	}
+
+	actions := make(map[actionKey]*action, len(agc.Actions))
+	for _, a := range agc.Actions {
+		primaryOutput := a.PrimaryOutputId
+		if primaryOutput == 0 && len(a.OutputIds) > 0 {
+			primaryOutput = a.OutputIds[0]
+		}
+		resolved := &action{
+			label:         targets[a.TargetId],
+			mnemonic:      a.Mnemonic,
+			primaryOutput: paths.artifact(primaryOutput),
+			arguments:     a.Arguments,
+			env:           make(map[string]string, len(a.EnvironmentVariables)),
+			inputs:        expandDepSets(a.InputDepSetIds, depSets, paths),
+		}
+		for _, kv := range a.EnvironmentVariables {
+			resolved.env[kv.Key] = kv.Value
+		}
+		actions[resolved.key()] = resolved
+	}
+	return actions
+}
+
+// expandDepSets returns the sorted paths of the artifacts in the given nested
+// sets of files.
+func expandDepSets(ids []uint32, depSets map[uint32]*analysis.DepSetOfFiles, paths *pathResolver) []string {
+	visited := map[uint32]bool{}
+	artifacts := map[uint32]bool{}
+	var visit func(id uint32)
+	visit = func(id uint32) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		d, ok := depSets[id]
+		if !ok {
+			return
+		}
+		for _, a := range d.DirectArtifactIds {
+			artifacts[a] = true
+		}
+		for _, t := range d.TransitiveDepSetIds {
+			visit(t)
+		}
+	}
+	for _, id := range ids {
+		visit(id)
+	}
+	result := make([]string, 0, len(artifacts))
+	for a := range artifacts {
+		result = append(result, paths.artifact(a))
+	}
+	sort.Strings(result)
+	return result
+}
+
+// pathResolver reconstructs artifact paths from the path fragment trie of an
+// action graph.
+type pathResolver struct {
+	fragments map[uint32]*analysis.PathFragment
+	artifacts map[uint32]*analysis.Artifact
+	cache     map[uint32]string
+}
+
+func newPathResolver(agc *analysis.ActionGraphContainer) *pathResolver {
+	r := &pathResolver{
+		fragments: make(map[uint32]*analysis.PathFragment, len(agc.PathFragments)),
+		artifacts: make(map[uint32]*analysis.Artifact, len(agc.Artifacts)),
+		cache:     map[uint32]string{},
+	}
+	for _, f := range agc.PathFragments {
+		r.fragments[f.Id] = f
+	}
+	for _, a := range agc.Artifacts {
+		r.artifacts[a.Id] = a
+	}
+	return r
+}
+
+func (r *pathResolver) artifact(id uint32) string {
+	a, ok := r.artifacts[id]
+	if !ok {
+		return ""
+	}
+	return r.path(a.PathFragmentId)
+}
+
+func (r *pathResolver) path(id uint32) string {
+	if p, ok := r.cache[id]; ok {
+		return p
+	}
+	f, ok := r.fragments[id]
+	if !ok {
+		return ""
+	}
+	p := f.Label
+	if f.ParentId > 0 {
+		p = r.path(f.ParentId) + "/" + p
+	}
+	r.cache[id] = p
+	return p
+}
+
+// readSnapshot reads an action graph written by --save, or by
+// `bazel aquery --output=proto` or `--output=jsonproto`.
+func readSnapshot(path string) (*analysis.ActionGraphContainer, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read action graph snapshot: %w", err)
+	}
+	agc := &analysis.ActionGraphContainer{}
+	if strings.HasPrefix(string(bytes.TrimSpace(b[:min(len(b), 64)])), "{") {
+		err = protojson.Unmarshal(b, agc)
+	} else {
+		err = proto.Unmarshal(b, agc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse action graph snapshot %s: %w", path, err)
+	}
+	return agc, nil
+}
+
+// writeSnapshot saves an action graph in the format of
+// `bazel aquery --output=proto`.
+func writeSnapshot(path string, agc *analysis.ActionGraphContainer) error {
+	b, err := proto.Marshal(agc)
+	if err != nil {
+		return fmt.Errorf("failed to save action graph snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("failed to save action graph snapshot: %w", err)
+	}
+	return nil
+}
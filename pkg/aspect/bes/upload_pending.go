@@ -0,0 +1,105 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/besproxy"
+)
+
+// UploadPending represents the aspect bes upload-pending command.
+type UploadPending struct {
+	ioutils.Streams
+	spoolDir func() (string, error)
+}
+
+// NewUploadPending creates an UploadPending command.
+func NewUploadPending(streams ioutils.Streams) *UploadPending {
+	return &UploadPending{
+		Streams:  streams,
+		spoolDir: besproxy.DefaultSpoolDir,
+	}
+}
+
+// Run uploads the build event journals that earlier invocations failed to
+// upload to their BES backends.
+func (runner *UploadPending) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	backendFilter, _ := cmd.Flags().GetString("bes_backend")
+	besHeaders, _ := cmd.Flags().GetStringArray("bes_header")
+	remoteHeaders, _ := cmd.Flags().GetStringArray("remote_header")
+
+	headers := map[string]string{}
+	for _, h := range append(remoteHeaders, besHeaders...) {
+		name, value, found := strings.Cut(h, "=")
+		if !found {
+			return fmt.Errorf("invalid header '%v'; value must be in the form of a 'name=value' assignment", h)
+		}
+		headers[name] = value
+	}
+
+	dir, err := runner.spoolDir()
+	if err != nil {
+		return err
+	}
+	pending, err := besproxy.PendingJournals(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(headers) > 0 && backendFilter == "" {
+		backends := map[string]struct{}{}
+		for _, p := range pending {
+			backends[p.Metadata.Backend] = struct{}{}
+		}
+		if len(backends) > 1 {
+			return fmt.Errorf("journals are pending for %d BES backends; pass --bes_backend to choose the one that the headers are sent to", len(backends))
+		}
+	}
+
+	uploaded := 0
+	failed := 0
+	for _, p := range pending {
+		if backendFilter != "" && p.Metadata.Backend != backendFilter {
+			continue
+		}
+		fmt.Fprintf(runner.Stdout, "Uploading %s to %s\n", p.Path, p.Metadata.Backend)
+		if err := besproxy.UploadPendingJournal(ctx, p, headers, viper.GetInt("bes.spool_max_attempts")); err != nil {
+			fmt.Fprintf(runner.Stderr, "%s failed to upload %s: %v\n", color.RedString("ERROR:"), p.Path, err)
+			failed++
+			continue
+		}
+		uploaded++
+	}
+
+	if uploaded == 0 && failed == 0 {
+		fmt.Fprintln(runner.Stdout, "No pending build event uploads.")
+		return nil
+	}
+	fmt.Fprintf(runner.Stdout, "Uploaded %d pending build event stream(s)\n", uploaded)
+	if failed > 0 {
+		return fmt.Errorf("%d pending build event stream(s) failed to upload", failed)
+	}
+	return nil
+}
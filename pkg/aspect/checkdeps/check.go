@@ -0,0 +1,142 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checkdeps
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
+)
+
+// Violation is a dependency that breaks a rule. Path is the shortest path from the target that
+// matches the 'from' patterns of the rule to the target it must not depend on.
+type Violation struct {
+	Rule *Rule
+	Path []string
+}
+
+// Check returns the violations of the rules in the graph, in the order of the rules and then of
+// the labels of the offending targets.
+func Check(g *graph.Graph, rules []*Rule) []*Violation {
+	violations := []*Violation{}
+	dependents := reverse(g)
+	for _, rule := range rules {
+		sources := make(map[string]bool)
+		for _, label := range g.Labels() {
+			if matchAny(rule.From, g.Nodes[label]) {
+				sources[label] = true
+			}
+		}
+		if len(rule.Deny) > 0 {
+			violations = append(violations, checkDeny(g, dependents, rule, sources)...)
+		}
+		if len(rule.Allow) > 0 {
+			violations = append(violations, checkAllow(g, rule, sources)...)
+		}
+	}
+	return violations
+}
+
+// reverse returns the dependents of every node.
+func reverse(g *graph.Graph) map[string][]string {
+	dependents := make(map[string][]string)
+	for _, label := range g.Labels() {
+		for _, dep := range g.Nodes[label].Deps {
+			dependents[dep] = append(dependents[dep], label)
+		}
+	}
+	return dependents
+}
+
+// checkDeny finds the sources that depend on a denied target. A breadth first search backwards
+// from the denied targets finds the shortest path from every target that reaches one. A source is
+// only reported when its path doesn't go through another source, which is reported instead, so
+// that a single offending dependency isn't reported for every target above it.
+func checkDeny(g *graph.Graph, dependents map[string][]string, rule *Rule, sources map[string]bool) []*Violation {
+	next := make(map[string]string)
+	distance := make(map[string]int)
+	queue := []string{}
+	for _, label := range g.Labels() {
+		if matchAny(rule.Deny, g.Nodes[label]) {
+			distance[label] = 0
+			queue = append(queue, label)
+		}
+	}
+	for len(queue) > 0 {
+		label := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[label] {
+			if _, ok := distance[dependent]; !ok {
+				distance[dependent] = distance[label] + 1
+				next[dependent] = label
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	violations := []*Violation{}
+	for _, label := range g.Labels() {
+		if !sources[label] || distance[label] == 0 {
+			continue
+		}
+		path := []string{label}
+		throughSource := false
+		for hop := next[label]; ; hop = next[hop] {
+			path = append(path, hop)
+			if distance[hop] == 0 {
+				break
+			}
+			throughSource = throughSource || sources[hop]
+		}
+		if !throughSource {
+			violations = append(violations, &Violation{Rule: rule, Path: path})
+		}
+	}
+	return violations
+}
+
+// checkAllow finds the direct dependencies of the sources that aren't allowed.
+func checkAllow(g *graph.Graph, rule *Rule, sources map[string]bool) []*Violation {
+	violations := []*Violation{}
+	for _, label := range g.Labels() {
+		if !sources[label] {
+			continue
+		}
+		for _, dep := range g.Nodes[label].Deps {
+			if sources[dep] || samePackage(label, dep) || matchAny(rule.Allow, g.Nodes[dep]) {
+				continue
+			}
+			violations = append(violations, &Violation{Rule: rule, Path: []string{label, dep}})
+		}
+	}
+	return violations
+}
+
+func writeViolations(w io.Writer, violations []*Violation) error {
+	var b strings.Builder
+	for _, v := range violations {
+		fmt.Fprintf(&b, "%s violates %q:\n", v.Path[0], v.Rule.Name)
+		fmt.Fprintf(&b, "    %s\n", v.Path[0])
+		for _, label := range v.Path[1:] {
+			fmt.Fprintf(&b, "    -> %s\n", label)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checkdeps
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+type CheckDeps struct {
+	ioutils.Streams
+	bzl bazel.Bazel
+}
+
+func New(streams ioutils.Streams, bzl bazel.Bazel) *CheckDeps {
+	return &CheckDeps{
+		Streams: streams,
+		bzl:     bzl,
+	}
+}
+
+func (runner *CheckDeps) Run(_ context.Context, _ *cobra.Command, args []string) error {
+	// Subtracted patterns, such as -//legacy/..., look like short flags to SeparateBazelFlags.
+	var subtracted []string
+	args = slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+		if strings.HasPrefix(arg, "-/") || strings.HasPrefix(arg, "-@") {
+			subtracted = append(subtracted, arg)
+			return true
+		}
+		return false
+	})
+	patterns, bazelFlags, err := bazel.SeparateBazelFlags("query", args)
+	if err != nil {
+		return err
+	}
+	patterns = append(patterns, subtracted...)
+
+	rules, err := UnmarshalRules(viper.Get(RulesConfigKey))
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return fmt.Errorf("no dependency rules are configured, add them to %s in .aspect/cli/config.yaml", RulesConfigKey)
+	}
+
+	g, err := graph.Query(runner.bzl, "query", runner.Streams, append(bazelFlags, universe(patterns)))
+	if err != nil {
+		return err
+	}
+
+	violations := Check(g, rules)
+	if err := writeViolations(runner.Stdout, violations); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &aspecterrors.ExitError{
+			Err:      fmt.Errorf("found %d dependency rule violations", len(violations)),
+			ExitCode: aspecterrors.DependencyViolations,
+		}
+	}
+	fmt.Fprintf(runner.Stderr, "No dependency rule violations in %d targets\n", len(g.Nodes))
+	return nil
+}
+
+// universe returns the query for the transitive dependencies of the target patterns, which
+// default to all targets in the main repository. Patterns starting with - are subtracted, as they
+// are on the command line of `bazel build`.
+func universe(patterns []string) string {
+	var b strings.Builder
+	for _, p := range patterns {
+		if negative, ok := strings.CutPrefix(p, "-"); ok {
+			if b.Len() == 0 {
+				b.WriteString("//...")
+			}
+			fmt.Fprintf(&b, " - %s", negative)
+		} else if b.Len() > 0 {
+			fmt.Fprintf(&b, " + %s", p)
+		} else {
+			b.WriteString(p)
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("//...")
+	}
+	return fmt.Sprintf("deps(%s)", b.String())
+}
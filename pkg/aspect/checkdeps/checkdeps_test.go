@@ -0,0 +1,219 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checkdeps
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aspect-build/aspect-cli/bazel/query"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func testGraph() *graph.Graph {
+	nodes := []*graph.Node{
+		{Label: "//app:app", Deps: []string{"//app:lib", "//ui:button"}},
+		{Label: "//app:lib", Deps: []string{"//core:core"}},
+		{Label: "//ui:button", Tags: []string{"ui"}, Deps: []string{"//core:core", "//server/db:db"}},
+		{Label: "//ui/theme:theme", Tags: []string{"ui"}, Deps: []string{"//ui:button"}},
+		{Label: "//core:core", Deps: []string{"@com_github_foo//:foo"}},
+		{Label: "//server/db:db", Deps: []string{"//core:core"}},
+		{Label: "@com_github_foo//:foo"},
+	}
+	g := &graph.Graph{Nodes: map[string]*graph.Node{}}
+	for _, n := range nodes {
+		g.Nodes[n.Label] = n
+	}
+	return g
+}
+
+func rulesFromYaml(t *testing.T, data interface{}) []*Rule {
+	rules, err := UnmarshalRules(data)
+	NewGomegaWithT(t).Expect(err).NotTo(HaveOccurred())
+	return rules
+}
+
+func TestUnmarshalRules(t *testing.T) {
+	t.Run("parses rules", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules := rulesFromYaml(t, []interface{}{
+			map[string]interface{}{"name": "layering", "from": "//core/...", "deny": []interface{}{"//app/...", "tag:ui"}},
+			map[string]interface{}{"from": "tag:ui", "allow": "//core:all"},
+		})
+		g.Expect(rules).To(HaveLen(2))
+		g.Expect(rules[0].Name).To(Equal("layering"))
+		g.Expect(rules[0].Deny).To(HaveLen(2))
+		g.Expect(rules[1].Name).To(Equal("tag:ui may only depend on //core:all"))
+	})
+
+	t.Run("requires from and allow or deny", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := UnmarshalRules([]interface{}{map[string]interface{}{"deny": "//app/..."}})
+		g.Expect(err).To(MatchError("expected check_deps.rules entry 0 to have a 'from' attribute"))
+
+		_, err = UnmarshalRules([]interface{}{map[string]interface{}{"from": "//app/..."}})
+		g.Expect(err).To(MatchError("expected check_deps.rules entry 0 to have an 'allow' or 'deny' attribute"))
+	})
+
+	t.Run("rejects invalid patterns", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := UnmarshalRules([]interface{}{map[string]interface{}{"from": "app", "deny": "//core"}})
+		g.Expect(err).To(MatchError(`check_deps.rules entry 0 'from': invalid pattern "app": expected an absolute label starting with // or @`))
+	})
+}
+
+func TestPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		label   string
+		tags    []string
+		match   bool
+	}{
+		{"//...", "//a/b:c", nil, true},
+		{"//...", "@foo//:foo", nil, false},
+		{"//a/...", "//a:a", nil, true},
+		{"//a/...", "//a/b:c", nil, true},
+		{"//a/...", "//ab:c", nil, false},
+		{"//a:all", "//a:x", nil, true},
+		{"//a:all", "//a/b:x", nil, false},
+		{"//a", "//a:a", nil, true},
+		{"//a", "//a:b", nil, false},
+		{"//a:b", "//a:b", nil, true},
+		{"@foo//...", "@foo//bar:baz", nil, true},
+		{"@foo//...", "@@foo//bar:baz", nil, true},
+		{"tag:ui", "//a:b", []string{"ui"}, true},
+		{"tag:ui", "//a:b", []string{"server"}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.pattern+" "+c.label, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			p, err := ParsePattern(c.pattern)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(p.Match(&graph.Node{Label: c.label, Tags: c.tags})).To(Equal(c.match))
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Run("reports the shortest path to a denied target", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules := rulesFromYaml(t, []interface{}{
+			map[string]interface{}{"from": "//app/...", "deny": "//server/..."},
+		})
+		violations := Check(testGraph(), rules)
+		g.Expect(violations).To(HaveLen(1))
+		g.Expect(violations[0].Path).To(Equal([]string{"//app:app", "//ui:button", "//server/db:db"}))
+	})
+
+	t.Run("reports only the source closest to a denied target", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules := rulesFromYaml(t, []interface{}{
+			map[string]interface{}{"from": "tag:ui", "deny": "//server/..."},
+		})
+		violations := Check(testGraph(), rules)
+		g.Expect(violations).To(HaveLen(1))
+		g.Expect(violations[0].Path).To(Equal([]string{"//ui:button", "//server/db:db"}))
+	})
+
+	t.Run("reports direct dependencies that aren't allowed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules := rulesFromYaml(t, []interface{}{
+			map[string]interface{}{"from": "tag:ui", "allow": "//core/..."},
+		})
+		violations := Check(testGraph(), rules)
+		g.Expect(violations).To(HaveLen(1))
+		g.Expect(violations[0].Path).To(Equal([]string{"//ui:button", "//server/db:db"}))
+	})
+
+	t.Run("allows dependencies in the same package", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules := rulesFromYaml(t, []interface{}{
+			map[string]interface{}{"from": "//app:app", "allow": "tag:ui"},
+		})
+		g.Expect(Check(testGraph(), rules)).To(BeEmpty())
+	})
+}
+
+func TestRun(t *testing.T) {
+	result, err := proto.Marshal(&query.QueryResult{
+		Target: []*query.Target{
+			{
+				Type: query.Target_RULE.Enum(),
+				Rule: &query.Rule{Name: proto.String("//app:app"), RuleClass: proto.String("go_binary"), RuleInput: []string{"//server:server"}},
+			},
+			{
+				Type: query.Target_RULE.Enum(),
+				Rule: &query.Rule{Name: proto.String("//server:server"), RuleClass: proto.String("go_library")},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("exits with an error when a rule is violated", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		viper.Set(RulesConfigKey, []interface{}{
+			map[string]interface{}{"name": "no server code in apps", "from": "//app/...", "deny": "//server/..."},
+		})
+		defer viper.Set(RulesConfigKey, nil)
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().
+			RunCommand(gomock.Any(), nil, "query", "--output=proto", "deps(//app/... - //app/legacy/...)").
+			DoAndReturn(func(streams ioutils.Streams, _ *string, _ ...string) error {
+				_, err := streams.Stdout.Write(result)
+				return err
+			})
+
+		var stdout strings.Builder
+		err := New(ioutils.Streams{Stdout: &stdout}, bzl).Run(context.Background(), nil, []string{"//app/...", "-//app/legacy/..."})
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.(*aspecterrors.ExitError).ExitCode).To(Equal(aspecterrors.DependencyViolations))
+		g.Expect(stdout.String()).To(Equal(`//app:app violates "no server code in apps":
+    //app:app
+    -> //server:server
+`))
+	})
+
+	t.Run("requires rules", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		err := New(ioutils.Streams{}, nil).Run(context.Background(), nil, nil)
+		g.Expect(err).To(MatchError("no dependency rules are configured, add them to check_deps.rules in .aspect/cli/config.yaml"))
+	})
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package checkdeps
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
+)
+
+// RulesConfigKey is the config.yaml attribute with the dependency rules.
+const RulesConfigKey = "check_deps.rules"
+
+// Rule restricts the dependencies of the targets that match From. Deny forbids depending on the
+// matching targets, directly or transitively. Allow, when set, lists the only targets that may
+// be direct dependencies, besides targets in the same package or that also match From.
+type Rule struct {
+	Name  string
+	From  []*Pattern
+	Allow []*Pattern
+	Deny  []*Pattern
+}
+
+// Pattern matches targets by label, such as //foo:bar, by package, such as //foo:all or
+// //foo/..., or by tag, such as tag:ui.
+type Pattern struct {
+	text string
+	tag  string
+	repo string
+	pkg  string
+	// name is the target name, or empty for all targets in the package.
+	name      string
+	recursive bool
+}
+
+// UnmarshalRules parses the `check_deps.rules` config.yaml attribute.
+func UnmarshalRules(data interface{}) ([]*Rule, error) {
+	result := []*Rule{}
+
+	if data == nil {
+		return result, nil
+	}
+
+	entries, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected %v config to be a list", RulesConfigKey)
+	}
+
+	for i, e := range entries {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected %v entry %v to be a map", RulesConfigKey, i)
+		}
+
+		rule := &Rule{}
+		if name, ok := m["name"]; ok {
+			if rule.Name, ok = name.(string); !ok {
+				return nil, fmt.Errorf("expected %v entry %v 'name' attribute to be a string", RulesConfigKey, i)
+			}
+		}
+
+		var err error
+		if rule.From, err = patternList(m, "from", i); err != nil {
+			return nil, err
+		}
+		if rule.Allow, err = patternList(m, "allow", i); err != nil {
+			return nil, err
+		}
+		if rule.Deny, err = patternList(m, "deny", i); err != nil {
+			return nil, err
+		}
+		if len(rule.From) == 0 {
+			return nil, fmt.Errorf("expected %v entry %v to have a 'from' attribute", RulesConfigKey, i)
+		}
+		if len(rule.Allow) == 0 && len(rule.Deny) == 0 {
+			return nil, fmt.Errorf("expected %v entry %v to have an 'allow' or 'deny' attribute", RulesConfigKey, i)
+		}
+		if rule.Name == "" {
+			rule.Name = rule.defaultName()
+		}
+
+		result = append(result, rule)
+	}
+
+	return result, nil
+}
+
+func (rule *Rule) defaultName() string {
+	var parts []string
+	if len(rule.Deny) > 0 {
+		parts = append(parts, fmt.Sprintf("%s must not depend on %s", patternsString(rule.From), patternsString(rule.Deny)))
+	}
+	if len(rule.Allow) > 0 {
+		parts = append(parts, fmt.Sprintf("%s may only depend on %s", patternsString(rule.From), patternsString(rule.Allow)))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func patternsString(patterns []*Pattern) string {
+	texts := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		texts = append(texts, p.text)
+	}
+	return strings.Join(texts, ", ")
+}
+
+// patternList parses an attribute that is either a single pattern or a list of patterns.
+func patternList(m map[string]interface{}, attr string, i int) ([]*Pattern, error) {
+	value, ok := m[attr]
+	if !ok {
+		return nil, nil
+	}
+	var texts []interface{}
+	switch v := value.(type) {
+	case string:
+		texts = []interface{}{v}
+	case []interface{}:
+		texts = v
+	default:
+		return nil, fmt.Errorf("expected %v entry %v '%v' attribute to be a string or a list", RulesConfigKey, i, attr)
+	}
+	result := make([]*Pattern, 0, len(texts))
+	for _, t := range texts {
+		text, ok := t.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected %v entry %v '%v' attribute to be a list of strings", RulesConfigKey, i, attr)
+		}
+		p, err := ParsePattern(text)
+		if err != nil {
+			return nil, fmt.Errorf("%v entry %v '%v': %w", RulesConfigKey, i, attr, err)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// ParsePattern parses a label, package or tag pattern.
+func ParsePattern(text string) (*Pattern, error) {
+	if tag, ok := strings.CutPrefix(text, "tag:"); ok {
+		if tag == "" {
+			return nil, fmt.Errorf("invalid pattern %q: the tag is empty", text)
+		}
+		return &Pattern{text: text, tag: tag}, nil
+	}
+	repo, pkg, name, err := splitLabel(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", text, err)
+	}
+	p := &Pattern{text: text, repo: repo}
+	switch {
+	case pkg == "..." || strings.HasSuffix(pkg, "/..."):
+		if name != "" && name != "all" && name != "*" && name != "all-targets" {
+			return nil, fmt.Errorf("invalid pattern %q: a recursive pattern can't name a target", text)
+		}
+		p.pkg = strings.TrimSuffix(strings.TrimSuffix(pkg, "..."), "/")
+		p.recursive = true
+	case name == "all" || name == "*" || name == "all-targets":
+		p.pkg = pkg
+	case name == "":
+		// //foo is short for //foo:foo
+		p.pkg = pkg
+		p.name = pkg[strings.LastIndex(pkg, "/")+1:]
+	default:
+		p.pkg = pkg
+		p.name = name
+	}
+	return p, nil
+}
+
+// splitLabel splits an absolute label into its repository, package and target name. The main
+// repository is the empty string.
+func splitLabel(label string) (string, string, string, error) {
+	repo, rest, ok := strings.Cut(label, "//")
+	if !ok || (repo != "" && !strings.HasPrefix(repo, "@")) {
+		return "", "", "", fmt.Errorf("expected an absolute label starting with // or @")
+	}
+	repo = strings.TrimLeft(repo, "@")
+	pkg, name, _ := strings.Cut(rest, ":")
+	return repo, pkg, name, nil
+}
+
+// Match returns whether the pattern matches the node.
+func (p *Pattern) Match(node *graph.Node) bool {
+	if p.tag != "" {
+		return slices.Contains(node.Tags, p.tag)
+	}
+	repo, pkg, name, err := splitLabel(node.Label)
+	if err != nil || repo != p.repo {
+		return false
+	}
+	if p.recursive {
+		return p.pkg == "" || pkg == p.pkg || strings.HasPrefix(pkg, p.pkg+"/")
+	}
+	return pkg == p.pkg && (p.name == "" || name == p.name)
+}
+
+func matchAny(patterns []*Pattern, node *graph.Node) bool {
+	for _, p := range patterns {
+		if p.Match(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// samePackage returns whether two labels are in the same package.
+func samePackage(a string, b string) bool {
+	repoA, pkgA, _, errA := splitLabel(a)
+	repoB, pkgB, _, errB := splitLabel(b)
+	return errA == nil && errB == nil && repoA == repoB && pkgA == pkgB
+}
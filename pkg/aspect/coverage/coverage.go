@@ -19,12 +19,16 @@ package coverage
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
 	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
 type Coverage struct {
@@ -41,22 +45,56 @@ func New(streams ioutils.Streams, hstreams ioutils.Streams, bzl bazel.Bazel) *Co
 	}
 }
 
+// NeedsBESBackend returns whether `aspect coverage` subscribes to the build
+// events for the flags it was given, to report the coverage or check it against
+// the thresholds of the config.yaml.
+func NeedsBESBackend(cmd *cobra.Command) bool {
+	reportKind, _ := cmd.Flags().GetString(ReportFlagName)
+	diffBase, _ := cmd.Flags().GetString(DiffBaseFlagName)
+	return reportKind != noReport || diffBase != "" || viper.IsSet(MinConfigKey)
+}
+
 func (runner *Coverage) Run(ctx context.Context, cmd *cobra.Command, args []string) (exitErr error) {
+	reportKind, diffBase := noReport, ""
+	var flagSet *pflag.FlagSet
+	if cmd != nil {
+		flagSet = cmd.Flags()
+		reportKind, _ = flagSet.GetString(ReportFlagName)
+		diffBase, _ = flagSet.GetString(DiffBaseFlagName)
+	}
+	args = bazel.RemoveFlags("coverage", flagSet, args, ReportFlagName, DiffBaseFlagName)
+	switch reportKind {
+	case packageReport, fileReport, noReport:
+	default:
+		return fmt.Errorf("invalid --%s %q, expected package, file or none", ReportFlagName, reportKind)
+	}
+	min, err := unmarshalThresholds(viper.Get(MinConfigKey))
+	if err != nil {
+		return err
+	}
+
 	bazelCmd := []string{"coverage"}
 	bazelCmd = append(bazelCmd, args...)
 
 	// Currently Bazel only supports a single --bes_backend so adding ours after
 	// any user supplied value will result in our bes_backend taking precedence.
-	// There is a very old & stale issue to add support for multiple BES
-	// backends https://github.com/bazelbuild/bazel/issues/10908. In the future,
-	// we could build this support into the Aspect CLI and post on that issue
-	// that using the Aspect CLI resolves it.
+	// The user's --bes_backend, whether from the command line or a bazelrc, is
+	// still honored: the BES backend reads the one in effect from the
+	// OptionsParsed event and forwards the stream to it through a proxy, along
+	// with any bes.backends of the config.yaml.
+	// See https://github.com/bazelbuild/bazel/issues/10908.
 	if bep.HasBESBackend(ctx) {
 		besBackend := bep.BESBackendFromContext(ctx)
 		besBackendFlag := fmt.Sprintf("--bes_backend=%s", besBackend.Addr())
 		bazelCmd = flags.AddFlagToCommand(bazelCmd, besBackendFlag)
 	}
 
+	var files *coverageFiles
+	if bep.HasBESBackend(ctx) && (reportKind != noReport || diffBase != "" || min != nil) {
+		files = newCoverageFiles(runner.bzl.WorkspaceRoot())
+		bep.BESBackendFromContext(ctx).RegisterSubscriber(files.bepEventCallback, false)
+	}
+
 	bzlCommandStreams := runner.streams
 	if cmd != nil {
 		hints, err := cmd.Root().PersistentFlags().GetBool(flags.AspectHintsFlagName)
@@ -68,7 +106,8 @@ func (runner *Coverage) Run(ctx context.Context, cmd *cobra.Command, args []stri
 		}
 	}
 
-	err := runner.bzl.RunCommand(bzlCommandStreams, nil, bazelCmd...)
+	started := time.Now()
+	err = runner.bzl.RunCommand(bzlCommandStreams, nil, bazelCmd...)
 
 	// Check for subscriber errors
 	subscriberErrors := bep.BESErrors(ctx)
@@ -81,5 +120,68 @@ func (runner *Coverage) Run(ctx context.Context, cmd *cobra.Command, args []stri
 		}
 	}
 
+	if files != nil {
+		reportErr := files.wait(besCompletionTimeout)
+		if reportErr == nil {
+			reportErr = runner.report(files, started, reportKind, diffBase, min)
+		}
+		if reportErr != nil && err == nil {
+			err = reportErr
+		}
+	}
+
 	return err
 }
+
+// report prints the coverage of the tests and checks it against the
+// thresholds of the config.yaml.
+func (runner *Coverage) report(files *coverageFiles, started time.Time, reportKind string, diffBase string, min *thresholds) error {
+	r, source, err := files.load(started)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		fmt.Fprintf(runner.streams.Stderr, "No coverage data was reported by the tests\n")
+		if min == nil {
+			return nil
+		}
+		// The thresholds can't be checked, which must not pass for meeting them. This happens
+		// when the coverage data isn't downloaded, e.g. with --remote_download_minimal.
+		return &aspecterrors.ExitError{
+			Err:      fmt.Errorf("no coverage data to check against the minimum set in %s", MinConfigKey),
+			ExitCode: aspecterrors.CoverageBelowMinimum,
+		}
+	}
+	fmt.Fprintf(runner.streams.Stderr, "Coverage of %d files from %s\n", len(r), source)
+
+	if reportKind != noReport {
+		if err := writeTable(runner.streams.Stdout, r, reportKind, files.workspaceRoot); err != nil {
+			return err
+		}
+	}
+
+	var changed *changedCoverage
+	if diffBase != "" {
+		lines, err := changedLines(files.workspaceRoot, diffBase)
+		if err != nil {
+			return err
+		}
+		changed = coverageOfChanges(r, lines)
+		writeChangedCoverage(runner.streams.Stdout, changed, diffBase)
+	}
+
+	if min == nil {
+		return nil
+	}
+	violations := min.check(r.total(), changed)
+	for _, v := range violations {
+		fmt.Fprintf(runner.streams.Stderr, "Error: %s\n", v)
+	}
+	if len(violations) > 0 {
+		return &aspecterrors.ExitError{
+			Err:      fmt.Errorf("coverage is below the minimum set in %s", MinConfigKey),
+			ExitCode: aspecterrors.CoverageBelowMinimum,
+		}
+	}
+	return nil
+}
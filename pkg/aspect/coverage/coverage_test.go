@@ -0,0 +1,178 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/coverage"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+	bep_mock "github.com/aspect-build/aspect-cli/pkg/plugin/system/bep/mock"
+)
+
+func TestCoverage(t *testing.T) {
+	t.Run("fails when the coverage is below the minimum", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		viper.Set(coverage.MinConfigKey, 80)
+		defer viper.Set(coverage.MinConfigKey, nil)
+
+		lcov := filepath.Join(t.TempDir(), "coverage.dat")
+		g.Expect(os.WriteFile(lcov, []byte("SF:foo/foo.go\nDA:1,1\nDA:2,0\nend_of_record\n"), 0644)).To(Succeed())
+
+		var stdout, stderr strings.Builder
+		streams := ioutils.Streams{Stdout: &stdout, Stderr: &stderr}
+
+		var subscriber bep.CallbackFn
+		besBackend := bep_mock.NewMockBESBackend(ctrl)
+		besBackend.EXPECT().Addr().Return("grpc://127.0.0.1:12345")
+		besBackend.EXPECT().RegisterSubscriber(gomock.Any(), false).Do(func(cb bep.CallbackFn, _ bool) {
+			subscriber = cb
+		})
+		besBackend.EXPECT().Errors()
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return(t.TempDir())
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "coverage", "//foo:foo_test", "--bes_backend=grpc://127.0.0.1:12345").
+			DoAndReturn(func(ioutils.Streams, *string, ...string) error {
+				return subscriber(&buildeventstream.BuildEvent{
+					Payload: &buildeventstream.BuildEvent_TestResult{
+						TestResult: &buildeventstream.TestResult{
+							TestActionOutput: []*buildeventstream.File{
+								{Name: "test.lcov", File: &buildeventstream.File_Uri{Uri: "file://" + lcov}},
+							},
+						},
+					},
+				}, 0)
+			})
+
+		ctx := bep.InjectBESBackend(context.Background(), besBackend)
+		err := coverage.New(streams, streams, bzl).Run(ctx, nil, []string{"//foo:foo_test"})
+
+		var exitErr *aspecterrors.ExitError
+		g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+		g.Expect(exitErr.ExitCode).To(Equal(aspecterrors.CoverageBelowMinimum))
+		g.Expect(stderr.String()).To(ContainSubstring("Error: Line coverage of 50.0% is below the minimum of 80%"))
+	})
+	t.Run("reports the coverage once the build event stream finished", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		viper.Set(coverage.MinConfigKey, 40)
+		defer viper.Set(coverage.MinConfigKey, nil)
+
+		lcov := filepath.Join(t.TempDir(), "coverage.dat")
+		g.Expect(os.WriteFile(lcov, []byte("SF:foo/foo.go\nDA:1,1\nDA:2,0\nend_of_record\n"), 0644)).To(Succeed())
+
+		var stdout, stderr strings.Builder
+		streams := ioutils.Streams{Stdout: &stdout, Stderr: &stderr}
+
+		var subscriber bep.CallbackFn
+		besBackend := bep_mock.NewMockBESBackend(ctrl)
+		besBackend.EXPECT().Addr().Return("grpc://127.0.0.1:12345")
+		besBackend.EXPECT().RegisterSubscriber(gomock.Any(), false).Do(func(cb bep.CallbackFn, _ bool) {
+			subscriber = cb
+		})
+		besBackend.EXPECT().Errors()
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return(t.TempDir())
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "coverage", "//foo:foo_test", "--bes_backend=grpc://127.0.0.1:12345").
+			DoAndReturn(func(ioutils.Streams, *string, ...string) error {
+				if err := subscriber(&buildeventstream.BuildEvent{
+					Payload: &buildeventstream.BuildEvent_Started{Started: &buildeventstream.BuildStarted{}},
+				}, 0); err != nil {
+					return err
+				}
+				// The subscribers receive the rest of the events after Bazel exited.
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					subscriber(&buildeventstream.BuildEvent{
+						Payload: &buildeventstream.BuildEvent_TestResult{
+							TestResult: &buildeventstream.TestResult{
+								TestActionOutput: []*buildeventstream.File{
+									{Name: "test.lcov", File: &buildeventstream.File_Uri{Uri: "file://" + lcov}},
+								},
+							},
+						},
+					}, 1)
+					subscriber(&buildeventstream.BuildEvent{
+						Payload: &buildeventstream.BuildEvent_Finished{Finished: &buildeventstream.BuildFinished{}},
+					}, 2)
+				}()
+				return nil
+			})
+
+		ctx := bep.InjectBESBackend(context.Background(), besBackend)
+		err := coverage.New(streams, streams, bzl).Run(ctx, nil, []string{"//foo:foo_test"})
+
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stderr.String()).To(ContainSubstring("Coverage of 1 files from the coverage of 1 test"))
+	})
+	t.Run("fails when the coverage can't be checked against the minimum", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		viper.Set(coverage.MinConfigKey, 80)
+		defer viper.Set(coverage.MinConfigKey, nil)
+
+		var stdout, stderr strings.Builder
+		streams := ioutils.Streams{Stdout: &stdout, Stderr: &stderr}
+
+		besBackend := bep_mock.NewMockBESBackend(ctrl)
+		besBackend.EXPECT().Addr().Return("grpc://127.0.0.1:12345")
+		besBackend.EXPECT().RegisterSubscriber(gomock.Any(), false)
+		besBackend.EXPECT().Errors()
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return(t.TempDir())
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "coverage", "//foo:foo_test", "--bes_backend=grpc://127.0.0.1:12345").
+			Return(nil)
+
+		ctx := bep.InjectBESBackend(context.Background(), besBackend)
+		err := coverage.New(streams, streams, bzl).Run(ctx, nil, []string{"//foo:foo_test"})
+
+		var exitErr *aspecterrors.ExitError
+		g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+		g.Expect(exitErr.ExitCode).To(Equal(aspecterrors.CoverageBelowMinimum))
+		g.Expect(stderr.String()).To(ContainSubstring("No coverage data was reported by the tests"))
+	})
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fileCoverage is the coverage of a source file, merged from LCOV records.
+type fileCoverage struct {
+	path string
+	// lines maps the instrumented lines to the number of times they ran.
+	lines map[int]int
+	// branches maps the instrumented branches to the number of times they were taken.
+	branches map[branch]int
+}
+
+type branch struct {
+	line   int
+	block  string
+	branch string
+}
+
+// report is the coverage of every source file, keyed by path.
+type report map[string]*fileCoverage
+
+func (r report) file(path string) *fileCoverage {
+	f, ok := r[path]
+	if !ok {
+		f = &fileCoverage{path: path, lines: map[int]int{}, branches: map[branch]int{}}
+		r[path] = f
+	}
+	return f
+}
+
+// paths returns the paths of the files in the report, sorted.
+func (r report) paths() []string {
+	paths := make([]string, 0, len(r))
+	for p := range r {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// total returns the coverage of every file.
+func (r report) total() counts {
+	total := counts{}
+	for _, f := range r {
+		total.add(f.counts(allLines))
+	}
+	return total
+}
+
+// parseLCOV adds the DA and BRDA records of an LCOV tracefile to the report.
+// Records of the same file, for example from several tests, are merged by
+// adding up their counts.
+func (r report) parseLCOV(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var current *fileCoverage
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		kind, value, _ := strings.Cut(line, ":")
+		switch kind {
+		case "SF":
+			current = r.file(value)
+		case "end_of_record":
+			current = nil
+		case "DA":
+			if current == nil {
+				return fmt.Errorf("line %d: DA record outside of a file record", n)
+			}
+			fields := strings.Split(value, ",")
+			if len(fields) < 2 {
+				return fmt.Errorf("line %d: invalid DA record %q", n, line)
+			}
+			lineNumber, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return fmt.Errorf("line %d: invalid DA record %q", n, line)
+			}
+			hits, err := parseCount(fields[1])
+			if err != nil {
+				return fmt.Errorf("line %d: invalid DA record %q", n, line)
+			}
+			current.lines[lineNumber] += hits
+		case "BRDA":
+			if current == nil {
+				return fmt.Errorf("line %d: BRDA record outside of a file record", n)
+			}
+			fields := strings.Split(value, ",")
+			if len(fields) != 4 {
+				return fmt.Errorf("line %d: invalid BRDA record %q", n, line)
+			}
+			lineNumber, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return fmt.Errorf("line %d: invalid BRDA record %q", n, line)
+			}
+			// A branch that was never evaluated is taken "-" times.
+			taken := 0
+			if fields[3] != "-" {
+				if taken, err = parseCount(fields[3]); err != nil {
+					return fmt.Errorf("line %d: invalid BRDA record %q", n, line)
+				}
+			}
+			current.branches[branch{line: lineNumber, block: fields[1], branch: fields[2]}] += taken
+		}
+	}
+	return scanner.Err()
+}
+
+// parseCount parses an execution count, which some tools write as a float.
+func parseCount(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return int(f), err
+}
+
+// counts is the number of instrumented and covered lines and branches.
+type counts struct {
+	lines, linesHit       int
+	branches, branchesHit int
+}
+
+func (c *counts) add(o counts) {
+	c.lines += o.lines
+	c.linesHit += o.linesHit
+	c.branches += o.branches
+	c.branchesHit += o.branchesHit
+}
+
+// counts returns the coverage of the lines of the file for which include
+// returns true, and of their branches.
+func (f *fileCoverage) counts(include func(line int) bool) counts {
+	c := counts{}
+	for line, hits := range f.lines {
+		if include(line) {
+			c.lines++
+			if hits > 0 {
+				c.linesHit++
+			}
+		}
+	}
+	for b, taken := range f.branches {
+		if include(b.line) {
+			c.branches++
+			if taken > 0 {
+				c.branchesHit++
+			}
+		}
+	}
+	return c
+}
+
+func allLines(int) bool { return true }
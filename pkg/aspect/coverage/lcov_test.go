@@ -0,0 +1,72 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const fooLCOV = `SF:foo/foo.go
+FN:3,Foo
+FNDA:1,Foo
+DA:3,1
+DA:4,1
+DA:5,0
+BRDA:4,0,0,1
+BRDA:4,0,1,-
+LH:2
+LF:3
+end_of_record
+SF:foo/bar/bar.go
+DA:1,0
+end_of_record
+`
+
+func TestParseLCOV(t *testing.T) {
+	t.Run("counts the covered lines and branches", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		r := report{}
+		g.Expect(r.parseLCOV(strings.NewReader(fooLCOV))).To(Succeed())
+
+		g.Expect(r.paths()).To(Equal([]string{"foo/bar/bar.go", "foo/foo.go"}))
+		g.Expect(r["foo/foo.go"].counts(allLines)).To(Equal(counts{lines: 3, linesHit: 2, branches: 2, branchesHit: 1}))
+		g.Expect(r.total()).To(Equal(counts{lines: 4, linesHit: 2, branches: 2, branchesHit: 1}))
+	})
+
+	t.Run("merges the records of the same file", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		r := report{}
+		g.Expect(r.parseLCOV(strings.NewReader(fooLCOV))).To(Succeed())
+		g.Expect(r.parseLCOV(strings.NewReader("SF:foo/foo.go\nDA:5,2.0\nBRDA:4,0,1,3\nend_of_record\n"))).To(Succeed())
+
+		g.Expect(r["foo/foo.go"].lines).To(Equal(map[int]int{3: 1, 4: 1, 5: 2}))
+		g.Expect(r["foo/foo.go"].counts(allLines)).To(Equal(counts{lines: 3, linesHit: 3, branches: 2, branchesHit: 2}))
+	})
+
+	t.Run("fails on invalid records", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(report{}.parseLCOV(strings.NewReader("DA:1,1\n"))).To(MatchError(ContainSubstring("line 1: DA record outside of a file record")))
+		g.Expect(report{}.parseLCOV(strings.NewReader("SF:a.go\nDA:x,1\n"))).To(MatchError(ContainSubstring(`line 2: invalid DA record "DA:x,1"`)))
+		g.Expect(report{}.parseLCOV(strings.NewReader("SF:a.go\nBRDA:1,0,0\n"))).To(MatchError(ContainSubstring("invalid BRDA record")))
+	})
+}
@@ -0,0 +1,394 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+)
+
+const (
+	// ReportFlagName selects the coverage table printed after the run.
+	ReportFlagName = "coverage:report"
+	// DiffBaseFlagName reports the coverage of the lines changed since a git
+	// revision.
+	DiffBaseFlagName = "coverage:diff_base"
+
+	// MinConfigKey is the config.yaml attribute with the coverage thresholds.
+	MinConfigKey = "coverage.min"
+)
+
+// The values of --coverage:report.
+const (
+	packageReport = "package"
+	fileReport    = "file"
+	noReport      = "none"
+)
+
+// besCompletionTimeout is how long to wait, once Bazel has exited, for the
+// BuildFinished event to reach the coverage subscriber.
+const besCompletionTimeout = 60 * time.Second
+
+// coverageFiles collects the coverage data reported in the build events of an
+// invocation.
+type coverageFiles struct {
+	workspaceRoot string
+
+	mutex         sync.Mutex
+	started       bool
+	done          bool
+	finished      chan struct{}
+	localExecRoot string
+	lcovs         []*buildeventstream.File
+}
+
+func newCoverageFiles(workspaceRoot string) *coverageFiles {
+	return &coverageFiles{workspaceRoot: workspaceRoot, finished: make(chan struct{})}
+}
+
+func (c *coverageFiles) bepEventCallback(event *buildeventstream.BuildEvent, _ int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch {
+	case event.GetStarted() != nil:
+		c.started = true
+	case event.GetFinished() != nil:
+		if !c.done {
+			c.done = true
+			close(c.finished)
+		}
+	case event.GetWorkspaceInfo() != nil:
+		c.localExecRoot = event.GetWorkspaceInfo().GetLocalExecRoot()
+	case event.GetTestResult() != nil:
+		for _, f := range event.GetTestResult().GetTestActionOutput() {
+			// Bazel reports the coverage.dat of a test as test.lcov.
+			if f.GetName() == "test.lcov" {
+				c.lcovs = append(c.lcovs, f)
+			}
+		}
+	}
+	return nil
+}
+
+// wait blocks until the BuildFinished event was received, or the timeout
+// expires. When BuildStarted wasn't received Bazel never got to run a build and
+// there is nothing to wait for.
+func (c *coverageFiles) wait(timeout time.Duration) error {
+	c.mutex.Lock()
+	started := c.started
+	c.mutex.Unlock()
+	if !started {
+		return nil
+	}
+	select {
+	case <-c.finished:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out waiting for the build finished event")
+	}
+}
+
+// combinedReportPath returns the path of the report that Bazel merges from
+// the coverage of every test with --combined_report=lcov.
+func (c *coverageFiles) combinedReportPath() string {
+	root := c.workspaceRoot
+	if c.localExecRoot != "" {
+		root = c.localExecRoot
+	}
+	return filepath.Join(root, "bazel-out", "_coverage", "_coverage_report.dat")
+}
+
+// load reads the combined coverage report if it was written since the
+// invocation started, or else merges the coverage of every test. It returns
+// the report and where it was read from.
+func (c *coverageFiles) load(started time.Time) (report, string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	r := report{}
+	combined := c.combinedReportPath()
+	if info, err := os.Stat(combined); err == nil && info.Size() > 0 && !info.ModTime().Before(started) {
+		if err := parseLCOVFile(r, combined); err != nil {
+			return nil, "", err
+		}
+		return r, combined, nil
+	}
+
+	read := 0
+	for _, f := range c.lcovs {
+		if uri := f.GetUri(); strings.HasPrefix(uri, "bytestream://") && strings.HasSuffix(uri, "/0") {
+			continue
+		}
+		p, err := bep.LocalFilePath(f, c.workspaceRoot, c.localExecRoot)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		if err := parseLCOVFile(r, p); err != nil {
+			return nil, "", err
+		}
+		read++
+	}
+	if read == 0 {
+		return nil, "", nil
+	}
+	if read == 1 {
+		return r, "the coverage of 1 test", nil
+	}
+	return r, fmt.Sprintf("the coverage of %d tests", read), nil
+}
+
+func parseLCOVFile(r report, p string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := r.parseLCOV(f); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", p, err)
+	}
+	return nil
+}
+
+// writeTable writes the line and branch coverage of every package or file,
+// followed by the total.
+func writeTable(w io.Writer, r report, groupBy string, workspaceRoot string) error {
+	groups := map[string]*counts{}
+	keys := []string{}
+	for _, p := range r.paths() {
+		key := p
+		if groupBy == packageReport {
+			key = owningPackage(workspaceRoot, p)
+		}
+		if groups[key] == nil {
+			groups[key] = &counts{}
+			keys = append(keys, key)
+		}
+		groups[key].add(r[p].counts(allLines))
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tLINES\tBRANCHES\n", strings.ToUpper(groupBy))
+	for _, key := range keys {
+		c := groups[key]
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key, ratio(c.linesHit, c.lines), ratio(c.branchesHit, c.branches))
+	}
+	total := r.total()
+	fmt.Fprintf(tw, "TOTAL\t%s\t%s\n", ratio(total.linesHit, total.lines), ratio(total.branchesHit, total.branches))
+	return tw.Flush()
+}
+
+func ratio(hit int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%% (%d/%d)", percent(hit, total), hit, total)
+}
+
+func percent(hit int, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return 100 * float64(hit) / float64(total)
+}
+
+// owningPackage returns the label of the package of a source file, which is
+// the closest directory with a BUILD file, or else the directory of the file.
+func owningPackage(workspaceRoot string, file string) string {
+	dir := path.Dir(filepath.ToSlash(file))
+	for d := dir; ; d = path.Dir(d) {
+		if d == "." {
+			d = ""
+		}
+		for _, f := range []string{"BUILD", "BUILD.bazel"} {
+			if info, err := os.Stat(filepath.Join(workspaceRoot, filepath.FromSlash(d), f)); err == nil && !info.IsDir() {
+				return "//" + d
+			}
+		}
+		if d == "" {
+			break
+		}
+	}
+	if dir == "." {
+		return "//"
+	}
+	return "//" + dir
+}
+
+// changedFile is the set of lines of a file that changed since the diff base.
+type changedFile struct {
+	// all is set for new files that aren't tracked by git yet.
+	all   bool
+	lines map[int]bool
+}
+
+func (c *changedFile) contains(line int) bool {
+	return c.all || c.lines[line]
+}
+
+// changedLines returns the lines that differ between the merge base of base
+// and HEAD and the working tree, keyed by their path relative to the
+// workspace root.
+func changedLines(workspaceRoot string, base string) (map[string]*changedFile, error) {
+	mergeBase, err := git(workspaceRoot, "merge-base", base, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := git(workspaceRoot, "diff", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames", "--no-prefix", "--relative", mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	changed := parseUnifiedDiff(diff)
+	untracked, err := git(workspaceRoot, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(untracked, "\x00") {
+		if p != "" {
+			changed[p] = &changedFile{all: true}
+		}
+	}
+	return changed, nil
+}
+
+// parseUnifiedDiff returns the added and modified lines of a diff without
+// context lines, as produced by `git diff --unified=0 --no-prefix`.
+func parseUnifiedDiff(diff string) map[string]*changedFile {
+	changed := map[string]*changedFile{}
+	var current *changedFile
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = nil
+			if p := strings.TrimSuffix(strings.TrimPrefix(line, "+++ "), "\t"); p != "/dev/null" {
+				current = &changedFile{lines: map[int]bool{}}
+				changed[p] = current
+			}
+		case strings.HasPrefix(line, "@@ ") && current != nil:
+			// @@ -start[,count] +start[,count] @@
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+				continue
+			}
+			startText, countText, hasCount := strings.Cut(fields[2][1:], ",")
+			start, err := strconv.Atoi(startText)
+			if err != nil {
+				continue
+			}
+			count := 1
+			if hasCount {
+				if count, err = strconv.Atoi(countText); err != nil {
+					continue
+				}
+			}
+			for l := start; l < start+count; l++ {
+				current.lines[l] = true
+			}
+		}
+	}
+	return changed
+}
+
+// changedCoverage is the coverage of the changed lines.
+type changedCoverage struct {
+	counts
+	// uncovered lists the changed lines that didn't run, by file.
+	uncovered map[string][]int
+}
+
+func coverageOfChanges(r report, changed map[string]*changedFile) *changedCoverage {
+	result := &changedCoverage{uncovered: map[string][]int{}}
+	for _, p := range r.paths() {
+		c, ok := changed[p]
+		if !ok {
+			continue
+		}
+		f := r[p]
+		result.add(f.counts(c.contains))
+		for line, hits := range f.lines {
+			if hits == 0 && c.contains(line) {
+				result.uncovered[p] = append(result.uncovered[p], line)
+			}
+		}
+		sort.Ints(result.uncovered[p])
+	}
+	return result
+}
+
+func writeChangedCoverage(w io.Writer, c *changedCoverage, base string) {
+	if c.lines == 0 {
+		fmt.Fprintf(w, "No instrumented lines changed since %s\n", base)
+		return
+	}
+	fmt.Fprintf(w, "Changed lines since %s: %s covered\n", base, ratio(c.linesHit, c.lines))
+	paths := make([]string, 0, len(c.uncovered))
+	for p := range c.uncovered {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		fmt.Fprintf(w, "  %s: %s not covered\n", p, lineRanges(c.uncovered[p]))
+	}
+}
+
+// lineRanges formats sorted line numbers as ranges, such as 3-5, 9.
+func lineRanges(lines []int) string {
+	ranges := []string{}
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(lines[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
+func git(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run git %s: %w\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+)
+
+func fooReport(t *testing.T) report {
+	r := report{}
+	if err := r.parseLCOV(strings.NewReader(fooLCOV)); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func writeFile(t *testing.T, p string, content string) {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	t.Run("groups the files by package", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		workspaceRoot := t.TempDir()
+		writeFile(t, filepath.Join(workspaceRoot, "foo", "BUILD.bazel"), "")
+
+		var out strings.Builder
+		g.Expect(writeTable(&out, fooReport(t), packageReport, workspaceRoot)).To(Succeed())
+		g.Expect(out.String()).To(Equal(`PACKAGE  LINES        BRANCHES
+//foo    50.0% (2/4)  50.0% (1/2)
+TOTAL    50.0% (2/4)  50.0% (1/2)
+`))
+	})
+
+	t.Run("lists every file", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out strings.Builder
+		g.Expect(writeTable(&out, fooReport(t), fileReport, t.TempDir())).To(Succeed())
+		g.Expect(out.String()).To(Equal(`FILE            LINES        BRANCHES
+foo/bar/bar.go  0.0% (0/1)   -
+foo/foo.go      66.7% (2/3)  50.0% (1/2)
+TOTAL           50.0% (2/4)  50.0% (1/2)
+`))
+	})
+}
+
+func TestChangedCoverage(t *testing.T) {
+	diff := `diff --git foo/foo.go foo/foo.go
+index 1111111..2222222 100644
+--- foo/foo.go
++++ foo/foo.go
+@@ -3,0 +4,2 @@ func Foo() {
++	if x {
++		return
+@@ -10 +12 @@ func Bar() {
+-	old()
++	new()
+diff --git gone.go gone.go
+deleted file mode 100644
+--- gone.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package gone
+`
+
+	t.Run("parses the added lines of a diff", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		changed := parseUnifiedDiff(diff)
+		g.Expect(changed).To(HaveLen(1))
+		g.Expect(changed["foo/foo.go"].lines).To(Equal(map[int]bool{4: true, 5: true, 12: true}))
+	})
+
+	t.Run("reports the changed lines that didn't run", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		changed := parseUnifiedDiff(diff)
+		changed["foo/bar/bar.go"] = &changedFile{all: true}
+		c := coverageOfChanges(fooReport(t), changed)
+
+		g.Expect(c.counts).To(Equal(counts{lines: 3, linesHit: 1, branches: 2, branchesHit: 1}))
+		g.Expect(c.uncovered).To(Equal(map[string][]int{"foo/foo.go": {5}, "foo/bar/bar.go": {1}}))
+
+		var out strings.Builder
+		writeChangedCoverage(&out, c, "main")
+		g.Expect(out.String()).To(Equal(`Changed lines since main: 33.3% (1/3) covered
+  foo/bar/bar.go: 1 not covered
+  foo/foo.go: 5 not covered
+`))
+	})
+
+	t.Run("formats line ranges", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(lineRanges([]int{3, 4, 5, 9, 11, 12})).To(Equal("3-5, 9, 11-12"))
+		g.Expect(lineRanges(nil)).To(Equal(""))
+	})
+}
+
+func TestLoad(t *testing.T) {
+	lcovFile := func(p string) *buildeventstream.File {
+		return &buildeventstream.File{Name: "test.lcov", File: &buildeventstream.File_Uri{Uri: "file://" + p}}
+	}
+	testResult := func(files ...*buildeventstream.File) *buildeventstream.BuildEvent {
+		return &buildeventstream.BuildEvent{
+			Payload: &buildeventstream.BuildEvent_TestResult{
+				TestResult: &buildeventstream.TestResult{TestActionOutput: files},
+			},
+		}
+	}
+
+	t.Run("merges the coverage of every test", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "a", "coverage.dat"), "SF:foo/foo.go\nDA:1,1\nDA:2,0\nend_of_record\n")
+		writeFile(t, filepath.Join(dir, "b", "coverage.dat"), "SF:foo/foo.go\nDA:2,1\nend_of_record\n")
+
+		files := newCoverageFiles(dir)
+		g.Expect(files.bepEventCallback(testResult(lcovFile(filepath.Join(dir, "a", "coverage.dat"))), 0)).To(Succeed())
+		g.Expect(files.bepEventCallback(testResult(lcovFile(filepath.Join(dir, "b", "coverage.dat"))), 1)).To(Succeed())
+		g.Expect(files.bepEventCallback(testResult(lcovFile(filepath.Join(dir, "missing.dat"))), 2)).To(Succeed())
+
+		r, source, err := files.load(time.Now())
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(source).To(Equal("the coverage of 2 tests"))
+		g.Expect(r.total()).To(Equal(counts{lines: 2, linesHit: 2}))
+	})
+
+	t.Run("prefers the combined report of this invocation", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		started := time.Now().Add(-time.Minute)
+		combined := filepath.Join(dir, "bazel-out", "_coverage", "_coverage_report.dat")
+		writeFile(t, combined, fooLCOV)
+		writeFile(t, filepath.Join(dir, "coverage.dat"), "SF:other.go\nDA:1,1\nend_of_record\n")
+
+		files := newCoverageFiles(dir)
+		g.Expect(files.bepEventCallback(testResult(lcovFile(filepath.Join(dir, "coverage.dat"))), 0)).To(Succeed())
+
+		r, source, err := files.load(started)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(source).To(Equal(combined))
+		g.Expect(r.paths()).To(Equal([]string{"foo/bar/bar.go", "foo/foo.go"}))
+
+		// A combined report from an earlier invocation is ignored.
+		r, source, err = files.load(time.Now().Add(time.Minute))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(source).To(Equal("the coverage of 1 test"))
+		g.Expect(r.paths()).To(Equal([]string{"other.go"}))
+	})
+
+	t.Run("returns no report without coverage data", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		r, _, err := newCoverageFiles(t.TempDir()).load(time.Now())
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(r).To(BeNil())
+	})
+}
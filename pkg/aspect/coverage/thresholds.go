@@ -0,0 +1,109 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// thresholds are the minimum coverage percentages set with the `coverage.min`
+// attribute of the Aspect CLI config.yaml, either as a single line coverage
+// percentage or for each kind of coverage:
+//
+//	coverage:
+//	  min:
+//	    lines: 80
+//	    branches: 60
+//	    changed_lines: 90
+//
+// changed_lines only applies when --coverage:diff_base is set. A threshold of
+// zero isn't enforced.
+type thresholds struct {
+	lines        float64
+	branches     float64
+	changedLines float64
+}
+
+// unmarshalThresholds parses the `coverage.min` config.yaml attribute.
+func unmarshalThresholds(data interface{}) (*thresholds, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if n, ok := toPercentage(data); ok {
+		return &thresholds{lines: n}, nil
+	}
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected %v config to be a percentage or a map", MinConfigKey)
+	}
+	t := &thresholds{}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		n, ok := toPercentage(m[key])
+		if !ok {
+			return nil, fmt.Errorf("expected %v '%v' attribute to be a percentage between 0 and 100", MinConfigKey, key)
+		}
+		switch key {
+		case "lines":
+			t.lines = n
+		case "branches":
+			t.branches = n
+		case "changed_lines":
+			t.changedLines = n
+		default:
+			return nil, fmt.Errorf("unknown %v attribute '%v', expected lines, branches or changed_lines", MinConfigKey, key)
+		}
+	}
+	return t, nil
+}
+
+func toPercentage(v interface{}) (float64, bool) {
+	var n float64
+	switch v := v.(type) {
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case float64:
+		n = v
+	default:
+		return 0, false
+	}
+	return n, n >= 0 && n <= 100
+}
+
+// check returns a message for every threshold that the coverage is below.
+// changed is nil when the coverage of the changed lines wasn't computed.
+func (t *thresholds) check(total counts, changed *changedCoverage) []string {
+	violations := []string{}
+	below := func(kind string, hit int, found int, min float64) {
+		if min > 0 && found > 0 && percent(hit, found) < min {
+			violations = append(violations, fmt.Sprintf("%s coverage of %.1f%% is below the minimum of %g%%", kind, percent(hit, found), min))
+		}
+	}
+	below("Line", total.linesHit, total.lines, t.lines)
+	below("Branch", total.branchesHit, total.branches, t.branches)
+	if changed != nil {
+		below("Changed line", changed.linesHit, changed.lines, t.changedLines)
+	}
+	return violations
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package coverage
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestThresholds(t *testing.T) {
+	t.Run("a number is the minimum line coverage", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		min, err := unmarshalThresholds(80)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(*min).To(Equal(thresholds{lines: 80}))
+	})
+
+	t.Run("a map sets each minimum", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		min, err := unmarshalThresholds(map[string]interface{}{"lines": 80, "branches": 62.5, "changed_lines": 90})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(*min).To(Equal(thresholds{lines: 80, branches: 62.5, changedLines: 90}))
+	})
+
+	t.Run("no minimum is unset", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		min, err := unmarshalThresholds(nil)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(min).To(BeNil())
+	})
+
+	t.Run("rejects invalid minimums", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := unmarshalThresholds(120)
+		g.Expect(err).To(HaveOccurred())
+		_, err = unmarshalThresholds(map[string]interface{}{"statements": 80})
+		g.Expect(err).To(HaveOccurred())
+		_, err = unmarshalThresholds("high")
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("reports the coverage below the minimums", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		min := &thresholds{lines: 80, branches: 50, changedLines: 90}
+		total := counts{lines: 10, linesHit: 7, branches: 4, branchesHit: 2}
+		changed := &changedCoverage{counts: counts{lines: 4, linesHit: 3}}
+
+		g.Expect(min.check(total, changed)).To(Equal([]string{
+			"Line coverage of 70.0% is below the minimum of 80%",
+			"Changed line coverage of 75.0% is below the minimum of 90%",
+		}))
+		g.Expect(min.check(counts{lines: 10, linesHit: 8}, nil)).To(BeEmpty())
+	})
+}
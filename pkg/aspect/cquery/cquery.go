@@ -22,6 +22,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/query/shared"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
@@ -59,6 +60,12 @@ func New(streams ioutils.Streams, bzl bazel.Bazel, isInteractive bool) *CQuery {
 }
 
 func (runner *CQuery) Run(ctx context.Context, cmd *cobra.Command, args []string) (exitErr error) {
+	graphOptions, err := graph.OptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	args = bazel.RemoveFlags(cmd.CalledAs(), cmd.Flags(), args, graph.GraphFlagName, graph.DepthFlagName, graph.ExcludeExternalFlagName)
+
 	nonFlags, flags, err := bazel.SeparateBazelFlags(cmd.CalledAs(), args)
 	if err != nil {
 		return err
@@ -69,19 +76,35 @@ func (runner *CQuery) Run(ctx context.Context, cmd *cobra.Command, args []string
 		return shared.GetPrettyError(cmd, err)
 	}
 
-	command, query, runReplacements, err := shared.SelectQuery(cmd.CalledAs(), presets, runner.Presets, presetNames, runner.Streams, nonFlags, flags, runner.Select)
+	// When rendering a graph, messages about the selected preset go to stderr so that stdout only
+	// has the graph.
+	messages := runner.Streams
+	if graphOptions != nil {
+		messages.Stdout = runner.Stderr
+	}
+
+	command, query, preset, err := shared.SelectQuery(cmd.CalledAs(), presets, runner.Presets, presetNames, messages, nonFlags, flags, runner.Select)
 	if err != nil {
 		return shared.GetPrettyError(cmd, err)
 	}
 
-	if runReplacements {
-		query, err = shared.ReplacePlaceholders(query, nonFlags, runner.Prompt)
+	if preset != nil {
+		var otherFlags []string
+		query, otherFlags, err = shared.ExpandPreset(preset, messages, nonFlags, shared.IsInteractive(cmd, runner.IsInteractive), runner.Prompt)
 		if err != nil {
 			return shared.GetPrettyError(cmd, err)
 		}
 
-		return shared.RunQuery(runner.Bzl, command, runner.Streams, append(flags, query))
+		bazelFlags := append(append(append([]string{}, preset.Flags...), flags...), otherFlags...)
+		return runner.runQuery(command, graphOptions, append(bazelFlags, query))
 	} else {
-		return shared.RunQuery(runner.Bzl, command, runner.Streams, args)
+		return runner.runQuery(command, graphOptions, args)
+	}
+}
+
+func (runner *CQuery) runQuery(command string, graphOptions *graph.Options, args []string) error {
+	if graphOptions != nil {
+		return graph.Run(runner.Bzl, command, runner.Streams, args, graphOptions)
 	}
+	return shared.RunQuery(runner.Bzl, command, runner.Streams, args)
 }
@@ -25,8 +25,12 @@ import (
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
 	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/bazel/query"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/cquery"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/graph"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/query/shared"
 	query_mock "github.com/aspect-build/aspect-cli/pkg/aspect/query/shared/mock"
 	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
@@ -228,4 +232,54 @@ func TestQuery(t *testing.T) {
 		err := q.Run(context.Background(), cmd, []string{})
 		g.Expect(err).To(BeNil())
 	})
+
+	t.Run("renders a preset query as a graph", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result, err := proto.Marshal(&analysis.CqueryResult{
+			Results: []*analysis.ConfiguredTarget{
+				{Target: &query.Target{
+					Type: query.Target_RULE.Enum(),
+					Rule: &query.Rule{Name: proto.String("//a"), RuleClass: proto.String("go_binary"), ConfiguredRuleInput: []*query.ConfiguredRuleInput{{Label: proto.String("//b")}}},
+				}},
+				{Target: &query.Target{
+					Type: query.Target_RULE.Enum(),
+					Rule: &query.Rule{Name: proto.String("//b"), RuleClass: proto.String("go_library")},
+				}},
+			},
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		var stdout, stderr strings.Builder
+		streams := ioutils.Streams{Stdout: &stdout, Stderr: &stderr}
+		spawner := bazel_mock.NewMockBazel(ctrl)
+		spawner.
+			EXPECT().
+			RunCommand(gomock.Any(), nil, "cquery", "--output=proto", "somepath(//a, //b)").
+			DoAndReturn(func(streams ioutils.Streams, _ *string, _ ...string) error {
+				_, err := streams.Stdout.Write(result)
+				return err
+			})
+
+		q := cquery.New(streams, spawner, true)
+		q.Presets = []*shared.PresetQuery{
+			{
+				Name:        "why",
+				Description: "Determine why a target depends on another",
+				Query:       "somepath(?target, ?dependency)",
+				Verb:        "cquery",
+				Flags:       []string{"--output=label_kind"},
+			},
+		}
+
+		cmd := &cobra.Command{Use: "cquery"}
+		graph.AddFlags(cmd.Flags())
+		g.Expect(cmd.ParseFlags([]string{"--graph=dot"})).To(Succeed())
+		g.Expect(q.Run(context.Background(), cmd, []string{"why", "//a", "//b"})).To(Succeed())
+		g.Expect(stdout.String()).To(HavePrefix("digraph query {"))
+		g.Expect(stdout.String()).To(ContainSubstring(`"//a" -> "//b";`))
+		g.Expect(stderr.String()).To(ContainSubstring(`Preset query "why" selected`))
+	})
 }
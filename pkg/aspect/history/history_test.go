@@ -0,0 +1,182 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/bazel/command_line"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func options(section string, values ...string) *command_line.CommandLineSection {
+	list := &command_line.OptionList{}
+	for _, v := range values {
+		list.Option = append(list.Option, &command_line.Option{CombinedForm: v})
+	}
+	return &command_line.CommandLineSection{
+		SectionLabel: section,
+		SectionType:  &command_line.CommandLineSection_OptionList{OptionList: list},
+	}
+}
+
+func chunks(section string, values ...string) *command_line.CommandLineSection {
+	return &command_line.CommandLineSection{
+		SectionLabel: section,
+		SectionType:  &command_line.CommandLineSection_ChunkList{ChunkList: &command_line.ChunkList{Chunk: values}},
+	}
+}
+
+// recordInvocation records a failed test invocation and returns a store
+// containing it.
+func recordInvocation(t *testing.T, stderrPath string) *history.Store {
+	store := history.NewStore(t.TempDir())
+	recorder := history.NewRecorder(store, history.Retention{})
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []*buildeventstream.BuildEvent{
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Started{Started: &buildeventstream.BuildEventId_BuildStartedId{}}},
+			Payload: &buildeventstream.BuildEvent_Started{Started: &buildeventstream.BuildStarted{
+				Uuid:             "0c4b7a1e-0000-0000-0000-000000000000",
+				Command:          "test",
+				StartTime:        timestamppb.New(start),
+				WorkingDirectory: "/workspace/pkg",
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_StructuredCommandLine{StructuredCommandLine: &buildeventstream.BuildEventId_StructuredCommandLineId{CommandLineLabel: "canonical"}}},
+			Payload: &buildeventstream.BuildEvent_StructuredCommandLine{StructuredCommandLine: &command_line.CommandLine{
+				CommandLineLabel: "canonical",
+				Sections: []*command_line.CommandLineSection{
+					chunks("executable", "bazel"),
+					options("startup options", "--ignore_all_rc_files"),
+					chunks("command", "test"),
+					options("command options", "--config=ci", "--remote_header=x-api-key=secret", "--bes_backend=grpc://127.0.0.1:12345"),
+					chunks("residual", "//...", "-//foo:bar"),
+				},
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_OptionsParsed{OptionsParsed: &buildeventstream.BuildEventId_OptionsParsedId{}}},
+			Payload: &buildeventstream.BuildEvent_OptionsParsed{OptionsParsed: &buildeventstream.OptionsParsed{
+				CmdLine: []string{"--bes_backend=grpcs://remote.example.com", "--config=ci", "--bes_backend=grpc://127.0.0.1:12345"},
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_ActionCompleted{ActionCompleted: &buildeventstream.BuildEventId_ActionCompletedId{Label: "//foo:gen"}}},
+			Payload: &buildeventstream.BuildEvent_Action{Action: &buildeventstream.ActionExecuted{
+				Label:    "//foo:gen",
+				Type:     "Genrule",
+				ExitCode: 1,
+				Stderr:   &buildeventstream.File{File: &buildeventstream.File_Uri{Uri: "file://" + stderrPath}},
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TestSummary{TestSummary: &buildeventstream.BuildEventId_TestSummaryId{Label: "//foo:test"}}},
+			Payload: &buildeventstream.BuildEvent_TestSummary{TestSummary: &buildeventstream.TestSummary{
+				OverallStatus: buildeventstream.TestStatus_FLAKY,
+				TotalRunCount: 2,
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_BuildFinished{BuildFinished: &buildeventstream.BuildEventId_BuildFinishedId{}}},
+			Payload: &buildeventstream.BuildEvent_Finished{Finished: &buildeventstream.BuildFinished{
+				ExitCode:   &buildeventstream.BuildFinished_ExitCode{Code: 1, Name: "BUILD_FAILURE"},
+				FinishTime: timestamppb.New(start.Add(90 * time.Second)),
+			}},
+			LastMessage: true,
+		},
+	}
+	for i, e := range events {
+		if err := recorder.Callback(e, int64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestHistory(t *testing.T) {
+	t.Run("list prints the recorded invocations", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := recordInvocation(t, "")
+		var stdout strings.Builder
+		list := NewList(ioutils.Streams{Stdout: &stdout})
+		list.openStore = func() (*history.Store, error) { return store, nil }
+
+		cmd := &cobra.Command{}
+		cmd.Flags().Int("limit", 20, "")
+		cmd.Flags().String("command", "", "")
+		g.Expect(list.Run(context.Background(), cmd, []string{})).To(Succeed())
+
+		g.Expect(stdout.String()).To(ContainSubstring("0c4b7a1e-0000-0000-0000-000000000000"))
+		g.Expect(stdout.String()).To(ContainSubstring("1m30s"))
+		g.Expect(stdout.String()).To(ContainSubstring("FAILED (BUILD_FAILURE)"))
+	})
+
+	t.Run("show prints the failed actions with their stderr and the test results", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		stderrPath := filepath.Join(t.TempDir(), "stderr")
+		g.Expect(os.WriteFile(stderrPath, []byte("gen.sh: command not found\n"), 0644)).To(Succeed())
+		store := recordInvocation(t, stderrPath)
+		var stdout strings.Builder
+		show := NewShow(ioutils.Streams{Stdout: &stdout})
+		show.openStore = func() (*history.Store, error) { return store, nil }
+
+		g.Expect(show.Run(context.Background(), nil, []string{"0c4b"})).To(Succeed())
+
+		g.Expect(stdout.String()).To(ContainSubstring("Command:    bazel --ignore_all_rc_files test --config=ci"))
+		g.Expect(stdout.String()).To(ContainSubstring("Genrule //foo:gen (exit code 1)\n    gen.sh: command not found\n"))
+		g.Expect(stdout.String()).To(MatchRegexp(`FLAKY\s+//foo:test \(2 runs\)`))
+	})
+
+	t.Run("rerun replays the canonical command line with the user's BES backends", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := recordInvocation(t, "")
+		var stderr strings.Builder
+		streams := ioutils.Streams{Stderr: &stderr}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		wd := "/workspace/pkg"
+		bzl.
+			EXPECT().
+			RunCommand(streams, &wd, "--ignore_all_rc_files", "test", "--config=ci", "--bes_backend=grpcs://remote.example.com", "--", "//...", "-//foo:bar").
+			Return(nil)
+		rerun := NewRerun(streams, bzl)
+		rerun.openStore = func() (*history.Store, error) { return store, nil }
+
+		g.Expect(rerun.Run(context.Background(), nil, []string{"0c4b7a1e"})).To(Succeed())
+		g.Expect(stderr.String()).To(ContainSubstring("Rerunning invocation 0c4b7a1e-0000-0000-0000-000000000000"))
+		g.Expect(stderr.String()).To(ContainSubstring("Not replaying --remote_header=<REDACTED>"))
+	})
+}
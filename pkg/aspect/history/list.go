@@ -0,0 +1,120 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// List represents the aspect history list command.
+type List struct {
+	ioutils.Streams
+	openStore func() (*history.Store, error)
+}
+
+// NewList creates a List command.
+func NewList(streams ioutils.Streams) *List {
+	return &List{
+		Streams:   streams,
+		openStore: history.NewDefaultStore,
+	}
+}
+
+// Run prints the recorded invocations, most recent first.
+func (runner *List) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	command, _ := cmd.Flags().GetString("command")
+
+	store, err := runner.openStore()
+	if err != nil {
+		return err
+	}
+	invocations, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(runner.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTARTED\tCOMMAND\tDURATION\tCACHE HITS\tSTATUS\tTARGETS")
+	n := 0
+	for _, i := range invocations {
+		if command != "" && i.Command != command {
+			continue
+		}
+		if limit > 0 && n == limit {
+			break
+		}
+		n++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i.ID,
+			i.StartTime.Local().Format(time.DateTime),
+			i.Command,
+			formatDuration(i.Duration()),
+			formatCacheHitRate(i.Actions),
+			formatStatus(i),
+			strings.Join(i.Targets, " "),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if n == 0 {
+		fmt.Fprintf(runner.Stderr, "No invocations recorded in %s\n", store.Dir())
+		if config, err := history.LoadConfig(); err == nil && !config.Enabled {
+			fmt.Fprintf(runner.Stderr, "Recording is disabled, enable it with history.enabled in the Aspect CLI config.yaml\n")
+		}
+	}
+	return nil
+}
+
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func formatCacheHitRate(actions *history.ActionStats) string {
+	rate, ok := actions.CacheHitRate()
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+func formatStatus(i *history.Invocation) string {
+	switch {
+	case !i.Complete:
+		return color.YellowString("INCOMPLETE")
+	case i.Success():
+		return color.GreenString("PASSED")
+	case i.ExitName != "":
+		return color.RedString("FAILED (%s)", i.ExitName)
+	default:
+		return color.RedString("FAILED (exit code %d)", i.ExitCode)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/besproxy"
+)
+
+const besBackendPrefix = "--bes_backend="
+
+// Rerun represents the aspect history rerun command.
+type Rerun struct {
+	ioutils.Streams
+	bzl       bazel.Bazel
+	openStore func() (*history.Store, error)
+}
+
+// NewRerun creates a Rerun command.
+func NewRerun(streams ioutils.Streams, bzl bazel.Bazel) *Rerun {
+	return &Rerun{
+		Streams:   streams,
+		bzl:       bzl,
+		openStore: history.NewDefaultStore,
+	}
+}
+
+// Run replays the canonical command line of a recorded invocation from the
+// working directory it was originally run in.
+func (runner *Rerun) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	store, err := runner.openStore()
+	if err != nil {
+		return err
+	}
+	invocation, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+	report, err := store.Report(invocation.ID)
+	if err != nil {
+		return err
+	}
+	if report.CanonicalCommandLine == nil {
+		return fmt.Errorf("invocation %s did not record its command line and cannot be rerun", invocation.ID)
+	}
+
+	bazelCmd, dropped := replayCommand(report)
+	for _, o := range dropped {
+		fmt.Fprintf(runner.Stderr, "%s Not replaying %s since its value was redacted when it was recorded\n", color.YellowString("WARNING:"), o)
+	}
+	fmt.Fprintf(runner.Stderr, "Rerunning invocation %s: bazel %s\n", invocation.ID, strings.Join(bazelCmd, " "))
+
+	// Add our own BES backend last so that it takes precedence over the BES
+	// backends of the original invocation, which are forwarded through it.
+	if bep.HasBESBackend(ctx) {
+		besBackend := bep.BESBackendFromContext(ctx)
+		bazelCmd = flags.AddFlagToCommand(bazelCmd, besBackendPrefix+besBackend.Addr())
+	}
+
+	var wd *string
+	if report.WorkingDirectory != "" {
+		wd = &report.WorkingDirectory
+	}
+	err = runner.bzl.RunCommand(runner.Streams, wd, bazelCmd...)
+
+	// Check for subscriber errors
+	subscriberErrors := bep.BESErrors(ctx)
+	if len(subscriberErrors) > 0 {
+		for _, err := range subscriberErrors {
+			fmt.Fprintf(runner.Stderr, "Error: failed to rerun invocation %s: %v\n", invocation.ID, err)
+		}
+		if err == nil {
+			err = fmt.Errorf("%v BES subscriber error(s)", len(subscriberErrors))
+		}
+	}
+
+	return err
+}
+
+// replayCommand returns the Bazel arguments that replay an invocation, and the
+// options that were left out because their values were redacted.
+//
+// The canonical command line only has the effective --bes_backend, which is
+// the one Aspect CLI added for its own BES backend and which is gone by now.
+// It is replaced with the BES backends the user configured, taken from the
+// options Bazel parsed, since the canonical startup options ignore bazelrc
+// files.
+func replayCommand(report *history.Report) ([]string, []string) {
+	canonical := report.CanonicalCommandLine
+	replay := *canonical
+	var dropped []string
+	replay.StartupOptions = []string{}
+	for _, o := range canonical.StartupOptions {
+		if strings.Contains(o, besproxy.Redacted) {
+			dropped = append(dropped, o)
+			continue
+		}
+		replay.StartupOptions = append(replay.StartupOptions, o)
+	}
+	replay.CommandOptions = []string{}
+	aspectBesBackend := ""
+	for _, o := range canonical.CommandOptions {
+		if value, ok := strings.CutPrefix(o, besBackendPrefix); ok {
+			aspectBesBackend = value
+			continue
+		}
+		if strings.Contains(o, besproxy.Redacted) {
+			dropped = append(dropped, o)
+			continue
+		}
+		replay.CommandOptions = append(replay.CommandOptions, o)
+	}
+	for _, o := range report.CmdLine {
+		if value, ok := strings.CutPrefix(o, besBackendPrefix); ok && value != aspectBesBackend && !strings.Contains(value, besproxy.Redacted) {
+			replay.CommandOptions = append(replay.CommandOptions, o)
+		}
+	}
+	return replay.Args(), dropped
+}
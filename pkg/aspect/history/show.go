@@ -0,0 +1,171 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+var bold = color.New(color.Bold)
+
+// Show represents the aspect history show command.
+type Show struct {
+	ioutils.Streams
+	openStore func() (*history.Store, error)
+}
+
+// NewShow creates a Show command.
+func NewShow(streams ioutils.Streams) *Show {
+	return &Show{
+		Streams:   streams,
+		openStore: history.NewDefaultStore,
+	}
+}
+
+// Run prints the targets, failed actions and test results of a recorded
+// invocation.
+func (runner *Show) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	store, err := runner.openStore()
+	if err != nil {
+		return err
+	}
+	invocation, err := store.Get(args[0])
+	if err != nil {
+		return err
+	}
+	report, err := store.Report(invocation.ID)
+	if err != nil {
+		return err
+	}
+
+	w := runner.Stdout
+	bold.Fprintf(w, "Invocation %s\n", invocation.ID)
+	if report.CanonicalCommandLine != nil {
+		fmt.Fprintf(w, "Command:    bazel %s\n", strings.Join(report.CanonicalCommandLine.Args(), " "))
+	} else {
+		fmt.Fprintf(w, "Command:    bazel %s %s\n", invocation.Command, strings.Join(invocation.Targets, " "))
+	}
+	fmt.Fprintf(w, "Workspace:  %s\n", invocation.WorkspaceDirectory)
+	fmt.Fprintf(w, "Started:    %s\n", invocation.StartTime.Local().Format(time.DateTime))
+	fmt.Fprintf(w, "Duration:   %s\n", formatDuration(invocation.Duration()))
+	fmt.Fprintf(w, "Cache hits: %s\n", formatCacheHitRate(invocation.Actions))
+	fmt.Fprintf(w, "Status:     %s\n", formatStatus(invocation))
+
+	if len(report.Targets) > 0 {
+		bold.Fprintf(w, "\nTargets (%d):\n", len(report.Targets))
+		for _, t := range report.Targets {
+			status := color.GreenString("OK    ")
+			if !t.Success {
+				status = color.RedString("FAILED")
+			}
+			fmt.Fprintf(w, "  %s %s", status, t.Label)
+			if t.Kind != "" {
+				fmt.Fprintf(w, " (%s)", t.Kind)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	if len(report.FailedActions) > 0 {
+		bold.Fprintf(w, "\nFailed actions (%d):\n", len(report.FailedActions))
+		for _, a := range report.FailedActions {
+			fmt.Fprintf(w, "  %s %s (exit code %d)\n", a.Mnemonic, a.Label, a.ExitCode)
+			runner.printFile(w, a.Stderr)
+		}
+	}
+
+	if len(report.Tests) > 0 {
+		bold.Fprintf(w, "\nTests (%d):\n", len(report.Tests))
+		for _, t := range report.Tests {
+			fmt.Fprintf(w, "  %s %s (%s", formatTestStatus(t.Status), t.Label, formatRuns(t))
+			if t.Duration > 0 {
+				fmt.Fprintf(w, " in %s", formatDuration(t.Duration))
+			}
+			fmt.Fprintln(w, ")")
+		}
+	}
+
+	return nil
+}
+
+// printFile prints the contents of a file referenced by a build event,
+// indented under the action it belongs to. Files that are not available
+// locally, such as those uploaded to a remote cache, are printed as URIs.
+func (runner *Show) printFile(w io.Writer, f *buildeventstream.File) {
+	if f == nil {
+		return
+	}
+	var contents []byte
+	switch {
+	case f.GetContents() != nil:
+		contents = f.GetContents()
+	case strings.HasPrefix(f.GetUri(), "file://"):
+		u, err := url.Parse(f.GetUri())
+		if err == nil {
+			contents, err = os.ReadFile(u.Path)
+		}
+		if err != nil {
+			fmt.Fprintf(w, "    stderr is no longer available: %v\n", err)
+			return
+		}
+	case f.GetUri() != "":
+		fmt.Fprintf(w, "    stderr: %s\n", f.GetUri())
+		return
+	}
+	if len(contents) == 0 {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(contents), "\n"), "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+func formatTestStatus(status buildeventstream.TestStatus) string {
+	s := fmt.Sprintf("%-14s", status.String())
+	switch status {
+	case buildeventstream.TestStatus_PASSED:
+		return color.GreenString(s)
+	case buildeventstream.TestStatus_FLAKY:
+		return color.YellowString(s)
+	default:
+		return color.RedString(s)
+	}
+}
+
+func formatRuns(t *history.TestResult) string {
+	runs := fmt.Sprintf("%d run", t.Runs)
+	if t.Runs != 1 {
+		runs += "s"
+	}
+	if t.Cached > 0 {
+		runs += fmt.Sprintf(", %d cached", t.Cached)
+	}
+	return runs
+}
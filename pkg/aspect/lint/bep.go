@@ -18,14 +18,13 @@ package lint
 
 import (
 	"fmt"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
 )
 
 // ResultForLabelAndMnemonic aggregates the relevant files we find in the BEP for
@@ -60,36 +59,15 @@ func newLintBEPHandler(workspaceRoot string, besCompleted chan<- struct{}) *Lint
 }
 
 func (runner *LintBEPHandler) readBEPFile(file *buildeventstream.File) ([]byte, error) {
-	resultsFile := ""
-
-	switch f := file.File.(type) {
-	case *buildeventstream.File_Uri:
-		uri, err := url.Parse(f.Uri)
-		if err != nil {
-			return nil, fmt.Errorf("unable to parse URI %s: %v", f.Uri, err)
-		}
-		if uri.Scheme == "file" {
-			resultsFile = filepath.Clean(uri.Path)
-		} else if uri.Scheme == "bytestream" {
-			if strings.HasSuffix(uri.Path, "/0") {
-				// No reason to read an empty results file from disk
-				return nil, nil
-			}
-			// Because we set --experimental_remote_download_regex, we can depend on the results file being
-			// in the output tree even when using a remote cache with build without the bytes.
-			// If possible, we use the localExecRoot from the workspaceInfo event when constructing the path
-			// to the results file in case the convenience symlinks are not present, e.g. if
-			// --experimental_convenience_symlinks=ignore is specified.
-			root := runner.workspaceRoot
-			if runner.localExecRoot != "" {
-				root = runner.localExecRoot
-			}
-			resultsFile = path.Join(root, path.Join(file.PathPrefix...), file.Name)
-		} else {
-			return nil, fmt.Errorf("unsupported BES file uri %v", f.Uri)
-		}
-	default:
-		return nil, fmt.Errorf("unsupported BES file type")
+	if uri := file.GetUri(); strings.HasPrefix(uri, "bytestream://") && strings.HasSuffix(uri, "/0") {
+		// No reason to read an empty results file from disk
+		return nil, nil
+	}
+	// Because we set --experimental_remote_download_regex, we can depend on the results file being
+	// in the output tree even when using a remote cache with build without the bytes.
+	resultsFile, err := bep.LocalFilePath(file, runner.workspaceRoot, runner.localExecRoot)
+	if err != nil {
+		return nil, err
 	}
 
 	start := time.Now()
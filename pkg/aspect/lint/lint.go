@@ -245,10 +245,11 @@ lint:
 
 	// Currently Bazel only supports a single --bes_backend so adding ours after
 	// any user supplied value will result in our bes_backend taking precedence.
-	// There is a very old & stale issue to add support for multiple BES
-	// backends https://github.com/bazelbuild/bazel/issues/10908. In the future,
-	// we could build this support into the Aspect CLI and post on that issue
-	// that using the Aspect CLI resolves it.
+	// The user's --bes_backend, whether from the command line or a bazelrc, is
+	// still honored: the BES backend reads the one in effect from the
+	// OptionsParsed event and forwards the stream to it through a proxy, along
+	// with any bes.backends of the config.yaml.
+	// See https://github.com/bazelbuild/bazel/issues/10908.
 	var lintBEPHandler *LintBEPHandler
 	if bep.HasBESBackend(ctx) {
 		besBackend := bep.BESBackendFromContext(ctx)
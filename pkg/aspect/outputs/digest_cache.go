@@ -0,0 +1,180 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aspect-build/aspect-cli/pkg/ioutils/cache"
+)
+
+const (
+	HashCacheFlagName = "hash_cache"
+
+	digestCacheFile = "outputs-digests.json"
+	// digestCacheTTL is how long a digest is kept in the cache after it was
+	// last used.
+	digestCacheTTL = 14 * 24 * time.Hour
+	// racyWindow guards against caching the digest of a file that may still be
+	// modified without its modification time changing, on filesystems with a
+	// coarse timestamp granularity.
+	racyWindow = 2 * time.Second
+)
+
+// digestEntry is the digest of a file along with the identity of the file
+// when it was hashed.
+type digestEntry struct {
+	Inode  uint64 `json:"inode"`
+	MTime  int64  `json:"mtime"`
+	Size   int64  `json:"size"`
+	Digest string `json:"digest"`
+	// Used is the unix time of the last run that used the digest.
+	Used int64 `json:"used"`
+}
+
+// digestCache is a persistent cache of file digests keyed by absolute path.
+// A digest is only reused while the inode, modification time and size of
+// the file are unchanged. A nil *digestCache caches nothing.
+type digestCache struct {
+	path    string
+	now     time.Time
+	mu      sync.Mutex
+	entries map[string]*digestEntry
+	dirty   bool
+}
+
+// defaultDigestCachePath returns the path of the digest cache in the Aspect
+// cache directory.
+func defaultDigestCachePath() (string, error) {
+	dir, err := cache.AspectCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, digestCacheFile), nil
+}
+
+// openDigestCache loads the digest cache at path. A missing or unreadable
+// cache is treated as empty since it can always be rebuilt.
+func openDigestCache(path string) *digestCache {
+	c := &digestCache{
+		path:    path,
+		now:     time.Now(),
+		entries: make(map[string]*digestEntry),
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]*digestEntry)
+		c.dirty = true
+	}
+	return c
+}
+
+// get returns the cached digest of file if file is unchanged since it was
+// hashed.
+func (c *digestCache) get(file string, info fs.FileInfo) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	key, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok || e.Inode != inode(info) || e.MTime != info.ModTime().UnixNano() || e.Size != info.Size() {
+		return "", false
+	}
+	if e.Used != c.now.Unix() {
+		e.Used = c.now.Unix()
+		c.dirty = true
+	}
+	return e.Digest, true
+}
+
+// put records the digest of file.
+func (c *digestCache) put(file string, info fs.FileInfo, digest string) {
+	if c == nil || c.now.Sub(info.ModTime()) < racyWindow {
+		return
+	}
+	key, err := filepath.Abs(file)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = &digestEntry{
+		Inode:  inode(info),
+		MTime:  info.ModTime().UnixNano(),
+		Size:   info.Size(),
+		Digest: digest,
+		Used:   c.now.Unix(),
+	}
+	c.dirty = true
+}
+
+// save prunes the digests that were not used recently and writes the cache
+// back to disk if it changed.
+func (c *digestCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, e := range c.entries {
+		if c.now.Sub(time.Unix(e.Used, 0)) > digestCacheTTL {
+			delete(c.entries, key)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that concurrent runs never read a
+	// partially written cache.
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), digestCacheFile+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// writeOldFile writes a file with a modification time outside of the racy
+// window so that its digest can be cached.
+func writeOldFile(g *WithT, path string, content string) {
+	g.Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	old := time.Now().Add(-time.Hour)
+	g.Expect(os.Chtimes(path, old, old)).To(Succeed())
+}
+
+func TestDigestCache(t *testing.T) {
+	t.Run("hashes identically with and without the cache", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		a := filepath.Join(dir, "a")
+		b := filepath.Join(dir, "b")
+		writeOldFile(g, a, "a")
+		writeOldFile(g, b, "b")
+		labelFiles := map[string][]string{"//a": {a, b}, "//b": {b}}
+
+		uncached, err := HashLabelFiles(labelFiles, 2, "salt")
+		g.Expect(err).NotTo(HaveOccurred())
+
+		cachePath := filepath.Join(dir, "cache.json")
+		cold := openDigestCache(cachePath)
+		first, err := hashLabelFiles(labelFiles, 2, "salt", cold)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cold.save()).To(Succeed())
+
+		warm := openDigestCache(cachePath)
+		g.Expect(warm.entries).To(HaveLen(2))
+		second, err := hashLabelFiles(labelFiles, 0, "salt", warm)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		g.Expect(first).To(Equal(uncached))
+		g.Expect(second).To(Equal(uncached))
+	})
+
+	t.Run("reuses digests of unchanged files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		file := filepath.Join(dir, "file")
+		writeOldFile(g, file, "content")
+		info, err := os.Stat(file)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		c := openDigestCache(filepath.Join(dir, "cache.json"))
+		c.put(file, info, "cafe")
+		line, err := hashFile(file, c)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(line).To(Equal("cafe  " + file + "\n"))
+	})
+
+	t.Run("rehashes files whose size or modification time changed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		file := filepath.Join(dir, "file")
+		writeOldFile(g, file, "content")
+		info, err := os.Stat(file)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		c := openDigestCache(filepath.Join(dir, "cache.json"))
+		c.put(file, info, "cafe")
+		writeOldFile(g, file, "changed content")
+		line, err := hashFile(file, c)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(line).NotTo(HavePrefix("cafe"))
+
+		c.put(file, info, "cafe")
+		later := info.ModTime().Add(time.Minute)
+		g.Expect(os.Chtimes(file, later, later)).To(Succeed())
+		_, ok := c.get(file, info)
+		g.Expect(ok).To(BeTrue())
+		info, err = os.Stat(file)
+		g.Expect(err).NotTo(HaveOccurred())
+		_, ok = c.get(file, info)
+		g.Expect(ok).To(BeFalse())
+	})
+
+	t.Run("does not cache recently modified files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		file := filepath.Join(dir, "file")
+		g.Expect(os.WriteFile(file, []byte("content"), 0644)).To(Succeed())
+
+		c := openDigestCache(filepath.Join(dir, "cache.json"))
+		_, err := hashFile(file, c)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(c.entries).To(BeEmpty())
+	})
+
+	t.Run("prunes digests that were not used recently", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		c := openDigestCache(cachePath)
+		c.entries["/stale"] = &digestEntry{Digest: "cafe", Used: time.Now().Add(-2 * digestCacheTTL).Unix()}
+		c.entries["/fresh"] = &digestEntry{Digest: "cafe", Used: time.Now().Unix()}
+		g.Expect(c.save()).To(Succeed())
+
+		g.Expect(openDigestCache(cachePath).entries).To(HaveKey("/fresh"))
+		g.Expect(openDigestCache(cachePath).entries).NotTo(HaveKey("/stale"))
+	})
+
+	t.Run("treats a corrupt cache as empty", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		cachePath := filepath.Join(t.TempDir(), "cache.json")
+		g.Expect(os.WriteFile(cachePath, []byte("{"), 0644)).To(Succeed())
+
+		g.Expect(openDigestCache(cachePath).entries).To(BeEmpty())
+	})
+
+	t.Run("a nil cache caches nothing", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var c *digestCache
+		_, ok := c.get("file", nil)
+		g.Expect(ok).To(BeFalse())
+		g.Expect(c.save()).To(Succeed())
+	})
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+)
+
+const (
+	OutputFlagName   = "output"
+	FileSizeFlagName = "file_size"
+
+	textOutput  = "text"
+	jsonOutput  = "json"
+	jsonlOutput = "jsonl"
+	csvOutput   = "csv"
+)
+
+var outputFormats = []string{textOutput, jsonOutput, jsonlOutput, csvOutput}
+
+func validateOutputFormat(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --%s %q: must be one of %v", OutputFlagName, format, outputFormats)
+}
+
+// outputRecord is an output file in the structured output formats.
+type outputRecord struct {
+	Label         string `json:"label"`
+	Mnemonic      string `json:"mnemonic"`
+	Path          string `json:"path"`
+	Configuration string `json:"configuration"`
+	// Size is the size of the file in bytes when --file_size is given and the
+	// file is on disk.
+	Size *int64 `json:"size,omitempty"`
+}
+
+// newOutputRecords returns the records of the outputs, sorted by label and path.
+// The sizes are read when withSize is set, from the paths relative to the
+// workspace root since the bazel-out symlink is there.
+func newOutputRecords(outs []bazel.Output, withSize bool, workspaceRoot string) []*outputRecord {
+	records := make([]*outputRecord, 0, len(outs))
+	for _, o := range outs {
+		r := &outputRecord{
+			Label:         o.Label,
+			Mnemonic:      o.Mnemonic,
+			Path:          o.Path,
+			Configuration: o.Configuration,
+		}
+		if withSize {
+			p := o.Path
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(workspaceRoot, p)
+			}
+			if info, err := os.Stat(p); err == nil && !info.IsDir() {
+				size := info.Size()
+				r.Size = &size
+			}
+		}
+		records = append(records, r)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Label != b.Label {
+			return a.Label < b.Label
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Mnemonic < b.Mnemonic
+	})
+	return records
+}
+
+// hashRecord is the ExecutableHash of a label in the structured output
+// formats, with the files that were hashed.
+type hashRecord struct {
+	Label string   `json:"label"`
+	Hash  string   `json:"hash"`
+	Files []string `json:"files"`
+}
+
+func newHashRecords(hashes map[string]string, hashFiles map[string][]string) []*hashRecord {
+	records := make([]*hashRecord, 0, len(hashes))
+	for label, hash := range hashes {
+		files := append([]string{}, hashFiles[label]...)
+		sort.Strings(files)
+		records = append(records, &hashRecord{Label: label, Hash: hash, Files: files})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Label < records[j].Label })
+	return records
+}
+
+func writeOutputRecords(w io.Writer, format string, mnemonicFilter string, records []*outputRecord) error {
+	switch format {
+	case jsonOutput:
+		return writeJSON(w, records)
+	case jsonlOutput:
+		return writeJSONLines(w, records)
+	case csvOutput:
+		rows := [][]string{{"label", "mnemonic", "path", "configuration", "size"}}
+		for _, r := range records {
+			size := ""
+			if r.Size != nil {
+				size = strconv.FormatInt(*r.Size, 10)
+			}
+			rows = append(rows, []string{r.Label, r.Mnemonic, r.Path, r.Configuration, size})
+		}
+		return writeCSV(w, rows)
+	}
+	for _, r := range records {
+		if len(mnemonicFilter) > 0 {
+			fmt.Fprintf(w, "%s\n", r.Path)
+		} else {
+			fmt.Fprintf(w, "%s %s\n", r.Mnemonic, r.Path)
+		}
+	}
+	return nil
+}
+
+func writeHashRecords(w io.Writer, format string, records []*hashRecord) error {
+	switch format {
+	case jsonOutput:
+		return writeJSON(w, records)
+	case jsonlOutput:
+		return writeJSONLines(w, records)
+	case csvOutput:
+		// One row per hashed file so that the files don't need to be escaped
+		// within a single column.
+		rows := [][]string{{"label", "hash", "file"}}
+		for _, r := range records {
+			for _, f := range r.Files {
+				rows = append(rows, []string{r.Label, r.Hash, f})
+			}
+		}
+		return writeCSV(w, rows)
+	}
+	for _, r := range records {
+		fmt.Fprintf(w, "%s %s\n", r.Label, r.Hash)
+	}
+	return nil
+}
+
+func writeJSON[T any](w io.Writer, records []T) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeJSONLines[T any](w io.Writer, records []T) error {
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCSV(w io.Writer, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+var testOutputs = []bazel.Output{
+	{Label: "//b:bin", Mnemonic: "GoLink", Path: "bazel-out/k8-fastbuild/bin/b/bin", Configuration: "k8-fastbuild"},
+	{Label: "//a:lib", Mnemonic: "GoCompilePkg", Path: "bazel-out/k8-fastbuild/bin/a/lib with space.a", Configuration: "k8-fastbuild"},
+	{Label: "//a:lib", Mnemonic: "GoCompilePkg", Path: "bazel-out/k8-fastbuild/bin/a/lib.x", Configuration: "k8-fastbuild"},
+}
+
+func TestOutputFormats(t *testing.T) {
+	t.Run("sorts outputs by label and path", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		records := newOutputRecords(testOutputs, false, "")
+		g.Expect(records).To(HaveLen(3))
+		g.Expect(records[0].Path).To(Equal("bazel-out/k8-fastbuild/bin/a/lib with space.a"))
+		g.Expect(records[1].Path).To(Equal("bazel-out/k8-fastbuild/bin/a/lib.x"))
+		g.Expect(records[2].Label).To(Equal("//b:bin"))
+		g.Expect(records[0].Size).To(BeNil())
+	})
+
+	t.Run("includes the size of files on disk", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path := filepath.Join(t.TempDir(), "out.txt")
+		g.Expect(os.WriteFile(path, []byte("hello"), 0644)).To(Succeed())
+		records := newOutputRecords([]bazel.Output{{Label: "//:out", Path: path}, {Label: "//:missing", Path: path + ".missing"}}, true, "")
+		g.Expect(records[0].Label).To(Equal("//:missing"))
+		g.Expect(records[0].Size).To(BeNil())
+		g.Expect(*records[1].Size).To(Equal(int64(5)))
+	})
+
+	t.Run("reads the size of files relative to the workspace root", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		workspaceRoot := t.TempDir()
+		g.Expect(os.MkdirAll(filepath.Join(workspaceRoot, "bazel-out", "bin"), 0755)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(workspaceRoot, "bazel-out", "bin", "out.txt"), []byte("hello"), 0644)).To(Succeed())
+		records := newOutputRecords([]bazel.Output{{Label: "//:out", Path: "bazel-out/bin/out.txt"}}, true, workspaceRoot)
+		g.Expect(*records[0].Size).To(Equal(int64(5)))
+	})
+
+	t.Run("writes csv", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var out strings.Builder
+		g.Expect(writeOutputRecords(&out, csvOutput, "", newOutputRecords(testOutputs[:2], false, ""))).To(Succeed())
+		g.Expect(out.String()).To(Equal(`label,mnemonic,path,configuration,size
+//a:lib,GoCompilePkg,bazel-out/k8-fastbuild/bin/a/lib with space.a,k8-fastbuild,
+//b:bin,GoLink,bazel-out/k8-fastbuild/bin/b/bin,k8-fastbuild,
+`))
+	})
+
+	t.Run("writes jsonl", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		var out strings.Builder
+		g.Expect(writeOutputRecords(&out, jsonlOutput, "", newOutputRecords(testOutputs[:1], false, ""))).To(Succeed())
+		g.Expect(out.String()).To(Equal(`{"label":"//b:bin","mnemonic":"GoLink","path":"bazel-out/k8-fastbuild/bin/b/bin","configuration":"k8-fastbuild"}` + "\n"))
+	})
+
+	t.Run("writes hashes with their files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		records := newHashRecords(
+			map[string]string{"//b:bin": "m3:b", "//a:bin": "m3:a"},
+			map[string][]string{"//b:bin": {"b/z", "b/a"}, "//a:bin": {"a/bin"}},
+		)
+		var out strings.Builder
+		g.Expect(writeHashRecords(&out, textOutput, records)).To(Succeed())
+		g.Expect(out.String()).To(Equal("//a:bin m3:a\n//b:bin m3:b\n"))
+
+		out.Reset()
+		g.Expect(writeHashRecords(&out, csvOutput, records)).To(Succeed())
+		g.Expect(out.String()).To(Equal("label,hash,file\n//a:bin,m3:a,a/bin\n//b:bin,m3:b,b/a\n//b:bin,m3:b,b/z\n"))
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(validateOutputFormat("yaml")).To(MatchError(ContainSubstring("invalid --output")))
+	})
+}
+
+func TestOutputsRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	bzl := mock.NewMockBazel(ctrl)
+	bzl.EXPECT().
+		RunCommand(gomock.Any(), nil, "info", "output_base").
+		DoAndReturn(func(streams ioutils.Streams, _ *string, _ ...string) error {
+			_, err := streams.Stdout.Write([]byte("/output_base\n"))
+			return err
+		})
+	bzl.EXPECT().
+		AQuery("//app", []string{}).
+		Return(&analysis.ActionGraphContainer{
+			Targets:       []*analysis.Target{{Id: 1, Label: "//app"}},
+			Configuration: []*analysis.Configuration{{Id: 1, Mnemonic: "k8-opt"}},
+			PathFragments: []*analysis.PathFragment{{Id: 1, Label: "bazel-out"}, {Id: 2, Label: "app", ParentId: 1}, {Id: 3, Label: "app.a", ParentId: 1}},
+			Artifacts:     []*analysis.Artifact{{Id: 1, PathFragmentId: 2}, {Id: 2, PathFragmentId: 3}},
+			Actions: []*analysis.Action{
+				{TargetId: 1, ConfigurationId: 1, Mnemonic: "GoLink", OutputIds: []uint32{1}},
+				{TargetId: 1, ConfigurationId: 1, Mnemonic: "GoCompilePkg", OutputIds: []uint32{2}},
+			},
+		}, nil)
+
+	cmd := &cobra.Command{Use: "outputs"}
+	AddFlags(cmd.Flags())
+	g.Expect(cmd.ParseFlags([]string{"--output=json"})).To(Succeed())
+
+	var stdout strings.Builder
+	err := New(ioutils.Streams{Stdout: &stdout}, bzl).Run(context.Background(), cmd, []string{"//app", "GoLink"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var records []map[string]interface{}
+	g.Expect(json.Unmarshal([]byte(stdout.String()), &records)).To(Succeed())
+	g.Expect(records).To(Equal([]map[string]interface{}{
+		{"label": "//app", "mnemonic": "GoLink", "path": "bazel-out/app", "configuration": "k8-opt"},
+	}))
+}
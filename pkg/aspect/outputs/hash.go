@@ -29,6 +29,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -40,7 +41,15 @@ import (
 	"github.com/twmb/murmur3"
 )
 
-const numConcurrentHashingThreads = 4
+// minConcurrentHashingThreads is the least number of files hashed at once.
+// Hashing is partly I/O bound, so it's worth using a few threads even on
+// machines with few CPUs.
+const minConcurrentHashingThreads = 4
+
+// hashingConcurrency returns the number of files to hash at once.
+func hashingConcurrency() int {
+	return max(runtime.NumCPU(), minConcurrentHashingThreads)
+}
 
 // AddExecutableHash appends the exePath to hashFiles entry of the label
 func AddExecutableHash(hashFiles map[string][]string, label string, exePath string) {
@@ -61,12 +70,46 @@ func AddRunfilesHash(hashFiles map[string][]string, label string, manifestPath s
 		fmt.Fprintf(os.Stderr, "%s manifest %s is not on disk, did you build it? Skipping...\n", label, manifestPath)
 		return nil
 	}
+	entries, err := readRunfilesManifest(manifestPath, outputBase)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.info.IsDir() {
+			// TODO(alexeagle): I think the actualPath means we'll get more hashed than we mean to
+			// we should pass some other value to the second arg "prefix"
+			direntries, err := dirhash.DirFiles(entry.actualPath, entry.actualPath)
+			if err != nil {
+				return fmt.Errorf("failed to recursively list directory %s: %w\n", entry.actualPath, err)
+			}
+			hashFiles[label] = append(hashFiles[label], direntries...)
+		} else {
+			hashFiles[label] = append(hashFiles[label], entry.actualPath)
+		}
+	}
+	return nil
+}
+
+// runfilesEntry is an entry of a runfiles manifest.
+type runfilesEntry struct {
+	// runfilesPath is the path of the file in the runfiles tree.
+	runfilesPath string
+	// actualPath is the path of the file that the runfiles tree links to.
+	actualPath string
+	info       fs.FileInfo
+}
+
+// readRunfilesManifest parses the entries of a runfiles manifest and resolves
+// the files they link to.
+func readRunfilesManifest(manifestPath string, outputBase string) ([]runfilesEntry, error) {
 	runfiles, err := os.Open(manifestPath)
 	if err != nil {
-		return fmt.Errorf("failed to open runfiles manifest %s: %w\n", manifestPath, err)
+		return nil, fmt.Errorf("failed to open runfiles manifest %s: %w\n", manifestPath, err)
 	}
 	defer runfiles.Close()
 
+	var entries []runfilesEntry
 	fileScanner := bufio.NewScanner(runfiles)
 	fileScanner.Split(bufio.ScanLines)
 
@@ -107,26 +150,23 @@ func AddRunfilesHash(hashFiles map[string][]string, label string, manifestPath s
 		}
 
 		if err != nil {
-			return fmt.Errorf("failed to stat runfiles manifest entry %s: %w\n", actualPath, err)
+			return nil, fmt.Errorf("failed to stat runfiles manifest entry %s: %w\n", actualPath, err)
 		}
 
-		if fileinfo.IsDir() {
-			// TODO(alexeagle): I think the actualPath means we'll get more hashed than we mean to
-			// we should pass some other value to the second arg "prefix"
-			direntries, err := dirhash.DirFiles(actualPath, actualPath)
-			if err != nil {
-				return fmt.Errorf("failed to recursively list directory %s: %w\n", actualPath, err)
-			}
-			hashFiles[label] = append(hashFiles[label], direntries...)
-		} else {
-			hashFiles[label] = append(hashFiles[label], actualPath)
-		}
+		entries = append(entries, runfilesEntry{
+			runfilesPath: unescape(line[:spaceIndex]),
+			actualPath:   actualPath,
+			info:         fileinfo,
+		})
 	}
-	return nil
+	return entries, nil
 }
 
 // =================================================================================================
-func gatherExecutableHashes(outs []bazel.Output, salt string, outputBase string) (map[string]string, error) {
+// gatherExecutableHashes returns the ExecutableHash of each label along with the
+// files that were hashed for it. Digests of files that are unchanged since a
+// previous run are read from the cache, which may be nil.
+func gatherExecutableHashes(outs []bazel.Output, salt string, outputBase string, cache *digestCache) (map[string]string, map[string][]string, error) {
 	// map from Label to the files/directories which should be hashed
 	hashFiles := make(map[string][]string)
 
@@ -135,15 +175,26 @@ func gatherExecutableHashes(outs []bazel.Output, salt string, outputBase string)
 			AddExecutableHash(hashFiles, a.Label, a.Path)
 		} else if a.Mnemonic == "SourceSymlinkManifest" {
 			if err := AddRunfilesHash(hashFiles, a.Label, a.Path, outputBase); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 	}
 
-	return HashLabelFiles(hashFiles, numConcurrentHashingThreads, salt)
+	hashes, err := hashLabelFiles(hashFiles, hashingConcurrency(), salt, cache)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cache.save(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save file digest cache: %v\n", err)
+	}
+	return hashes, hashFiles, nil
 }
 
 func HashLabelFiles(labelFiles map[string][]string, concurrency int, salt string) (map[string]string, error) {
+	return hashLabelFiles(labelFiles, concurrency, salt, nil)
+}
+
+func hashLabelFiles(labelFiles map[string][]string, concurrency int, salt string, cache *digestCache) (map[string]string, error) {
 	// cache of file hashes so we don't hash the same file twice for different targets
 	mep := haxmap.New[string, string]()
 	result := make(map[string]string)
@@ -153,9 +204,9 @@ func HashLabelFiles(labelFiles map[string][]string, concurrency int, salt string
 		if concurrency == 0 {
 			// Fully synchronous hash implementation is used for testing to ensure that the faster
 			// concurrent implementation generates an identical hash to the slower sync implementation.
-			hash, err = hashMurmur3Sync(files, mep, salt)
+			hash, err = hashMurmur3Sync(files, mep, cache, salt)
 		} else {
-			hash, err = hashMurmur3Concurrent(files, mep, concurrency, salt)
+			hash, err = hashMurmur3Concurrent(files, mep, cache, concurrency, salt)
 		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to compute runfiles hash for manifest: %w\n", err)
@@ -168,7 +219,7 @@ func HashLabelFiles(labelFiles map[string][]string, concurrency int, salt string
 // =================================================================================================
 // https://github.com/twmb/murmur3
 // =================================================================================================
-func hashMurmur3Sync(files []string, mep *haxmap.Map[string, string], salt string) (string, error) {
+func hashMurmur3Sync(files []string, mep *haxmap.Map[string, string], cache *digestCache, salt string) (string, error) {
 	h := murmur3.New128()
 	files = append([]string(nil), files...)
 	sort.Strings(files)
@@ -178,20 +229,10 @@ func hashMurmur3Sync(files []string, mep *haxmap.Map[string, string], salt strin
 			h.Write([]byte(s))
 			continue
 		}
-		if strings.Contains(file, "\n") {
-			return "", errors.New("filenames with newlines are not supported")
-		}
-		r, err := os.Open(file)
-		if err != nil {
-			return "", err
-		}
-		hf := murmur3.New128()
-		_, err = io.Copy(hf, r)
-		r.Close()
+		s, err := hashFile(file, cache)
 		if err != nil {
 			return "", err
 		}
-		s = fmt.Sprintf("%x  %s\n", hf.Sum(nil), file)
 		mep.Set(file, s)
 		h.Write([]byte(s))
 	}
@@ -213,7 +254,7 @@ type hashResult struct {
 	err    error
 }
 
-func hashMurmur3Concurrent(files []string, mep *haxmap.Map[string, string], numThreads int, salt string) (string, error) {
+func hashMurmur3Concurrent(files []string, mep *haxmap.Map[string, string], cache *digestCache, numThreads int, salt string) (string, error) {
 	h := murmur3.New128()
 	files = append([]string(nil), files...)
 	sort.Strings(files)
@@ -243,7 +284,7 @@ func hashMurmur3Concurrent(files []string, mep *haxmap.Map[string, string], numT
 					result: m.result,
 				})
 			} else {
-				inputChan <- hashWorker(m.file)
+				inputChan <- hashWorker{file: m.file, cache: cache}
 			}
 		}
 		close(inputChan)
@@ -274,34 +315,44 @@ func (i cachedPassThrough) Run(ctx context.Context) interface{} {
 	}
 }
 
-type hashWorker string
+type hashWorker struct {
+	file  string
+	cache *digestCache
+}
 
 func (i hashWorker) Run(ctx context.Context) interface{} {
-	file := string(i)
+	result, err := hashFile(i.file, i.cache)
+	return hashResult{
+		file:   i.file,
+		result: result,
+		err:    err,
+	}
+}
+
+// hashFile returns the line that file contributes to the hash of a label,
+// which is its murmur3 digest followed by its path.
+func hashFile(file string, cache *digestCache) (string, error) {
 	if strings.Contains(file, "\n") {
-		return hashResult{
-			file: file,
-			err:  fmt.Errorf("filenames with newlines are not supported"),
-		}
+		return "", errors.New("filenames with newlines are not supported")
 	}
-	r, err := os.Open(file)
+	info, err := os.Stat(file)
 	if err != nil {
-		return hashResult{
-			file: file,
-			err:  fmt.Errorf("failed to open file %v for hashing: %w", file, err),
-		}
+		return "", fmt.Errorf("failed to stat file %v for hashing: %w", file, err)
 	}
-	hf := murmur3.New128()
-	_, err = io.Copy(hf, r)
-	r.Close()
-	if err != nil {
-		return hashResult{
-			file: file,
-			err:  fmt.Errorf("failed to stream file %v for hashing: %w", file, err),
+	digest, ok := cache.get(file, info)
+	if !ok {
+		r, err := os.Open(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to open file %v for hashing: %w", file, err)
 		}
+		hf := murmur3.New128()
+		_, err = io.Copy(hf, r)
+		r.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to stream file %v for hashing: %w", file, err)
+		}
+		digest = fmt.Sprintf("%x", hf.Sum(nil))
+		cache.put(file, info, digest)
 	}
-	return hashResult{
-		file:   file,
-		result: fmt.Sprintf("%x  %s\n", hf.Sum(nil), file),
-	}
+	return fmt.Sprintf("%s  %s\n", digest, file), nil
 }
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+)
+
+const (
+	ManifestFlagName  = "manifest"
+	CompareToFlagName = "compare-to"
+
+	hashAdded   = "added"
+	hashRemoved = "removed"
+	hashChanged = "changed"
+)
+
+// writeManifest saves the ExecutableHash of each label so that a later run can
+// detect which labels changed with --compare-to.
+func writeManifest(path string, records []*hashRecord) error {
+	var b bytes.Buffer
+	if err := writeJSON(&b, records); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+// readManifest reads the ExecutableHash of each label from a manifest written
+// with --manifest or with --output=json or jsonl, or from the text output of
+// an earlier run.
+func readManifest(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	hashes := map[string]string{}
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case len(trimmed) == 0:
+	case trimmed[0] == '[':
+		var records []*hashRecord
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+		}
+		for _, r := range records {
+			hashes[r.Label] = r.Hash
+		}
+	case trimmed[0] == '{':
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			r := &hashRecord{}
+			if err := dec.Decode(r); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+			}
+			hashes[r.Label] = r.Hash
+		}
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			label, hash, ok := strings.Cut(line, " ")
+			if !ok {
+				return nil, fmt.Errorf("failed to parse manifest %s: invalid line %q", path, line)
+			}
+			hashes[label] = strings.TrimSpace(hash)
+		}
+	}
+	return hashes, nil
+}
+
+// hashChange is a label whose ExecutableHash differs from the manifest it is
+// compared to.
+type hashChange struct {
+	Label   string `json:"label"`
+	Status  string `json:"status"`
+	OldHash string `json:"old_hash,omitempty"`
+	NewHash string `json:"new_hash,omitempty"`
+}
+
+func compareHashes(old, new map[string]string) []*hashChange {
+	changes := []*hashChange{}
+	for label, hash := range new {
+		oldHash, ok := old[label]
+		if !ok {
+			changes = append(changes, &hashChange{Label: label, Status: hashAdded, NewHash: hash})
+		} else if oldHash != hash {
+			changes = append(changes, &hashChange{Label: label, Status: hashChanged, OldHash: oldHash, NewHash: hash})
+		}
+	}
+	for label, hash := range old {
+		if _, ok := new[label]; !ok {
+			changes = append(changes, &hashChange{Label: label, Status: hashRemoved, OldHash: hash})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Label < changes[j].Label })
+	return changes
+}
+
+func writeHashChanges(w io.Writer, format string, changes []*hashChange) error {
+	switch format {
+	case jsonOutput:
+		return writeJSON(w, changes)
+	case jsonlOutput:
+		return writeJSONLines(w, changes)
+	case csvOutput:
+		rows := [][]string{{"label", "status", "old_hash", "new_hash"}}
+		for _, c := range changes {
+			rows = append(rows, []string{c.Label, c.Status, c.OldHash, c.NewHash})
+		}
+		return writeCSV(w, rows)
+	}
+	for _, c := range changes {
+		fmt.Fprintf(w, "%s %s\n", c.Label, c.Status)
+	}
+	return nil
+}
+
+// outputsChangedError signals to scripts that a deployable changed.
+func outputsChangedError(changes []*hashChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return &aspecterrors.ExitError{ExitCode: aspecterrors.OutputsChanged}
+}
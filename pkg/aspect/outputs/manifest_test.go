@@ -0,0 +1,118 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func TestManifest(t *testing.T) {
+	t.Run("round trips through --manifest", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		g.Expect(writeManifest(path, []*hashRecord{{Label: "//:a", Hash: "m3:a", Files: []string{"a"}}})).To(Succeed())
+		hashes, err := readManifest(path)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(hashes).To(Equal(map[string]string{"//:a": "m3:a"}))
+	})
+
+	t.Run("reads jsonl and text output", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		jsonl := filepath.Join(dir, "manifest.jsonl")
+		g.Expect(os.WriteFile(jsonl, []byte(`{"label":"//:a","hash":"m3:a"}`+"\n"+`{"label":"//:b","hash":"m3:b"}`+"\n"), 0644)).To(Succeed())
+		hashes, err := readManifest(jsonl)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(hashes).To(Equal(map[string]string{"//:a": "m3:a", "//:b": "m3:b"}))
+
+		text := filepath.Join(dir, "manifest.txt")
+		g.Expect(os.WriteFile(text, []byte("//:a m3:a\n//:b m3:b\n"), 0644)).To(Succeed())
+		hashes, err = readManifest(text)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(hashes).To(Equal(map[string]string{"//:a": "m3:a", "//:b": "m3:b"}))
+	})
+
+	t.Run("reports added, removed and changed labels", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		changes := compareHashes(
+			map[string]string{"//:same": "m3:1", "//:changed": "m3:2", "//:removed": "m3:3"},
+			map[string]string{"//:same": "m3:1", "//:changed": "m3:4", "//:added": "m3:5"},
+		)
+		var out strings.Builder
+		g.Expect(writeHashChanges(&out, textOutput, changes)).To(Succeed())
+		g.Expect(out.String()).To(Equal("//:added added\n//:changed changed\n//:removed removed\n"))
+
+		var exitErr *aspecterrors.ExitError
+		g.Expect(errors.As(outputsChangedError(changes), &exitErr)).To(BeTrue())
+		g.Expect(exitErr.ExitCode).To(Equal(aspecterrors.OutputsChanged))
+		g.Expect(outputsChangedError(compareHashes(map[string]string{"//:a": "m3:a"}, map[string]string{"//:a": "m3:a"}))).To(Succeed())
+	})
+}
+
+func TestOutputsCompareTo(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "app")
+	g.Expect(os.WriteFile(executable, []byte("v2"), 0755)).To(Succeed())
+	old := filepath.Join(dir, "old.json")
+	g.Expect(os.WriteFile(old, []byte("//app m3:v1\n//gone m3:v1\n"), 0644)).To(Succeed())
+	manifest := filepath.Join(dir, "new.json")
+
+	bzl := mock.NewMockBazel(ctrl)
+	bzl.EXPECT().RunCommand(gomock.Any(), nil, "info", "output_base").Return(nil)
+	bzl.EXPECT().
+		AQuery("//...", []string{}).
+		Return(&analysis.ActionGraphContainer{
+			Targets:       []*analysis.Target{{Id: 1, Label: "//app"}},
+			PathFragments: []*analysis.PathFragment{{Id: 1, Label: executable}},
+			Artifacts:     []*analysis.Artifact{{Id: 1, PathFragmentId: 1}},
+			Actions:       []*analysis.Action{{TargetId: 1, Mnemonic: "ExecutableSymlink", OutputIds: []uint32{1}}},
+		}, nil)
+
+	cmd := &cobra.Command{Use: "outputs"}
+	AddFlags(cmd.Flags())
+	g.Expect(cmd.ParseFlags([]string{"--compare-to", old, "--manifest", manifest, "--hash_cache=false"})).To(Succeed())
+
+	var stdout strings.Builder
+	err := New(ioutils.Streams{Stdout: &stdout}, bzl).Run(context.Background(), cmd, []string{"//...", "ExecutableHash"})
+
+	var exitErr *aspecterrors.ExitError
+	g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+	g.Expect(exitErr.ExitCode).To(Equal(aspecterrors.OutputsChanged))
+	g.Expect(stdout.String()).To(Equal("//app changed\n//gone removed\n"))
+
+	hashes, err := readManifest(manifest)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(hashes).To(HaveKey("//app"))
+}
@@ -42,6 +42,14 @@ func New(streams ioutils.Streams, bzl bazel.Bazel) *Outputs {
 
 func AddFlags(flagSet *pflag.FlagSet) {
 	flagSet.String("hash_salt", "", "When 'ExecutableHash' is specified, this value will be added as a suffix to every hash")
+	flagSet.String(OutputFlagName, textOutput, "The output format: text, json, jsonl or csv")
+	flagSet.Bool(FileSizeFlagName, false, "Include the size of each output file, which must have been built, in the json, jsonl and csv output")
+	flagSet.String(ManifestFlagName, "", "When 'ExecutableHash' is specified, write the hash and hashed files of every label to this file")
+	flagSet.String(StageFlagName, "", "Copy the matched outputs, with their runfiles trees, into this directory and write a manifest.json with their sha256")
+	flagSet.String(StageLayoutFlagName, labelLayout, "The layout of the --stage directory: label, which puts the outputs of each label in a directory named after it, or flat")
+	flagSet.Bool(StageHardlinkFlagName, false, "Hardlink rather than copy outputs into the --stage directory when possible")
+	flagSet.Bool(HashCacheFlagName, true, "When 'ExecutableHash' is specified, reuse the digests of files that are unchanged since a previous run, as identified by their path, inode, modification time and size")
+	flagSet.String(CompareToFlagName, "", "When 'ExecutableHash' is specified, only print the labels whose hash differs from this manifest")
 }
 
 func remove(slice []string, i int) []string {
@@ -54,8 +62,11 @@ func RemoveCobraFlagsFromArgs(cmd *cobra.Command, args []string) []string {
 			if arg == fmt.Sprintf("--%s=%s", f.Name, f.Value) {
 				args = remove(args, i)
 				break
+			} else if arg == fmt.Sprintf("--%s", f.Name) && f.Value.Type() == "bool" {
+				args = remove(args, i)
+				break
 			} else if arg == fmt.Sprintf("--%s", f.Name) &&
-				len(args)+1 > i &&
+				i+1 < len(args) &&
 				args[i+1] == f.Value.String() {
 				args = remove(args, i+1)
 				args = remove(args, i)
@@ -73,13 +84,59 @@ func (runner *Outputs) Run(_ context.Context, cmd *cobra.Command, args []string)
 	}
 
 	salt := ""
+	format := textOutput
+	withSize := false
+	manifest := ""
+	compareTo := ""
+	stageDir := ""
+	stageLayout := labelLayout
+	stageHardlink := false
+	hashCache := false
 	if cmd != nil {
 		nonBazelFlags = RemoveCobraFlagsFromArgs(cmd, nonBazelFlags)
 		salt, err = cmd.Flags().GetString("hash_salt")
 		if err != nil {
 			return err
 		}
+		format, err = cmd.Flags().GetString(OutputFlagName)
+		if err != nil {
+			return err
+		}
+		withSize, err = cmd.Flags().GetBool(FileSizeFlagName)
+		if err != nil {
+			return err
+		}
+		manifest, err = cmd.Flags().GetString(ManifestFlagName)
+		if err != nil {
+			return err
+		}
+		compareTo, err = cmd.Flags().GetString(CompareToFlagName)
+		if err != nil {
+			return err
+		}
+		stageDir, err = cmd.Flags().GetString(StageFlagName)
+		if err != nil {
+			return err
+		}
+		stageLayout, err = cmd.Flags().GetString(StageLayoutFlagName)
+		if err != nil {
+			return err
+		}
+		stageHardlink, err = cmd.Flags().GetBool(StageHardlinkFlagName)
+		if err != nil {
+			return err
+		}
+		hashCache, err = cmd.Flags().GetBool(HashCacheFlagName)
+		if err != nil {
+			return err
+		}
 	}
+	if err := validateOutputFormat(format); err != nil {
+		return err
+	}
+	// --output shadows the aquery flag of the same name, which is always
+	// --output=proto under the hood.
+	bazelFlags = removeFlag(bazelFlags, OutputFlagName)
 
 	// Test to see if the command has been passed the `--query_file` Bazel flag.
 	// There is no short hand version of this flag, so the single check is fine.
@@ -119,6 +176,10 @@ func (runner *Outputs) Run(_ context.Context, cmd *cobra.Command, args []string)
 		}
 	}
 
+	if (manifest != "" || compareTo != "") && mnemonicFilter != "ExecutableHash" {
+		return fmt.Errorf("--%s and --%s require the 'ExecutableHash' mnemonic", ManifestFlagName, CompareToFlagName)
+	}
+
 	var out strings.Builder
 	streams := ioutils.Streams{Stdout: &out, Stderr: nil}
 	err = runner.bzl.RunCommand(streams, nil, "info", "output_base")
@@ -133,27 +194,92 @@ func (runner *Outputs) Run(_ context.Context, cmd *cobra.Command, args []string)
 	}
 	outs := bazel.ParseOutputs(agc)
 
+	if stageDir != "" {
+		s, err := newStager(stageDir, stageLayout, stageHardlink, outputBase)
+		if err != nil {
+			return err
+		}
+		if err := s.stage(matchOutputs(outs, mnemonicFilter)); err != nil {
+			return err
+		}
+		if err := s.writeManifest(); err != nil {
+			return err
+		}
+		fmt.Fprintf(runner.Stderr, "Staged %d files to %s\n", len(s.files), stageDir)
+		return nil
+	}
+
 	// Special case pseudo-mnemonic indicating we should compute an overall hash
 	// for any executables in the aquery result
 	if mnemonicFilter == "ExecutableHash" {
-		hashes, err := gatherExecutableHashes(outs, salt, outputBase)
+		var cache *digestCache
+		if hashCache {
+			cachePath, err := defaultDigestCachePath()
+			if err != nil {
+				return err
+			}
+			cache = openDigestCache(cachePath)
+		}
+		hashes, hashFiles, err := gatherExecutableHashes(outs, salt, outputBase, cache)
 		if err != nil {
 			return err
 		}
-		for label, hash := range hashes {
-			fmt.Fprintf(runner.Stdout, "%s %s\n", label, hash)
+		records := newHashRecords(hashes, hashFiles)
+		if manifest != "" {
+			if err := writeManifest(manifest, records); err != nil {
+				return err
+			}
+		}
+		if compareTo != "" {
+			old, err := readManifest(compareTo)
+			if err != nil {
+				return err
+			}
+			changes := compareHashes(old, hashes)
+			if err := writeHashChanges(runner.Stdout, format, changes); err != nil {
+				return err
+			}
+			return outputsChangedError(changes)
 		}
-		return nil
+		return writeHashRecords(runner.Stdout, format, records)
 	}
 
+	workspaceRoot := ""
+	if withSize {
+		workspaceRoot = runner.bzl.WorkspaceRoot()
+	}
+	return writeOutputRecords(runner.Stdout, format, mnemonicFilter, newOutputRecords(matchOutputs(outs, mnemonicFilter), withSize, workspaceRoot))
+}
+
+// matchOutputs returns the outputs of actions with the given mnemonic. The
+// 'ExecutableHash' pseudo-mnemonic matches the executables and their runfiles
+// manifests.
+func matchOutputs(outs []bazel.Output, mnemonicFilter string) []bazel.Output {
+	if len(mnemonicFilter) == 0 {
+		return outs
+	}
+	matched := make([]bazel.Output, 0, len(outs))
 	for _, a := range outs {
-		if len(mnemonicFilter) > 0 {
-			if a.Mnemonic == mnemonicFilter {
-				fmt.Fprintf(runner.Stdout, "%s\n", a.Path)
-			}
-		} else {
-			fmt.Fprintf(runner.Stdout, "%s %s\n", a.Mnemonic, a.Path)
+		if a.Mnemonic == mnemonicFilter ||
+			(mnemonicFilter == "ExecutableHash" && (a.Mnemonic == "ExecutableSymlink" || a.Mnemonic == "SourceSymlinkManifest")) {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
+
+// removeFlag removes a flag that takes a value from args.
+func removeFlag(args []string, name string) []string {
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--"+name {
+			i++
+			continue
+		}
+		if strings.HasPrefix(args[i], "--"+name+"=") {
+			continue
 		}
+		result = append(result, args[i])
 	}
-	return nil
+	return result
 }
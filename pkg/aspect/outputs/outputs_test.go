@@ -84,5 +84,12 @@ func TestOutputs(t *testing.T) {
 		g.Expect(resultingFlags[0]).To(Equal("foo"))
 		g.Expect(resultingFlags[1]).To(Equal("bar"))
 		g.Expect(resultingFlags[2]).To(Equal("baz"))
+
+		resultingFlags = RemoveCobraFlagsFromArgs(cmd, []string{"foo", "--file_size", "bar", "baz"})
+
+		g.Expect(len(resultingFlags)).To(Equal(3))
+		g.Expect(resultingFlags[0]).To(Equal("foo"))
+		g.Expect(resultingFlags[1]).To(Equal("bar"))
+		g.Expect(resultingFlags[2]).To(Equal("baz"))
 	})
 }
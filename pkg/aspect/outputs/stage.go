@@ -0,0 +1,259 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+)
+
+const (
+	StageFlagName         = "stage"
+	StageLayoutFlagName   = "stage_layout"
+	StageHardlinkFlagName = "stage_hardlink"
+
+	labelLayout = "label"
+	flatLayout  = "flat"
+
+	// stageManifestName is the name of the manifest written to the root of
+	// the staging directory.
+	stageManifestName = "manifest.json"
+
+	// runfilesMnemonic marks the files of a runfiles tree in the manifest.
+	runfilesMnemonic = "Runfiles"
+)
+
+// stagedFile is an entry of the manifest of a staging directory.
+type stagedFile struct {
+	Label    string `json:"label"`
+	Mnemonic string `json:"mnemonic"`
+	// Path is relative to the staging directory.
+	Path   string `json:"path"`
+	Source string `json:"source"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// stager copies or hardlinks outputs into a staging directory.
+type stager struct {
+	dir        string
+	layout     string
+	hardlink   bool
+	outputBase string
+	files      []*stagedFile
+	// staged maps each staged path to its source so that outputs that would
+	// overwrite each other in the flat layout are detected.
+	staged map[string]string
+}
+
+func newStager(dir string, layout string, hardlink bool, outputBase string) (*stager, error) {
+	if layout != labelLayout && layout != flatLayout {
+		return nil, fmt.Errorf("invalid --%s %q: must be %q or %q", StageLayoutFlagName, layout, labelLayout, flatLayout)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	return &stager{
+		dir:        dir,
+		layout:     layout,
+		hardlink:   hardlink,
+		outputBase: outputBase,
+		staged:     map[string]string{stageManifestName: ""},
+	}, nil
+}
+
+// labelDir returns the directory of a label in the label layout, such as
+// cli/pro/pro for //cli/pro:pro and external/repo/pkg/name for @repo//pkg:name.
+func labelDir(label string) string {
+	repo, rest, ok := strings.Cut(label, "//")
+	if !ok {
+		rest = label
+	}
+	pkg, name, ok := strings.Cut(rest, ":")
+	if !ok {
+		name = filepath.Base(pkg)
+	}
+	dir := filepath.Join(pkg, name)
+	if repo = strings.TrimLeft(repo, "@"); repo != "" {
+		dir = filepath.Join("external", repo, dir)
+	}
+	return dir
+}
+
+func (s *stager) destination(label string, base string) string {
+	if s.layout == flatLayout {
+		return base
+	}
+	return filepath.Join(labelDir(label), base)
+}
+
+// stage stages the outputs that are on disk. Runfiles manifests are
+// dereferenced into a runfiles tree next to the staged executable.
+func (s *stager) stage(outs []bazel.Output) error {
+	for _, o := range outs {
+		info, err := os.Stat(o.Path)
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "%s output %s is not on disk, did you build it? Skipping...\n", o.Label, o.Path)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", o.Path, err)
+		}
+
+		dest := s.destination(o.Label, filepath.Base(o.Path))
+		if err := s.stagePath(o.Label, o.Mnemonic, o.Path, dest, info); err != nil {
+			return err
+		}
+
+		if o.Mnemonic == "SourceSymlinkManifest" {
+			entries, err := readRunfilesManifest(o.Path, s.outputBase)
+			if err != nil {
+				return err
+			}
+			runfiles := strings.TrimSuffix(dest, "_manifest")
+			for _, entry := range entries {
+				if err := s.stagePath(o.Label, runfilesMnemonic, entry.actualPath, filepath.Join(runfiles, entry.runfilesPath), entry.info); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// stagePath stages a file, or every file of a directory such as a tree
+// artifact.
+func (s *stager) stagePath(label, mnemonic, source, dest string, info fs.FileInfo) error {
+	if !info.IsDir() {
+		return s.stageFile(label, mnemonic, source, dest, info)
+	}
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		// Follow symlinks within the directory, as runfiles trees consist of them.
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		if info.IsDir() {
+			return s.stagePath(label, mnemonic, path, filepath.Join(dest, rel), info)
+		}
+		return s.stageFile(label, mnemonic, path, filepath.Join(dest, rel), info)
+	})
+}
+
+func (s *stager) stageFile(label, mnemonic, source, dest string, info fs.FileInfo) error {
+	dest = filepath.ToSlash(dest)
+	if existing, ok := s.staged[dest]; ok {
+		if existing == source {
+			return nil
+		}
+		return fmt.Errorf("cannot stage %s and %s at the same path %s; use --%s=%s", existing, source, dest, StageLayoutFlagName, labelLayout)
+	}
+	s.staged[dest] = source
+
+	target := filepath.Join(s.dir, dest)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", source, err)
+	}
+	// Bazel outputs are read-only, so an earlier staged copy must be removed
+	// rather than overwritten.
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stage %s: %w", source, err)
+	}
+	linked := s.hardlink && os.Link(source, target) == nil
+	if !linked {
+		if err := copyFile(source, target, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", source, err)
+		}
+	}
+
+	sum, err := sha256File(target)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", target, err)
+	}
+	s.files = append(s.files, &stagedFile{
+		Label:    label,
+		Mnemonic: mnemonic,
+		Path:     dest,
+		Source:   source,
+		SHA256:   sum,
+		Size:     info.Size(),
+	})
+	return nil
+}
+
+// writeManifest writes the manifest of the staged files to the root of the
+// staging directory.
+func (s *stager) writeManifest() error {
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].Path < s.files[j].Path })
+	var b bytes.Buffer
+	if err := writeJSON(&b, s.files); err != nil {
+		return fmt.Errorf("failed to write staging manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, stageManifestName), b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write staging manifest: %w", err)
+	}
+	return nil
+}
+
+func copyFile(source, target string, perm fs.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package outputs
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+)
+
+// stageFixture writes an executable with a runfiles manifest and a tree
+// artifact and returns their outputs.
+func stageFixture(t *testing.T) []bazel.Output {
+	dir := t.TempDir()
+	write := func(path, contents string, perm os.FileMode) string {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), perm); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	app := write("bin/app/app", "#!/bin/sh\n", 0755)
+	data := write("app/data file.txt", "data", 0644)
+	manifest := write("bin/app/app.runfiles_manifest", "_main/app/app "+app+"\n _main/app/data\\sfile.txt "+data+"\n", 0644)
+	write("bin/app/tree/a.txt", "a", 0644)
+	write("bin/app/tree/b/c.txt", "c", 0644)
+	return []bazel.Output{
+		{Label: "//app:app", Mnemonic: "ExecutableSymlink", Path: app},
+		{Label: "//app:app", Mnemonic: "SourceSymlinkManifest", Path: manifest},
+		{Label: "//app:tree", Mnemonic: "CopyDirectory", Path: filepath.Join(dir, "bin/app/tree")},
+		{Label: "//app:missing", Mnemonic: "GoLink", Path: filepath.Join(dir, "bin/app/missing")},
+	}
+}
+
+func TestStage(t *testing.T) {
+	t.Run("stages outputs by label with their runfiles", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		s, err := newStager(dir, labelLayout, false, "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s.stage(stageFixture(t))).To(Succeed())
+		g.Expect(s.writeManifest()).To(Succeed())
+
+		g.Expect(filepath.Join(dir, "app/app/app")).To(BeARegularFile())
+		info, err := os.Stat(filepath.Join(dir, "app/app/app"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0755)))
+		g.Expect(filepath.Join(dir, "app/app/app.runfiles/_main/app/data file.txt")).To(BeARegularFile())
+		g.Expect(filepath.Join(dir, "app/tree/tree/b/c.txt")).To(BeARegularFile())
+
+		b, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+		g.Expect(err).NotTo(HaveOccurred())
+		var files []*stagedFile
+		g.Expect(json.Unmarshal(b, &files)).To(Succeed())
+		paths := []string{}
+		for _, f := range files {
+			paths = append(paths, f.Path)
+		}
+		g.Expect(paths).To(Equal([]string{
+			"app/app/app",
+			"app/app/app.runfiles/_main/app/app",
+			"app/app/app.runfiles/_main/app/data file.txt",
+			"app/app/app.runfiles_manifest",
+			"app/tree/tree/a.txt",
+			"app/tree/tree/b/c.txt",
+		}))
+		g.Expect(files[2].Mnemonic).To(Equal(runfilesMnemonic))
+		// sha256 of "data"
+		g.Expect(files[2].SHA256).To(Equal("3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"))
+		g.Expect(files[2].Size).To(Equal(int64(4)))
+	})
+
+	t.Run("stages outputs flat", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		s, err := newStager(dir, flatLayout, true, "")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(s.stage(stageFixture(t)[:1])).To(Succeed())
+		g.Expect(filepath.Join(dir, "app")).To(BeARegularFile())
+	})
+
+	t.Run("rejects outputs that collide in the flat layout", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		outs := stageFixture(t)
+		other := stageFixture(t)
+		s, err := newStager(t.TempDir(), flatLayout, false, "")
+		g.Expect(err).NotTo(HaveOccurred())
+		err = s.stage([]bazel.Output{outs[0], other[0]})
+		g.Expect(err).To(MatchError(ContainSubstring("at the same path app; use --stage_layout=label")))
+	})
+
+	t.Run("restages over read-only files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		dir := t.TempDir()
+		outs := stageFixture(t)[:1]
+		g.Expect(os.Chmod(outs[0].Path, 0555)).To(Succeed())
+		for i := 0; i < 2; i++ {
+			s, err := newStager(dir, labelLayout, false, "")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(s.stage(outs)).To(Succeed())
+		}
+	})
+
+	t.Run("names label directories", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		g.Expect(labelDir("//cli/pro:pro")).To(Equal("cli/pro/pro"))
+		g.Expect(labelDir("//cli/pro")).To(Equal("cli/pro/pro"))
+		g.Expect(labelDir("@repo//pkg:name")).To(Equal("external/repo/pkg/name"))
+		g.Expect(labelDir("@@repo+//:name")).To(Equal("external/repo+/name"))
+	})
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package presets
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// environment returns the KEY=VALUE pairs of the env files of the preset followed by those of its
+// env, so that the later ones take precedence when they are set in order.
+func (preset *Preset) environment(workspaceRoot string) ([]string, error) {
+	var env []string
+	for _, envFile := range preset.EnvFiles {
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(workspaceRoot, envFile)
+		}
+		fileEnv, err := readEnvFile(envFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the env file of preset %q: %w", preset.Name, err)
+		}
+		env = append(env, fileEnv...)
+	}
+	for _, e := range preset.Env {
+		if key, _, ok := strings.Cut(e, "="); !ok || key == "" {
+			return nil, fmt.Errorf("env of preset %q must be KEY=VALUE: %q", preset.Name, e)
+		}
+		env = append(env, e)
+	}
+	return env, nil
+}
+
+// readEnvFile reads the KEY=VALUE lines of a dotenv style file. Blank lines and lines starting
+// with # are skipped, an export before the key is allowed, and values may be quoted.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var env []string
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env = append(env, key+"="+value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package presets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReadEnvFile(t *testing.T) {
+	writeEnvFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), ".env")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	t.Run("reads KEY=VALUE lines", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		env, err := readEnvFile(writeEnvFile(t, `
+# The local database
+DATABASE_URL=postgres://localhost:5432/dev
+export PORT = 8080
+GREETING="hello world"
+QUOTE='a=b'
+EMPTY=
+`))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(env).To(Equal([]string{
+			"DATABASE_URL=postgres://localhost:5432/dev",
+			"PORT=8080",
+			"GREETING=hello world",
+			"QUOTE=a=b",
+			"EMPTY=",
+		}))
+	})
+
+	t.Run("fails on lines that aren't KEY=VALUE", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		path := writeEnvFile(t, "A=1\nnot a variable\n")
+		_, err := readEnvFile(path)
+		g.Expect(err).To(MatchError(path + ":2: expected KEY=VALUE"))
+	})
+}
+
+func TestEnvironment(t *testing.T) {
+	t.Run("puts env after the env files so that it takes precedence", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		workspaceRoot := t.TempDir()
+		g.Expect(os.WriteFile(filepath.Join(workspaceRoot, "dev.env"), []byte("A=file\nB=file\n"), 0644)).To(Succeed())
+
+		preset := &Preset{Name: "dev", EnvFiles: []string{"dev.env"}, Env: []string{"A=env"}}
+		env, err := preset.environment(workspaceRoot)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(env).To(Equal([]string{"A=file", "B=file", "A=env"}))
+	})
+
+	t.Run("fails on env that isn't KEY=VALUE", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		preset := &Preset{Name: "dev", Env: []string{"A"}}
+		_, err := preset.environment(t.TempDir())
+		g.Expect(err).To(MatchError(`env of preset "dev" must be KEY=VALUE: "A"`))
+	})
+}
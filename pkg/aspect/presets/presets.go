@@ -0,0 +1,357 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package presets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/shared"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/run"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// Preset is a named invocation of `aspect run` or `aspect test` defined under run.presets or
+// test.presets in the Aspect CLI config.
+type Preset struct {
+	Name        string
+	Description string
+	// Targets are the labels or target patterns to run or test.
+	Targets []string
+	// Flags are passed to Bazel before the flags given on the command line.
+	Flags []string
+	// Env holds KEY=VALUE pairs set in the environment of the program or tests.
+	Env []string
+	// EnvFiles are files of KEY=VALUE lines, relative to the workspace root, that are read before
+	// Env so that Env takes precedence.
+	EnvFiles []string
+	// Args are passed to the program, or to the tests with --test_arg.
+	Args []string
+	// Before names a preset of the same command that runs to completion first.
+	Before string
+}
+
+// Presets expands the @name argument of `aspect run` and `aspect test` to the preset it names.
+type Presets struct {
+	ioutils.Streams
+
+	Bzl           bazel.Bazel
+	Command       string
+	IsInteractive bool
+	Prefs         viper.Viper
+
+	Select func(presetNames []string) shared.SelectRunner
+}
+
+// New creates the presets of the given command, which is run or test.
+func New(streams ioutils.Streams, bzl bazel.Bazel, command string, isInteractive bool) *Presets {
+	return &Presets{
+		Streams:       streams,
+		Bzl:           bzl,
+		Command:       command,
+		IsInteractive: isInteractive,
+		Prefs:         *viper.GetViper(),
+		Select:        Select,
+	}
+}
+
+// Select lists the presets in an interactive picker.
+func Select(presetNames []string) shared.SelectRunner {
+	return &promptui.Select{
+		Label: "Select a preset",
+		Items: presetNames,
+	}
+}
+
+// Load reads the presets of the command from the config, sorted by name.
+func Load(command string, prefs viper.Viper) []*Preset {
+	presetsKey := fmt.Sprintf("%s.presets", command)
+
+	var presets []*Preset
+	for name := range prefs.GetStringMap(presetsKey) {
+		presetKey := fmt.Sprintf("%s.%s", presetsKey, name)
+		presets = append(presets, &Preset{
+			Name:        name,
+			Description: prefs.GetString(presetKey + ".description"),
+			Targets:     prefs.GetStringSlice(presetKey + ".targets"),
+			Flags:       prefs.GetStringSlice(presetKey + ".flags"),
+			Env:         prefs.GetStringSlice(presetKey + ".env"),
+			EnvFiles:    prefs.GetStringSlice(presetKey + ".env_files"),
+			Args:        prefs.GetStringSlice(presetKey + ".args"),
+			Before:      strings.TrimPrefix(prefs.GetString(presetKey+".before"), "@"),
+		})
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets
+}
+
+// Interceptor replaces a first argument of @name with the targets, flags and arguments of the
+// preset it names, and sets its environment while the command runs. A lone @ selects the preset
+// interactively. Arguments naming no preset, such as @repo//pkg:target, are left as they are.
+// It must come before the watch interceptor so that the targets of the preset are watched.
+func (p *Presets) Interceptor(ctx context.Context, cmd *cobra.Command, args []string, next interceptors.RunEContextFn) error {
+	i := presetArgIndex(args)
+	if i < 0 {
+		return next(ctx, cmd, args)
+	}
+
+	presets := Load(p.Command, p.Prefs)
+	byName := make(map[string]*Preset, len(presets))
+	for _, preset := range presets {
+		byName[preset.Name] = preset
+	}
+
+	name := strings.TrimPrefix(args[i], "@")
+	preset, ok := byName[name]
+	if name == "" {
+		var err error
+		if preset, err = p.selectPreset(cmd, presets); err != nil {
+			return err
+		}
+	} else if !ok {
+		return next(ctx, cmd, args)
+	}
+
+	chain, err := runFirst(preset, byName)
+	if err != nil {
+		return err
+	}
+
+	rest := slices.Delete(slices.Clone(args), i, i+1)
+	for _, preset := range chain {
+		presetArgs := rest
+		if preset != chain[len(chain)-1] {
+			// The presets that run first don't get the arguments of the command line.
+			presetArgs = nil
+		}
+		if err := p.run(ctx, cmd, preset, presetArgs, preset == chain[len(chain)-1], next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run runs the command for the preset with its environment set.
+func (p *Presets) run(ctx context.Context, cmd *cobra.Command, preset *Preset, args []string, last bool, next interceptors.RunEContextFn) error {
+	if preset.Description != "" {
+		fmt.Fprintf(p.Stderr, "Running preset %q: %s\n", preset.Name, preset.Description)
+	} else {
+		fmt.Fprintf(p.Stderr, "Running preset %q\n", preset.Name)
+	}
+
+	env, err := preset.environment(p.Bzl.WorkspaceRoot())
+	if err != nil {
+		return err
+	}
+	expanded, err := preset.expand(p.Command, env, args)
+	if err != nil {
+		return err
+	}
+
+	restore, err := setenv(env)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	if !last {
+		// The presets that run first run once, even with --watch.
+		defer disableWatch(cmd)()
+	}
+	if p.Command == "run" && len(preset.Targets) > 1 {
+		defer enableMulti(cmd)()
+	}
+	return next(ctx, cmd, expanded)
+}
+
+func (p *Presets) selectPreset(cmd *cobra.Command, presets []*Preset) (*Preset, error) {
+	if len(presets) == 0 {
+		return nil, fmt.Errorf("no %s.presets are defined in the Aspect CLI config", p.Command)
+	}
+	presetNames := make([]string, len(presets))
+	for i, preset := range presets {
+		presetNames[i] = fmt.Sprintf("%s: %s", preset.Name, preset.Description)
+	}
+	if cmd == nil || !shared.IsInteractive(cmd, p.IsInteractive) {
+		return nil, fmt.Errorf("a preset must be named when the session isn't interactive, such as one of:\n  @%s", strings.Join(presetNames, "\n  @"))
+	}
+	i, _, err := p.Select(presetNames).Run()
+	if err != nil {
+		return nil, err
+	}
+	return presets[i], nil
+}
+
+// runFirst returns the presets that run before the given preset, in the order they run, followed
+// by the preset itself.
+func runFirst(preset *Preset, byName map[string]*Preset) ([]*Preset, error) {
+	chain := []*Preset{preset}
+	for preset.Before != "" {
+		before, ok := byName[preset.Before]
+		if !ok {
+			return nil, fmt.Errorf("preset %q runs the unknown preset %q first", preset.Name, preset.Before)
+		}
+		if slices.Contains(chain, before) {
+			var names []string
+			for _, p := range chain {
+				names = append(names, p.Name)
+			}
+			names = append(names, before.Name)
+			return nil, fmt.Errorf("presets can't run each other first: %s", strings.Join(names, " -> "))
+		}
+		chain = append(chain, before)
+		preset = before
+	}
+	slices.Reverse(chain)
+	return chain, nil
+}
+
+// expand returns the arguments of the command for the preset given the remaining arguments of
+// the command line. Flags of the command line follow those of the preset so that they take
+// precedence. Other arguments are passed to the program by `aspect run`, and are more target
+// patterns for `aspect test`.
+func (preset *Preset) expand(command string, env []string, args []string) ([]string, error) {
+	if len(preset.Targets) == 0 {
+		return nil, fmt.Errorf("preset %q has no targets", preset.Name)
+	}
+
+	before, after := args, []string(nil)
+	if i := slices.Index(args, "--"); i >= 0 {
+		before, after = args[:i], args[i+1:]
+	}
+	positional, flags, err := bazel.SeparateBazelFlags(command, before)
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range positional {
+		if strings.HasPrefix(arg, "-") {
+			// Flags of Aspect CLI and flags unknown to Bazel.
+			flags = append(flags, arg)
+		}
+	}
+	positional = slices.DeleteFunc(positional, func(arg string) bool {
+		return strings.HasPrefix(arg, "-")
+	})
+
+	expanded := slices.Clone(preset.Flags)
+	if command == "test" {
+		for _, e := range env {
+			expanded = append(expanded, "--test_env="+e)
+		}
+		for _, arg := range preset.Args {
+			expanded = append(expanded, "--test_arg="+arg)
+		}
+	}
+	expanded = append(expanded, flags...)
+	expanded = append(expanded, preset.Targets...)
+
+	if command == "test" {
+		expanded = append(expanded, positional...)
+		if len(after) > 0 {
+			expanded = append(append(expanded, "--"), after...)
+		}
+		return expanded, nil
+	}
+
+	programArgs := append(append(slices.Clone(preset.Args), positional...), after...)
+	if len(programArgs) > 0 {
+		expanded = append(append(expanded, "--"), programArgs...)
+	}
+	return expanded, nil
+}
+
+// presetArgIndex returns the index of the first argument before a double dash that isn't a flag
+// when it starts with @, or -1.
+func presetArgIndex(args []string) int {
+	for i, arg := range args {
+		if arg == "--" {
+			return -1
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if strings.HasPrefix(arg, "@") && !strings.ContainsAny(arg, "/:") {
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+// setenv sets the variables of env in the environment, and returns a function that restores
+// their previous values.
+func setenv(env []string) (func(), error) {
+	var restores []func()
+	restore := func() {
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+	for _, e := range env {
+		key, value, _ := strings.Cut(e, "=")
+		if previous, ok := os.LookupEnv(key); ok {
+			restores = append(restores, func() { os.Setenv(key, previous) })
+		} else {
+			restores = append(restores, func() { os.Unsetenv(key) })
+		}
+		if err := os.Setenv(key, value); err != nil {
+			restore()
+			return nil, fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return restore, nil
+}
+
+// disableWatch turns off --watch, and returns a function that turns it back on.
+func disableWatch(cmd *cobra.Command) func() {
+	if cmd == nil {
+		return func() {}
+	}
+	f := cmd.Flags().Lookup(watch.FlagName)
+	if f == nil || f.Value.String() != "true" {
+		return func() {}
+	}
+	f.Value.Set("false")
+	return func() { f.Value.Set("true") }
+}
+
+// enableMulti sets --run:multi for the duration of a run preset with several targets, so that they
+// run at the same time.
+func enableMulti(cmd *cobra.Command) func() {
+	if cmd == nil {
+		return func() {}
+	}
+	f := cmd.Flags().Lookup(run.MultiFlagName)
+	if f == nil || f.Value.String() == "true" {
+		return func() {}
+	}
+	f.Value.Set("true")
+	return func() { f.Value.Set("false") }
+}
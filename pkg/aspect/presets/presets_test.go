@@ -0,0 +1,262 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package presets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/query/shared"
+	query_mock "github.com/aspect-build/aspect-cli/pkg/aspect/query/shared/mock"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/run"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+const config = `
+run:
+  presets:
+    dev-stack:
+      description: Run the services
+      targets: ["//svc/api", "//svc/web"]
+      flags: ["--config=dev"]
+      env: ["PRESETS_TEST_LOG_LEVEL=debug"]
+      before: migrate
+    migrate:
+      targets: //db:migrate
+      args: ["--to=latest"]
+      env_files: [".env.migrate"]
+    loop-a:
+      targets: ["//a"]
+      before: loop-b
+    loop-b:
+      targets: ["//b"]
+      before: loop-a
+test:
+  presets:
+    integration:
+      targets: ["//services/..."]
+      flags: ["--test_tag_filters=integration"]
+      env: ["DATABASE_URL=postgres://localhost/test"]
+      args: ["--verbose"]
+`
+
+type call struct {
+	args []string
+	env  map[string]string
+}
+
+func newPresets(t *testing.T, command string) (*Presets, *strings.Builder) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	workspaceRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workspaceRoot, ".env.migrate"), []byte("PRESETS_TEST_DB=local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bzl := bazel_mock.NewMockBazel(ctrl)
+	bzl.EXPECT().WorkspaceRoot().Return(workspaceRoot).AnyTimes()
+
+	prefs := viper.New()
+	prefs.SetConfigType("yaml")
+	if err := prefs.ReadConfig(strings.NewReader(config)); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr strings.Builder
+	p := New(ioutils.Streams{Stderr: &stderr}, bzl, command, true)
+	p.Prefs = *prefs
+	return p, &stderr
+}
+
+// record returns the command that the interceptor calls next, which records its arguments and the
+// environment variables of the presets.
+func record(calls *[]call) func(ctx context.Context, cmd *cobra.Command, args []string) error {
+	return func(ctx context.Context, cmd *cobra.Command, args []string) error {
+		env := map[string]string{}
+		for _, key := range []string{"PRESETS_TEST_LOG_LEVEL", "PRESETS_TEST_DB"} {
+			if value, ok := os.LookupEnv(key); ok {
+				env[key] = value
+			}
+		}
+		*calls = append(*calls, call{args: args, env: env})
+		return nil
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	t.Run("passes arguments that don't name a preset through", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "run")
+
+		for _, args := range [][]string{
+			{"//svc/api"},
+			{"@other//svc:api"},
+			{"@unknown"},
+			{"//svc/api", "--", "@dev-stack"},
+		} {
+			var calls []call
+			g.Expect(p.Interceptor(context.Background(), nil, args, record(&calls))).To(Succeed())
+			g.Expect(calls).To(HaveLen(1))
+			g.Expect(calls[0].args).To(Equal(args))
+		}
+	})
+
+	t.Run("runs the preset to run first and then the named preset", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, stderr := newPresets(t, "run")
+
+		var calls []call
+		err := p.Interceptor(context.Background(), nil, []string{"@dev-stack"}, record(&calls))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls).To(Equal([]call{
+			{
+				args: []string{"//db:migrate", "--", "--to=latest"},
+				env:  map[string]string{"PRESETS_TEST_DB": "local"},
+			},
+			{
+				args: []string{"--config=dev", "//svc/api", "//svc/web"},
+				env:  map[string]string{"PRESETS_TEST_LOG_LEVEL": "debug"},
+			},
+		}))
+		g.Expect(stderr.String()).To(Equal("Running preset \"migrate\"\nRunning preset \"dev-stack\": Run the services\n"))
+
+		_, set := os.LookupEnv("PRESETS_TEST_LOG_LEVEL")
+		g.Expect(set).To(BeFalse())
+	})
+
+	t.Run("passes the remaining arguments to the program", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "run")
+
+		var calls []call
+		err := p.Interceptor(context.Background(), nil, []string{"@migrate", "--", "--dry-run"}, record(&calls))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls[0].args).To(Equal([]string{"//db:migrate", "--", "--to=latest", "--dry-run"}))
+	})
+
+	t.Run("passes the environment and arguments of test presets as flags", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "test")
+
+		var calls []call
+		err := p.Interceptor(context.Background(), nil, []string{"@integration", "//more/..."}, record(&calls))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls[0].args).To(Equal([]string{
+			"--test_tag_filters=integration",
+			"--test_env=DATABASE_URL=postgres://localhost/test",
+			"--test_arg=--verbose",
+			"//services/...",
+			"//more/...",
+		}))
+	})
+
+	t.Run("doesn't watch the preset to run first", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "run")
+
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool(watch.FlagName, false, "")
+		g.Expect(cmd.Flags().Set(watch.FlagName, "true")).To(Succeed())
+
+		var watched []bool
+		err := p.Interceptor(context.Background(), cmd, []string{"@dev-stack"}, func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			enabled, _ := cmd.Flags().GetBool(watch.FlagName)
+			watched = append(watched, enabled)
+			return nil
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(watched).To(Equal([]bool{false, true}))
+	})
+
+	t.Run("runs the targets of a preset with several targets at the same time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "run")
+
+		cmd := &cobra.Command{}
+		cmd.Flags().Bool(run.MultiFlagName, false, "")
+
+		var multi []bool
+		err := p.Interceptor(context.Background(), cmd, []string{"@dev-stack"}, func(ctx context.Context, cmd *cobra.Command, args []string) error {
+			enabled, _ := cmd.Flags().GetBool(run.MultiFlagName)
+			multi = append(multi, enabled)
+			return nil
+		})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(multi).To(Equal([]bool{false, true}))
+		g.Expect(cmd.Flags().Lookup(run.MultiFlagName).Value.String()).To(Equal("false"))
+	})
+
+	t.Run("fails when presets run each other first", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "run")
+
+		var calls []call
+		err := p.Interceptor(context.Background(), nil, []string{"@loop-a"}, record(&calls))
+		g.Expect(err).To(MatchError("presets can't run each other first: loop-a -> loop-b -> loop-a"))
+		g.Expect(calls).To(BeEmpty())
+	})
+
+	t.Run("selects a preset interactively", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		p, _ := newPresets(t, "run")
+
+		selectRunner := query_mock.NewMockSelectRunner(ctrl)
+		selectRunner.
+			EXPECT().
+			Run().
+			Return(3, "", nil).
+			Times(1)
+		p.Select = func(presetNames []string) shared.SelectRunner {
+			g.Expect(presetNames).To(Equal([]string{
+				"dev-stack: Run the services",
+				"loop-a: ",
+				"loop-b: ",
+				"migrate: ",
+			}))
+			return selectRunner
+		}
+
+		var calls []call
+		err := p.Interceptor(context.Background(), &cobra.Command{}, []string{"@"}, record(&calls))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls).To(HaveLen(1))
+		g.Expect(calls[0].args).To(Equal([]string{"//db:migrate", "--", "--to=latest"}))
+	})
+
+	t.Run("lists the presets when the session isn't interactive", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		p, _ := newPresets(t, "test")
+		p.IsInteractive = false
+
+		var calls []call
+		err := p.Interceptor(context.Background(), &cobra.Command{}, []string{"@"}, record(&calls))
+		g.Expect(err).To(MatchError("a preset must be named when the session isn't interactive, such as one of:\n  @integration: "))
+		g.Expect(calls).To(BeEmpty())
+	})
+}
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/bazel/query"
+)
+
+// Node is a target in the dependency graph.
+type Node struct {
+	Label string
+	// Kind is the rule class of a rule, or the kind of a file target.
+	Kind string
+	// Tags are the tags of a rule.
+	Tags []string
+	// Deps are the labels of the direct dependencies of the target that are in the graph.
+	Deps []string
+}
+
+// Graph is the dependency graph of the targets in a query result. Like Bazel's
+// --output=graph, it only has edges between targets that are in the result.
+type Graph struct {
+	Nodes map[string]*Node
+}
+
+// FromQueryResult returns the dependency graph of a `query --output=proto` result.
+func FromQueryResult(result *query.QueryResult) *Graph {
+	g := &Graph{Nodes: make(map[string]*Node)}
+	deps := make(map[string][]string)
+	for _, target := range result.GetTarget() {
+		label, kind, targetDeps := describeTarget(target)
+		g.add(label, kind, ruleTags(target))
+		deps[label] = append(deps[label], targetDeps...)
+	}
+	g.connect(deps)
+	return g
+}
+
+// FromCqueryResult returns the dependency graph of a `cquery --output=proto` result. Targets that
+// are configured more than once are merged into a single node.
+func FromCqueryResult(result *analysis.CqueryResult) *Graph {
+	g := &Graph{Nodes: make(map[string]*Node)}
+	deps := make(map[string][]string)
+	for _, configured := range result.GetResults() {
+		label, kind, targetDeps := describeTarget(configured.GetTarget())
+		for _, input := range configured.GetTarget().GetRule().GetConfiguredRuleInput() {
+			targetDeps = append(targetDeps, input.GetLabel())
+		}
+		g.add(label, kind, ruleTags(configured.GetTarget()))
+		deps[label] = append(deps[label], targetDeps...)
+	}
+	g.connect(deps)
+	return g
+}
+
+func ruleTags(target *query.Target) []string {
+	for _, attr := range target.GetRule().GetAttribute() {
+		if attr.GetName() == "tags" {
+			return attr.GetStringListValue()
+		}
+	}
+	return nil
+}
+
+func describeTarget(target *query.Target) (string, string, []string) {
+	switch target.GetType() {
+	case query.Target_RULE:
+		return target.GetRule().GetName(), target.GetRule().GetRuleClass(), target.GetRule().GetRuleInput()
+	case query.Target_SOURCE_FILE:
+		return target.GetSourceFile().GetName(), "source file", nil
+	case query.Target_GENERATED_FILE:
+		return target.GetGeneratedFile().GetName(), "generated file", []string{target.GetGeneratedFile().GetGeneratingRule()}
+	case query.Target_PACKAGE_GROUP:
+		return target.GetPackageGroup().GetName(), "package group", nil
+	case query.Target_ENVIRONMENT_GROUP:
+		return target.GetEnvironmentGroup().GetName(), "environment group", nil
+	}
+	return "", "", nil
+}
+
+func (g *Graph) add(label string, kind string, tags []string) {
+	if label == "" {
+		return
+	}
+	if _, ok := g.Nodes[label]; !ok {
+		g.Nodes[label] = &Node{Label: label, Kind: kind, Tags: tags}
+	}
+}
+
+// connect adds the edges to the deps that are in the graph.
+func (g *Graph) connect(deps map[string][]string) {
+	for label, labelDeps := range deps {
+		node, ok := g.Nodes[label]
+		if !ok {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, dep := range labelDeps {
+			if _, ok := g.Nodes[dep]; ok && dep != label && !seen[dep] {
+				seen[dep] = true
+				node.Deps = append(node.Deps, dep)
+			}
+		}
+		sort.Strings(node.Deps)
+	}
+}
+
+// Labels returns the labels of the nodes in sorted order.
+func (g *Graph) Labels() []string {
+	labels := make([]string, 0, len(g.Nodes))
+	for label := range g.Nodes {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// Roots returns the labels of the nodes that no other node depends on, in sorted order. When
+// every node is depended on, which happens when the graph is a cycle, all nodes are roots.
+func (g *Graph) Roots() []string {
+	dependedOn := make(map[string]bool)
+	for _, node := range g.Nodes {
+		for _, dep := range node.Deps {
+			dependedOn[dep] = true
+		}
+	}
+	roots := []string{}
+	for _, label := range g.Labels() {
+		if !dependedOn[label] {
+			roots = append(roots, label)
+		}
+	}
+	if len(roots) == 0 {
+		return g.Labels()
+	}
+	return roots
+}
+
+// WithoutExternal returns the graph without the targets in external repositories.
+func (g *Graph) WithoutExternal() *Graph {
+	return g.filter(func(label string) bool {
+		return !isExternal(label)
+	})
+}
+
+// WithDepth returns the graph of the nodes that are at most depth edges away from a root.
+func (g *Graph) WithDepth(depth int) *Graph {
+	distance := make(map[string]int)
+	queue := []string{}
+	for _, root := range g.Roots() {
+		distance[root] = 0
+		queue = append(queue, root)
+	}
+	for len(queue) > 0 {
+		label := queue[0]
+		queue = queue[1:]
+		if distance[label] == depth {
+			continue
+		}
+		for _, dep := range g.Nodes[label].Deps {
+			if _, ok := distance[dep]; !ok {
+				distance[dep] = distance[label] + 1
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return g.filter(func(label string) bool {
+		_, ok := distance[label]
+		return ok
+	})
+}
+
+func (g *Graph) filter(keep func(label string) bool) *Graph {
+	filtered := &Graph{Nodes: make(map[string]*Node)}
+	for label, node := range g.Nodes {
+		if keep(label) {
+			filtered.Nodes[label] = &Node{Label: label, Kind: node.Kind, Tags: node.Tags}
+		}
+	}
+	for label, node := range filtered.Nodes {
+		for _, dep := range g.Nodes[label].Deps {
+			if _, ok := filtered.Nodes[dep]; ok {
+				node.Deps = append(node.Deps, dep)
+			}
+		}
+	}
+	return filtered
+}
+
+// isExternal returns whether a label is in an external repository rather than the main one.
+func isExternal(label string) bool {
+	if !strings.HasPrefix(label, "@") {
+		return false
+	}
+	repo := strings.TrimLeft(label, "@")
+	return !strings.HasPrefix(repo, "//")
+}
@@ -0,0 +1,232 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aspect-build/aspect-cli/bazel/query"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func rule(name string, ruleClass string, inputs ...string) *query.Target {
+	return &query.Target{
+		Type: query.Target_RULE.Enum(),
+		Rule: &query.Rule{Name: proto.String(name), RuleClass: proto.String(ruleClass), RuleInput: inputs},
+	}
+}
+
+func sourceFile(name string) *query.Target {
+	return &query.Target{
+		Type:       query.Target_SOURCE_FILE.Enum(),
+		SourceFile: &query.SourceFile{Name: proto.String(name)},
+	}
+}
+
+func testResult() *query.QueryResult {
+	return &query.QueryResult{
+		Target: []*query.Target{
+			rule("//app:app", "go_binary", "//app:main.go", "//lib:lib", "//tools:not_in_result"),
+			sourceFile("//app:main.go"),
+			rule("//lib:lib", "go_library", "@com_github_foo//:foo", "//util:util"),
+			rule("//util:util", "go_library"),
+			rule("@com_github_foo//:foo", "go_library", "//util:util"),
+		},
+	}
+}
+
+func TestGraph(t *testing.T) {
+	t.Run("only has edges between targets in the result", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		graph := FromQueryResult(testResult())
+		g.Expect(graph.Labels()).To(HaveLen(5))
+		g.Expect(graph.Nodes["//app:app"].Deps).To(Equal([]string{"//app:main.go", "//lib:lib"}))
+		g.Expect(graph.Nodes["//app:main.go"].Kind).To(Equal("source file"))
+		g.Expect(graph.Roots()).To(Equal([]string{"//app:app"}))
+	})
+
+	t.Run("excludes external repositories", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		graph := FromQueryResult(testResult()).WithoutExternal()
+		g.Expect(graph.Labels()).To(Equal([]string{"//app:app", "//app:main.go", "//lib:lib", "//util:util"}))
+		g.Expect(graph.Nodes["//lib:lib"].Deps).To(Equal([]string{"//util:util"}))
+		g.Expect(isExternal("@//foo:bar")).To(BeFalse())
+		g.Expect(isExternal("@@//foo:bar")).To(BeFalse())
+		g.Expect(isExternal("@@rules_go~//go:def")).To(BeTrue())
+	})
+
+	t.Run("limits the depth from the roots", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		graph := FromQueryResult(testResult()).WithDepth(1)
+		g.Expect(graph.Labels()).To(Equal([]string{"//app:app", "//app:main.go", "//lib:lib"}))
+		g.Expect(graph.Nodes["//lib:lib"].Deps).To(BeEmpty())
+	})
+
+	t.Run("treats all targets of a cycle as roots", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		graph := FromQueryResult(&query.QueryResult{Target: []*query.Target{rule("//a", "r", "//b"), rule("//b", "r", "//a")}})
+		g.Expect(graph.Roots()).To(Equal([]string{"//a", "//b"}))
+	})
+}
+
+func TestWrite(t *testing.T) {
+	graph := FromQueryResult(testResult()).WithoutExternal()
+
+	t.Run("writes dot", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out strings.Builder
+		g.Expect(Write(&out, DotFormat, graph)).To(Succeed())
+		g.Expect(out.String()).To(Equal(`digraph query {
+  rankdir=LR;
+  node [shape=box];
+  "//app:app" [tooltip="go_binary"];
+  "//app:main.go" [tooltip="source file"];
+  "//lib:lib" [tooltip="go_library"];
+  "//util:util" [tooltip="go_library"];
+  "//app:app" -> "//app:main.go";
+  "//app:app" -> "//lib:lib";
+  "//lib:lib" -> "//util:util";
+}
+`))
+	})
+
+	t.Run("writes mermaid", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out strings.Builder
+		g.Expect(Write(&out, MermaidFormat, graph)).To(Succeed())
+		g.Expect(out.String()).To(Equal(`graph LR
+  n0["//app:app"]
+  n1["//app:main.go"]
+  n2["//lib:lib"]
+  n3["//util:util"]
+  n0 --> n1
+  n0 --> n2
+  n2 --> n3
+`))
+	})
+
+	t.Run("writes html with each target's deps listed once", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		diamond := FromQueryResult(&query.QueryResult{Target: []*query.Target{
+			rule("//a", "r", "//b", "//c"),
+			rule("//b", "r", "//d"),
+			rule("//c", "r", "//d"),
+			rule("//d", "r", "//e"),
+			rule("//e", "r"),
+		}})
+		var out strings.Builder
+		g.Expect(Write(&out, HTMLFormat, diamond)).To(Succeed())
+		html := out.String()
+		g.Expect(html).To(HavePrefix("<!DOCTYPE html>"))
+		g.Expect(html).To(ContainSubstring("5 targets, 5 dependencies"))
+		g.Expect(html).To(ContainSubstring(`<details id="n3" open><summary>//d`))
+		g.Expect(html).To(ContainSubstring(`<a class="ref" href="#n3">//d</a>`))
+		g.Expect(strings.Count(html, `data-label="//e"`)).To(Equal(1))
+		g.Expect(html).NotTo(ContainSubstring("http"))
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(Write(&strings.Builder{}, "png", graph)).To(MatchError(`unknown graph format "png", expected one of dot, mermaid or html`))
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("renders the proto output of query", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		result, err := proto.Marshal(testResult())
+		g.Expect(err).NotTo(HaveOccurred())
+
+		bzl := mock.NewMockBazel(ctrl)
+		bzl.EXPECT().
+			RunCommand(gomock.Any(), nil, "query", "--output=proto", "--keep_going", "deps(//app)").
+			DoAndReturn(func(streams ioutils.Streams, _ *string, _ ...string) error {
+				_, err := streams.Stdout.Write(result)
+				return err
+			})
+
+		var stdout strings.Builder
+		opts := &Options{Format: MermaidFormat, Depth: 1, ExcludeExternal: true}
+		args := []string{"--output=label_kind", "--keep_going", "deps(//app)"}
+		g.Expect(Run(bzl, "query", ioutils.Streams{Stdout: &stdout}, args, opts)).To(Succeed())
+		g.Expect(stdout.String()).To(HavePrefix("graph LR\n  n0[\"//app:app\"]\n"))
+		g.Expect(stdout.String()).NotTo(ContainSubstring("//util:util"))
+	})
+
+	t.Run("is only supported by query and cquery", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		err := Run(nil, "aquery", ioutils.Streams{}, nil, &Options{Format: DotFormat})
+		g.Expect(err).To(MatchError("--graph is only supported by query and cquery, not aquery"))
+	})
+}
+
+func TestFlags(t *testing.T) {
+	t.Run("reads the options from the command", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		cmd := &cobra.Command{Use: "query"}
+		AddFlags(cmd.Flags())
+		opts, err := OptionsFromFlags(cmd)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(opts).To(BeNil())
+
+		g.Expect(cmd.ParseFlags([]string{"--graph", "html", "--graph_depth=2", "--graph_exclude_external"})).To(Succeed())
+		opts, err = OptionsFromFlags(cmd)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(opts).To(Equal(&Options{Format: HTMLFormat, Depth: 2, ExcludeExternal: true}))
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		cmd := &cobra.Command{Use: "query"}
+		AddFlags(cmd.Flags())
+		g.Expect(cmd.ParseFlags([]string{"--graph=svg"})).To(Succeed())
+		_, err := OptionsFromFlags(cmd)
+		g.Expect(err).To(MatchError(`unknown --graph format "svg", expected one of dot, mermaid or html`))
+	})
+
+	t.Run("removes the graph flags from the query arguments", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		cmd := &cobra.Command{Use: "query"}
+		AddFlags(cmd.Flags())
+		args := []string{"--graph", "dot", "deps(//a)", "--graph_depth=2", "--nograph_exclude_external", "--keep_going", "--", "--graph"}
+		g.Expect(bazel.RemoveFlags("query", cmd.Flags(), args, GraphFlagName, DepthFlagName, ExcludeExternalFlagName)).
+			To(Equal([]string{"deps(//a)", "--keep_going", "--", "--graph"}))
+	})
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+//go:embed graph.html.tmpl
+var htmlTemplateSource string
+
+var htmlTemplate = template.Must(template.New("graph").Parse(htmlTemplateSource))
+
+// treeNode is a node in the tree that the HTML page shows the graph as. A target that is reachable
+// along several paths has its deps listed only at its first occurrence, and links to it elsewhere.
+type treeNode struct {
+	ID       string
+	Label    string
+	Kind     string
+	Ref      bool
+	Children []*treeNode
+}
+
+type htmlPage struct {
+	Nodes int
+	Edges int
+	Roots []*treeNode
+}
+
+// writeHTML renders the graph as a self-contained HTML page, which shows the graph as a
+// collapsible tree from its roots, with a search box to find targets in it.
+func writeHTML(w io.Writer, g *Graph) error {
+	ids := make(map[string]string, len(g.Nodes))
+	for i, label := range g.Labels() {
+		ids[label] = fmt.Sprintf("n%d", i)
+	}
+	shown := make(map[string]bool)
+	var build func(label string) *treeNode
+	build = func(label string) *treeNode {
+		node := &treeNode{ID: ids[label], Label: label, Kind: g.Nodes[label].Kind}
+		if shown[label] {
+			node.Ref = true
+			return node
+		}
+		shown[label] = true
+		for _, dep := range g.Nodes[label].Deps {
+			node.Children = append(node.Children, build(dep))
+		}
+		return node
+	}
+
+	page := htmlPage{Nodes: len(g.Nodes)}
+	for _, node := range g.Nodes {
+		page.Edges += len(node.Deps)
+	}
+	for _, root := range g.Roots() {
+		if !shown[root] {
+			page.Roots = append(page.Roots, build(root))
+		}
+	}
+	return htmlTemplate.Execute(w, page)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The formats that a graph can be rendered in.
+const (
+	DotFormat     = "dot"
+	MermaidFormat = "mermaid"
+	HTMLFormat    = "html"
+)
+
+// Write renders the graph in the given format.
+func Write(w io.Writer, format string, g *Graph) error {
+	switch format {
+	case DotFormat:
+		return writeDot(w, g)
+	case MermaidFormat:
+		return writeMermaid(w, g)
+	case HTMLFormat:
+		return writeHTML(w, g)
+	}
+	return fmt.Errorf("unknown graph format %q, expected one of %s, %s or %s", format, DotFormat, MermaidFormat, HTMLFormat)
+}
+
+// writeDot renders the graph in the Graphviz DOT language, which can be turned into an image with
+// `dot -Tsvg`.
+func writeDot(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph query {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, label := range g.Labels() {
+		node := g.Nodes[label]
+		fmt.Fprintf(&b, "  %s [tooltip=%s];\n", dotQuote(label), dotQuote(node.Kind))
+	}
+	for _, label := range g.Labels() {
+		for _, dep := range g.Nodes[label].Deps {
+			fmt.Fprintf(&b, "  %s -> %s;\n", dotQuote(label), dotQuote(dep))
+		}
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// writeMermaid renders the graph as a Mermaid flowchart, which GitHub renders when it's pasted
+// into a ```mermaid code block.
+func writeMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	ids := make(map[string]string, len(g.Nodes))
+	for i, label := range g.Labels() {
+		ids[label] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[label], mermaidEscape(label))
+	}
+	for _, label := range g.Labels() {
+		for _, dep := range g.Nodes[label].Deps {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[label], ids[dep])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graph
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/aspect-build/aspect-cli/bazel/analysis"
+	"github.com/aspect-build/aspect-cli/bazel/query"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+const (
+	GraphFlagName           = "graph"
+	DepthFlagName           = "graph_depth"
+	ExcludeExternalFlagName = "graph_exclude_external"
+)
+
+// Options controls how the result of a query is rendered as a graph.
+type Options struct {
+	Format string
+	// Depth is the number of edges from the roots of the graph to keep, or 0 to keep all.
+	Depth           int
+	ExcludeExternal bool
+}
+
+func AddFlags(flagSet *pflag.FlagSet) {
+	flagSet.String(GraphFlagName, "", "Render the dependency graph of the query result instead of printing it: dot, mermaid or html")
+	flagSet.Int(DepthFlagName, 0, "With --graph, only render targets that are at most this many dependencies away from the top of the graph, or all targets when 0")
+	flagSet.Bool(ExcludeExternalFlagName, false, "With --graph, leave out targets in external repositories")
+}
+
+// OptionsFromFlags returns the graph options that were set on the command, or nil when --graph
+// wasn't set.
+func OptionsFromFlags(cmd *cobra.Command) (*Options, error) {
+	if cmd.Flags().Lookup(GraphFlagName) == nil {
+		return nil, nil
+	}
+	format, err := cmd.Flags().GetString(GraphFlagName)
+	if err != nil || format == "" {
+		return nil, err
+	}
+	depth, err := cmd.Flags().GetInt(DepthFlagName)
+	if err != nil {
+		return nil, err
+	}
+	if depth < 0 {
+		return nil, fmt.Errorf("--%s must not be negative", DepthFlagName)
+	}
+	excludeExternal, err := cmd.Flags().GetBool(ExcludeExternalFlagName)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case DotFormat, MermaidFormat, HTMLFormat:
+	default:
+		return nil, fmt.Errorf("unknown --%s format %q, expected one of %s, %s or %s", GraphFlagName, format, DotFormat, MermaidFormat, HTMLFormat)
+	}
+	return &Options{Format: format, Depth: depth, ExcludeExternal: excludeExternal}, nil
+}
+
+// Run runs the query with --output=proto and renders its result as a graph.
+func Run(bzl bazel.Bazel, verb string, streams ioutils.Streams, args []string, opts *Options) error {
+	g, err := Query(bzl, verb, streams, args)
+	if err != nil {
+		return err
+	}
+	if opts.ExcludeExternal {
+		g = g.WithoutExternal()
+	}
+	if opts.Depth > 0 {
+		g = g.WithDepth(opts.Depth)
+	}
+	return Write(streams.Stdout, opts.Format, g)
+}
+
+// Query runs a query or cquery with --output=proto and returns the dependency graph of its result.
+// Any --output flag in args is overridden.
+func Query(bzl bazel.Bazel, verb string, streams ioutils.Streams, args []string) (*Graph, error) {
+	if verb != "query" && verb != "cquery" {
+		return nil, fmt.Errorf("--%s is only supported by query and cquery, not %s", GraphFlagName, verb)
+	}
+
+	var stdout bytes.Buffer
+	bazelStreams := ioutils.Streams{Stdin: streams.Stdin, Stdout: &stdout, Stderr: streams.Stderr}
+	// --output is replaced by --output=proto.
+	bazelCmd := append([]string{verb, "--output=proto"}, bazel.RemoveFlags(verb, nil, args, "output")...)
+	if err := bzl.RunCommand(bazelStreams, nil, bazelCmd...); err != nil {
+		return nil, err
+	}
+
+	if verb == "query" {
+		result := &query.QueryResult{}
+		if err := proto.Unmarshal(stdout.Bytes(), result); err != nil {
+			return nil, fmt.Errorf("failed to parse query result: %w", err)
+		}
+		return FromQueryResult(result), nil
+	}
+	result := &analysis.CqueryResult{}
+	if err := proto.Unmarshal(stdout.Bytes(), result); err != nil {
+		return nil, fmt.Errorf("failed to parse cquery result: %w", err)
+	}
+	return FromCqueryResult(result), nil
+}
@@ -0,0 +1,254 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// The types of preset query parameters.
+const (
+	StringParameter        = "string"
+	LabelParameter         = "label"
+	TargetPatternParameter = "target_pattern"
+	IntegerParameter       = "integer"
+)
+
+// PresetParameter describes a ?placeholder in the query of a preset.
+type PresetParameter struct {
+	Name        string
+	Type        string
+	Default     string
+	Description string
+}
+
+// presetParameters reads the parameters of a user defined preset from the config, such as
+//
+//	query:
+//	  presets:
+//	    rdeps:
+//	      query: rdeps(//..., ?target, ?depth)
+//	      parameters:
+//	        target:
+//	          type: label
+//	          description: The target to find the reverse dependencies of
+//	        depth:
+//	          type: integer
+//	          default: 1
+func presetParameters(viper viper.Viper, presetKey string) []*PresetParameter {
+	parametersKey := fmt.Sprintf("%s.parameters", presetKey)
+	parameters := []*PresetParameter{}
+	for name := range viper.GetStringMap(parametersKey) {
+		parameter := viper.GetStringMapString(fmt.Sprintf("%s.%s", parametersKey, name))
+		parameters = append(parameters, &PresetParameter{
+			Name:        name,
+			Type:        parameter["type"],
+			Default:     parameter["default"],
+			Description: parameter["description"],
+		})
+	}
+	return parameters
+}
+
+// IsInteractive returns whether the user may be prompted for the parameters of a preset query,
+// honoring the --aspect:interactive flag when the command has it.
+func IsInteractive(cmd *cobra.Command, isInteractive bool) bool {
+	if !isInteractive {
+		return false
+	}
+	if cmd.Root().PersistentFlags().Lookup(flags.AspectInteractiveFlagName) == nil {
+		return true
+	}
+	interactive, err := cmd.Root().PersistentFlags().GetBool(flags.AspectInteractiveFlagName)
+	return err == nil && interactive
+}
+
+// ExpandPreset replaces the placeholders in the query of a preset with the values of its
+// parameters. args are the non-flag arguments of the command, the first of which names the preset
+// unless it was selected interactively. Parameters are given by name as --name=value or
+// --name value, or positionally in the order they appear in the query.
+// Parameters that are absent take their default value, or are prompted for when interactive.
+// The remaining flags in args, which are not parameters, are returned to be passed to Bazel.
+func ExpandPreset(preset *PresetQuery, streams ioutils.Streams, args []string, interactive bool, p func(label string) PromptRunner) (string, []string, error) {
+	parameters, err := preset.parameters()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(args) > 0 && args[0] == preset.Name {
+		args = args[1:]
+	}
+
+	values := make(map[string]string)
+	otherFlags := []string{}
+	positional := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		parameter := findParameter(parameters, name)
+		if parameter == nil {
+			otherFlags = append(otherFlags, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return "", nil, fmt.Errorf("missing value for parameter %q of preset %q", parameter.Name, preset.Name)
+			}
+			i++
+			value = args[i]
+		}
+		values[parameter.Name] = value
+	}
+
+	unset := []*PresetParameter{}
+	for _, parameter := range parameters {
+		if _, ok := values[parameter.Name]; !ok {
+			unset = append(unset, parameter)
+		}
+	}
+	if len(positional) > len(unset) {
+		return "", nil, fmt.Errorf("preset %q takes %d parameters but got %d arguments", preset.Name, len(parameters), len(values)+len(positional))
+	}
+	for i, value := range positional {
+		values[unset[i].Name] = value
+	}
+
+	for _, parameter := range parameters {
+		value, ok := values[parameter.Name]
+		if ok {
+			fmt.Fprintf(streams.Stdout, "%s set to %s\n", parameter.Name, value)
+		} else if parameter.Default != "" {
+			value = parameter.Default
+		} else if interactive {
+			label := fmt.Sprintf("Value for '%s'", parameter.Name)
+			if parameter.Description != "" {
+				label = fmt.Sprintf("%s (%s)", label, parameter.Description)
+			}
+			value, err = p(label).Run()
+			if err != nil {
+				return "", nil, err
+			}
+		} else {
+			return "", nil, parameter.missingError(preset)
+		}
+		if err := parameter.validate(value); err != nil {
+			return "", nil, fmt.Errorf("invalid value %q for parameter %q of preset %q: %w", value, parameter.Name, preset.Name, err)
+		}
+		values[parameter.Name] = value
+	}
+
+	query := placeholderRegex.ReplaceAllStringFunc(preset.Query, func(placeholder string) string {
+		return values[strings.TrimPrefix(placeholder, "?")]
+	})
+	return query, otherFlags, nil
+}
+
+// parameters returns the parameters of the preset in the order they appear in its query. Viper
+// lowercases config keys, so placeholders are matched to their declarations case-insensitively.
+func (preset *PresetQuery) parameters() ([]*PresetParameter, error) {
+	parameters := []*PresetParameter{}
+	for _, placeholder := range placeholderRegex.FindAllString(preset.Query, -1) {
+		name := strings.TrimPrefix(placeholder, "?")
+		if findParameter(parameters, name) != nil {
+			continue
+		}
+		parameter := &PresetParameter{Name: name, Type: StringParameter}
+		if declared := findParameter(preset.Parameters, name); declared != nil {
+			parameter.Type = declared.Type
+			parameter.Default = declared.Default
+			parameter.Description = declared.Description
+			if parameter.Type == "" {
+				parameter.Type = StringParameter
+			}
+		}
+		switch parameter.Type {
+		case StringParameter, LabelParameter, TargetPatternParameter, IntegerParameter:
+		default:
+			return nil, fmt.Errorf("parameter %q of preset %q has unknown type %q, expected one of %s, %s, %s or %s", name, preset.Name, parameter.Type, StringParameter, LabelParameter, TargetPatternParameter, IntegerParameter)
+		}
+		parameters = append(parameters, parameter)
+	}
+	for _, declared := range preset.Parameters {
+		if findParameter(parameters, declared.Name) == nil {
+			return nil, fmt.Errorf("parameter %q of preset %q does not appear in its query %q", declared.Name, preset.Name, preset.Query)
+		}
+	}
+	return parameters, nil
+}
+
+func findParameter(parameters []*PresetParameter, name string) *PresetParameter {
+	for _, parameter := range parameters {
+		if strings.EqualFold(parameter.Name, name) {
+			return parameter
+		}
+	}
+	return nil
+}
+
+func (parameter *PresetParameter) missingError(preset *PresetQuery) error {
+	description := ""
+	if parameter.Description != "" {
+		description = fmt.Sprintf(" (%s)", parameter.Description)
+	}
+	return fmt.Errorf("missing value for parameter %q of preset %q%s: pass it as --%s=<%s>", parameter.Name, preset.Name, description, parameter.Name, parameter.Type)
+}
+
+func (parameter *PresetParameter) validate(value string) error {
+	switch parameter.Type {
+	case IntegerParameter:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+	case LabelParameter:
+		if err := validateTargetPattern(value); err != nil {
+			return fmt.Errorf("expected a label: %w", err)
+		}
+		if strings.Contains(value, "...") || strings.HasSuffix(value, ":all") || strings.HasSuffix(value, ":*") || strings.HasSuffix(value, ":all-targets") || strings.HasPrefix(value, "-") {
+			return fmt.Errorf("expected a label, not a target pattern")
+		}
+	case TargetPatternParameter:
+		if err := validateTargetPattern(value); err != nil {
+			return fmt.Errorf("expected a target pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateTargetPattern(value string) error {
+	if value == "" {
+		return fmt.Errorf("value is empty")
+	}
+	if strings.IndexFunc(value, unicode.IsSpace) != -1 {
+		return fmt.Errorf("value contains whitespace")
+	}
+	if strings.Count(value, ":") > 1 {
+		return fmt.Errorf("value contains more than one ':'")
+	}
+	return nil
+}
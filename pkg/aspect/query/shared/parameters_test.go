@@ -0,0 +1,177 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shared
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+var testStreams = ioutils.Streams{Stdout: io.Discard, Stderr: io.Discard}
+
+type promptFunc func() (string, error)
+
+func (p promptFunc) Run() (string, error) {
+	return p()
+}
+
+func noPrompt(label string) PromptRunner {
+	return promptFunc(func() (string, error) {
+		return "", fmt.Errorf("unexpected prompt %q", label)
+	})
+}
+
+func rdepsPreset() *PresetQuery {
+	return &PresetQuery{
+		Name:  "rdeps",
+		Query: "rdeps(?universe, ?target, ?depth)",
+		Verb:  "query",
+		Parameters: []*PresetParameter{
+			{Name: "universe", Type: TargetPatternParameter, Default: "//..."},
+			{Name: "target", Type: LabelParameter, Description: "the target to find the dependents of"},
+			{Name: "depth", Type: IntegerParameter, Default: "1"},
+		},
+	}
+}
+
+func TestExpandPreset(t *testing.T) {
+	t.Run("takes parameters by name", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		query, otherFlags, err := ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps", "--target=//a", "--depth", "2", "--@rules_go//go/config:pure"}, false, noPrompt)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(query).To(Equal("rdeps(//..., //a, 2)"))
+		g.Expect(otherFlags).To(Equal([]string{"--@rules_go//go/config:pure"}))
+	})
+
+	t.Run("takes parameters positionally after the named ones", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		query, _, err := ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps", "--depth=3", "//pkg/...", "//a"}, false, noPrompt)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(query).To(Equal("rdeps(//pkg/..., //a, 3)"))
+	})
+
+	t.Run("replaces placeholders that share a prefix", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		preset := &PresetQuery{Name: "why", Query: "somepath(?target, ?targetB)"}
+		query, _, err := ExpandPreset(preset, testStreams, []string{"why", "--targetB=//b", "--target=//a"}, false, noPrompt)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(query).To(Equal("somepath(//a, //b)"))
+	})
+
+	t.Run("names the missing parameter when not interactive", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, _, err := ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps"}, false, noPrompt)
+		g.Expect(err).To(MatchError(`missing value for parameter "target" of preset "rdeps" (the target to find the dependents of): pass it as --target=<label>`))
+	})
+
+	t.Run("prompts for missing parameters when interactive", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var labels []string
+		prompt := func(label string) PromptRunner {
+			labels = append(labels, label)
+			return promptFunc(func() (string, error) { return "//b", nil })
+		}
+		query, _, err := ExpandPreset(rdepsPreset(), testStreams, []string{}, true, prompt)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(query).To(Equal("rdeps(//..., //b, 1)"))
+		g.Expect(labels).To(Equal([]string{"Value for 'target' (the target to find the dependents of)"}))
+	})
+
+	t.Run("rejects values of the wrong type", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, _, err := ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps", "--target=//a", "--depth=deep"}, false, noPrompt)
+		g.Expect(err).To(MatchError(`invalid value "deep" for parameter "depth" of preset "rdeps": expected an integer`))
+
+		_, _, err = ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps", "--target=//a/..."}, false, noPrompt)
+		g.Expect(err).To(MatchError(`invalid value "//a/..." for parameter "target" of preset "rdeps": expected a label, not a target pattern`))
+
+		_, _, err = ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps", "--target=//a", "--universe=//a b"}, false, noPrompt)
+		g.Expect(err).To(MatchError(`invalid value "//a b" for parameter "universe" of preset "rdeps": expected a target pattern: value contains whitespace`))
+	})
+
+	t.Run("rejects too many arguments", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, _, err := ExpandPreset(rdepsPreset(), testStreams, []string{"rdeps", "//...", "//a", "1", "2"}, false, noPrompt)
+		g.Expect(err).To(MatchError(`preset "rdeps" takes 3 parameters but got 4 arguments`))
+	})
+
+	t.Run("rejects invalid parameter declarations", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		preset := &PresetQuery{Name: "deps", Query: "deps(?target)", Parameters: []*PresetParameter{{Name: "target", Type: "file"}}}
+		_, _, err := ExpandPreset(preset, testStreams, []string{"deps", "//a"}, false, noPrompt)
+		g.Expect(err).To(MatchError(`parameter "target" of preset "deps" has unknown type "file", expected one of string, label, target_pattern or integer`))
+
+		preset = &PresetQuery{Name: "deps", Query: "deps(?target)", Parameters: []*PresetParameter{{Name: "depth"}}}
+		_, _, err = ExpandPreset(preset, testStreams, []string{"deps", "//a"}, false, noPrompt)
+		g.Expect(err).To(MatchError(`parameter "depth" of preset "deps" does not appear in its query "deps(?target)"`))
+	})
+}
+
+func TestPrecannedQueries(t *testing.T) {
+	t.Run("reads parameters and flags of user defined presets", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		cfg, err := os.CreateTemp(t.TempDir(), "config*.yaml")
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = cfg.WriteString(`query:
+  presets:
+    rdeps:
+      description: Get the reverse deps of a target
+      query: rdeps(//..., ?targetLabel, ?depth)
+      verb: query
+      flags: ["--output=label_kind"]
+      parameters:
+        targetLabel:
+          type: label
+          description: the target
+        depth:
+          type: integer
+          default: 1
+`)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(cfg.Close()).To(Succeed())
+
+		v := *viper.New()
+		v.SetConfigFile(cfg.Name())
+		g.Expect(v.ReadInConfig()).To(Succeed())
+
+		presets := PrecannedQueries("query", v)
+		g.Expect(presets).To(HaveLen(3))
+		rdeps := presets[2]
+		g.Expect(rdeps.Name).To(Equal("rdeps"))
+		g.Expect(rdeps.Flags).To(Equal([]string{"--output=label_kind"}))
+
+		query, _, err := ExpandPreset(rdeps, testStreams, []string{"rdeps", "--targetLabel=//a"}, false, noPrompt)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(query).To(Equal("rdeps(//..., //a, 1)"))
+	})
+}
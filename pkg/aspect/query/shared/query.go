@@ -33,13 +33,19 @@ import (
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
 )
 
-var placeholderRegex = regexp.MustCompile(`(\?[a-zA-Z]*)`)
+var placeholderRegex = regexp.MustCompile(`(\?[a-zA-Z][a-zA-Z0-9_]*)`)
 
 type PresetQuery struct {
 	Name        string
 	Description string
 	Query       string
 	Verb        string
+	// Parameters declares the type, default and description of the ?placeholders in Query.
+	// Placeholders without a declaration are untyped and required.
+	Parameters []*PresetParameter
+	// Flags are passed to Bazel before the flags given on the command line, such as a default
+	// --output.
+	Flags []string
 }
 
 type PromptRunner interface {
@@ -81,24 +87,31 @@ func PrecannedQueries(verb string, viper viper.Viper) []*PresetQuery {
 			Description: "Determine why targetA depends on targetB",
 			Query:       "somepath(?targetA, ?targetB)",
 			Verb:        "query",
+			Parameters: []*PresetParameter{
+				{Name: "targetA", Type: TargetPatternParameter, Description: "the dependent target"},
+				{Name: "targetB", Type: TargetPatternParameter, Description: "the dependency"},
+			},
 		},
 		{
 			Name:        "deps",
 			Description: "Get the deps of a target",
 			Query:       "deps(?target)",
 			Verb:        "query",
+			Parameters:  []*PresetParameter{{Name: "target", Type: TargetPatternParameter}},
 		},
 		{
 			Name:        "adeps",
 			Description: "Get the deps of a target",
 			Query:       "deps(?target)",
 			Verb:        "aquery",
+			Parameters:  []*PresetParameter{{Name: "target", Type: TargetPatternParameter}},
 		},
 		{
 			Name:        "cdeps",
 			Description: "Get the deps of a target",
 			Query:       "deps(?target)",
 			Verb:        "cquery",
+			Parameters:  []*PresetParameter{{Name: "target", Type: TargetPatternParameter}},
 		},
 	}
 
@@ -107,13 +120,16 @@ func PrecannedQueries(verb string, viper viper.Viper) []*PresetQuery {
 	userDefinedQueries := viper.GetStringMap(presetsKey)
 
 	for name := range userDefinedQueries {
-		userDefinedQuery := viper.GetStringMapString(fmt.Sprintf("%s.%s", presetsKey, name))
+		presetKey := fmt.Sprintf("%s.%s", presetsKey, name)
+		userDefinedQuery := viper.GetStringMapString(presetKey)
 
 		presetQuery := &PresetQuery{
 			Name:        name,
 			Description: userDefinedQuery["description"],
 			Query:       userDefinedQuery["query"],
 			Verb:        userDefinedQuery["verb"],
+			Parameters:  presetParameters(viper, presetKey),
+			Flags:       viper.GetStringSlice(fmt.Sprintf("%s.flags", presetKey)),
 		}
 
 		presetExists, existingPresetIndex := isPresetQueryInSlice(presetQuery, presets)
@@ -169,32 +185,6 @@ func RunQuery(bzl bazel.Bazel, command string, streams ioutils.Streams, args []s
 	return bzl.RunCommand(streams, nil, bazelCmd...)
 }
 
-func ReplacePlaceholders(query string, args []string, p func(label string) PromptRunner) (string, error) {
-	placeholders := placeholderRegex.FindAllString(query, -1)
-
-	if len(placeholders) == len(args)-1 {
-		for i, placeholder := range placeholders {
-			fmt.Printf("%s set to %s\n", strings.Replace(placeholder, "?", "", 1), args[i+1])
-			// todo.... Print out targetA was set to //foo and targetB was set to //bar
-			query = strings.ReplaceAll(query, placeholder, args[i+1])
-		}
-	} else if len(placeholders) > 0 {
-		for _, placeholder := range placeholders {
-			label := fmt.Sprintf("Value for '%s'", strings.TrimPrefix(placeholder, "?"))
-			prompt := p(label)
-			val, err := prompt.Run()
-
-			if err != nil {
-				return "", err
-			}
-
-			query = strings.ReplaceAll(query, placeholder, val)
-		}
-	}
-
-	return query, nil
-}
-
 func SelectQuery(
 	verb string,
 	processedPresets map[string]*PresetQuery,
@@ -204,7 +194,7 @@ func SelectQuery(
 	args []string,
 	flags []string,
 	s func(presetNames []string) SelectRunner,
-) (string, string, bool, error) {
+) (string, string, *PresetQuery, error) {
 
 	hasQueryFile := false
 	for _, flag := range flags {
@@ -221,7 +211,7 @@ func SelectQuery(
 		i, _, err := selectQueryPrompt.Run()
 
 		if err != nil {
-			return verb, "", false, err
+			return verb, "", nil, err
 		}
 
 		preset = rawPresets[i]
@@ -237,16 +227,16 @@ func SelectQuery(
 			preset = value
 		} else {
 			// Treat this as a raw query expression.
-			return verb, maybeQueryOrPreset, false, nil
+			return verb, maybeQueryOrPreset, nil, nil
 		}
 	}
 
 	if preset == nil {
 		err := fmt.Errorf("unable to determine preset query")
-		return verb, "", false, err
+		return verb, "", nil, err
 	}
 
-	return preset.Verb, preset.Query, true, nil
+	return preset.Verb, preset.Query, preset, nil
 }
 
 func GetPrettyError(cmd *cobra.Command, err error) error {
@@ -0,0 +1,302 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package run
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+)
+
+const (
+	// MultiFlagName runs every target given at the same time, rather than
+	// passing the arguments that follow the first target to its program.
+	MultiFlagName = "run:multi"
+
+	// KeepRunningFlagName keeps the other programs running when one of several
+	// targets run at the same time fails.
+	KeepRunningFlagName = "run:keep_running"
+
+	// stopTimeout is how long a program has to exit after SIGTERM before it's
+	// killed.
+	stopTimeout = 5 * time.Second
+
+	// maxLineLength is the length after which a line that isn't finished yet is
+	// written anyway.
+	maxLineLength = 64 * 1024
+)
+
+// labelColors are the colors of the labels that prefix the output of the
+// programs.
+var labelColors = []*color.Color{
+	color.New(color.FgCyan),
+	color.New(color.FgMagenta),
+	color.New(color.FgYellow),
+	color.New(color.FgGreen),
+	color.New(color.FgBlue),
+	color.New(color.FgRed),
+}
+
+// multipleTargets returns the targets and the Bazel flags of the arguments
+// with --run:multi, which are every argument that isn't a flag. Arguments can't
+// be passed to the programs.
+func multipleTargets(args []string) ([]string, []string, error) {
+	before, after := args, []string(nil)
+	if i := slices.Index(args, "--"); i >= 0 {
+		before, after = args[:i], args[i+1:]
+	}
+	if len(after) > 0 {
+		return nil, nil, fmt.Errorf("arguments can't be passed to the programs with --%s", MultiFlagName)
+	}
+	targets, bazelFlags, err := bazel.SeparateBazelFlags("run", before)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("--%s requires at least one target", MultiFlagName)
+	}
+	return targets, bazelFlags, nil
+}
+
+// runMultiple runs the programs of the targets at the same time. This takes
+// N+1 Bazel invocations for N targets: a `bazel build` of every target, then a
+// `bazel run --script_path` per target, one after the other, to write the
+// script that runs its program. Only the build sends its events to the BES
+// backend and plugins; the runs don't. The runs don't build anything again, but
+// they analyze the target again when the bazelrc has options for run that
+// change the configuration.
+func (runner *Run) runMultiple(ctx context.Context, cmd *cobra.Command, targets []string, bazelFlags []string, keepRunning bool) error {
+	for _, arg := range bazelFlags {
+		if arg == "--script_path" || strings.HasPrefix(arg, "--script_path=") {
+			return fmt.Errorf("--script_path can't be used with --%s", MultiFlagName)
+		}
+	}
+
+	bazelCmd := []string{"build"}
+	bazelCmd = append(bazelCmd, bazelFlags...)
+	bazelCmd = append(bazelCmd, targets...)
+	if bep.HasBESBackend(ctx) {
+		besBackend := bep.BESBackendFromContext(ctx)
+		besBackendFlag := fmt.Sprintf("--bes_backend=%s", besBackend.Addr())
+		bazelCmd = flags.AddFlagToCommand(bazelCmd, besBackendFlag)
+	}
+	bzlCommandStreams, err := runner.commandStreams(cmd)
+	if err != nil {
+		return err
+	}
+	err = runner.bzl.RunCommand(bzlCommandStreams, nil, bazelCmd...)
+	if err = runner.checkSubscriberErrors(ctx, err); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "aspect-run-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// The targets are already built, so each `bazel run` only writes the script
+	// of its target. The BES backend only takes the stream of one invocation,
+	// so these don't send their build events to it.
+	scripts := make([]string, len(targets))
+	for i, target := range targets {
+		scripts[i] = filepath.Join(dir, fmt.Sprintf("run_%d.sh", i))
+		var stderr bytes.Buffer
+		streams := ioutils.Streams{Stdin: runner.streams.Stdin, Stdout: runner.streams.Stdout, Stderr: &stderr}
+		runCmd := []string{"run"}
+		runCmd = append(runCmd, bazelFlags...)
+		runCmd = append(runCmd, "--script_path="+scripts[i], target)
+		if err := runner.bzl.RunCommand(streams, nil, runCmd...); err != nil {
+			runner.streams.Stderr.Write(stderr.Bytes())
+			return err
+		}
+	}
+
+	// Ctrl+C stops the programs. They get the signal too since they are in the
+	// same process group, and are killed if they don't exit in time.
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return runPrograms(ctx, runner.streams, targets, scripts, keepRunning)
+}
+
+// program is the program of one of the targets run at the same time.
+type program struct {
+	label  string
+	cmd    *exec.Cmd
+	err    error
+	exited bool
+}
+
+// runPrograms runs the scripts at the same time, with their output prefixed
+// with their labels, until they all exit. Unless keepRunning is set, the other
+// programs are stopped when one of them fails. It returns an exit error with
+// the exit code of the first program that failed.
+func runPrograms(ctx context.Context, streams ioutils.Streams, labels []string, scripts []string, keepRunning bool) error {
+	width := 0
+	for _, label := range labels {
+		width = max(width, len(label))
+	}
+
+	// The lines of all programs are written one at a time.
+	var mu sync.Mutex
+	exits := make(chan *program, len(labels))
+	programs := make([]*program, 0, len(labels))
+	var failure error
+	stopping := false
+	var kill <-chan time.Time
+	stopAll := func() {
+		stopping = true
+		for _, p := range programs {
+			if !p.exited {
+				if err := p.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+					_ = p.cmd.Process.Kill()
+				}
+			}
+		}
+		kill = time.After(stopTimeout)
+	}
+
+	for i, label := range labels {
+		prefix := labelColors[i%len(labelColors)].Sprintf("%-*s |", width, label) + " "
+		stdout := &prefixWriter{w: streams.Stdout, prefix: prefix, mu: &mu}
+		stderr := &prefixWriter{w: streams.Stderr, prefix: prefix, mu: &mu}
+		p := &program{label: label, cmd: exec.Command(scripts[i])}
+		p.cmd.Stdout = stdout
+		p.cmd.Stderr = stderr
+		if err := p.cmd.Start(); err != nil {
+			failure = fmt.Errorf("failed to run %s: %w", label, err)
+			break
+		}
+		programs = append(programs, p)
+		go func() {
+			p.err = p.cmd.Wait()
+			stdout.flush()
+			stderr.flush()
+			exits <- p
+		}()
+	}
+	if failure != nil {
+		stopAll()
+	}
+
+	done := ctx.Done()
+	for remaining := len(programs); remaining > 0; {
+		select {
+		case <-done:
+			done = nil
+			if !stopping {
+				stopAll()
+			}
+		case <-kill:
+			for _, p := range programs {
+				if !p.exited {
+					_ = p.cmd.Process.Kill()
+				}
+			}
+		case p := <-exits:
+			remaining--
+			p.exited = true
+			if stopping {
+				continue
+			}
+			mu.Lock()
+			if p.err == nil {
+				fmt.Fprintf(streams.Stderr, "%s exited\n", p.label)
+			} else {
+				fmt.Fprintf(streams.Stderr, "%s exited: %v\n", p.label, p.err)
+			}
+			mu.Unlock()
+			if p.err == nil {
+				continue
+			}
+			if failure == nil {
+				failure = p.err
+				var exitErr *exec.ExitError
+				if errors.As(p.err, &exitErr) && exitErr.ExitCode() > 0 {
+					failure = &aspecterrors.ExitError{
+						Err:      fmt.Errorf("%s exited with code %d", p.label, exitErr.ExitCode()),
+						ExitCode: exitErr.ExitCode(),
+					}
+				}
+			}
+			if !keepRunning {
+				stopAll()
+			}
+		}
+	}
+	return failure
+}
+
+// prefixWriter writes the output of a program prefixed with its label. Only
+// whole lines are written, so that the lines of programs running at the same
+// time don't interleave.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		pw.writeLine(pw.buf[:i+1])
+		pw.buf = pw.buf[i+1:]
+	}
+	if len(pw.buf) > maxLineLength {
+		pw.flush()
+	}
+	return len(p), nil
+}
+
+// flush writes the last line when it isn't finished.
+func (pw *prefixWriter) flush() {
+	if len(pw.buf) > 0 {
+		pw.writeLine(append(pw.buf, '\n'))
+		pw.buf = nil
+	}
+}
+
+func (pw *prefixWriter) writeLine(line []byte) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	fmt.Fprintf(pw.w, "%s%s", pw.prefix, line)
+}
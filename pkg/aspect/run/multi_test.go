@@ -0,0 +1,161 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	. "github.com/onsi/gomega"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// syncBuffer is written to by the programs running at the same time.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func writeScripts(t *testing.T, bodies ...string) []string {
+	dir := t.TempDir()
+	scripts := make([]string, len(bodies))
+	for i, body := range bodies {
+		scripts[i] = filepath.Join(dir, fmt.Sprintf("run_%d.sh", i))
+		if err := os.WriteFile(scripts[i], []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return scripts
+}
+
+func TestMultipleTargets(t *testing.T) {
+	t.Run("returns the targets", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		targets, _, err := multipleTargets([]string{"//svc/a", "@other//svc:b", ":c"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(targets).To(Equal([]string{"//svc/a", "@other//svc:b", ":c"}))
+	})
+
+	t.Run("fails on arguments for the programs", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, _, err := multipleTargets([]string{"//svc/a", "//svc/b", "--", "--port=80"})
+		g.Expect(err).To(MatchError("arguments can't be passed to the programs with --run:multi"))
+	})
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Run("prefixes whole lines", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out strings.Builder
+		pw := &prefixWriter{w: &out, prefix: "//a | ", mu: &sync.Mutex{}}
+		pw.Write([]byte("one\ntw"))
+		g.Expect(out.String()).To(Equal("//a | one\n"))
+		pw.Write([]byte("o\nthree"))
+		pw.flush()
+		g.Expect(out.String()).To(Equal("//a | one\n//a | two\n//a | three\n"))
+	})
+}
+
+func TestRunPrograms(t *testing.T) {
+	color.NoColor = true
+
+	t.Run("runs the programs at the same time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		// Each program waits for the other one to start.
+		dir := t.TempDir()
+		scripts := writeScripts(t,
+			fmt.Sprintf("touch %s/a; while [ ! -e %s/b ]; do sleep 0.01; done; echo a", dir, dir),
+			fmt.Sprintf("touch %s/b; while [ ! -e %s/a ]; do sleep 0.01; done; echo b >&2", dir, dir),
+		)
+
+		var stdout, stderr syncBuffer
+		err := runPrograms(context.Background(), ioutils.Streams{Stdout: &stdout, Stderr: &stderr}, []string{"//a", "//svc:b"}, scripts, false)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(stdout.String()).To(Equal("//a     | a\n"))
+		g.Expect(stderr.String()).To(ContainSubstring("//svc:b | b\n"))
+		g.Expect(stderr.String()).To(ContainSubstring("//a exited\n"))
+		g.Expect(stderr.String()).To(ContainSubstring("//svc:b exited\n"))
+	})
+
+	t.Run("stops the other programs when one fails", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		scripts := writeScripts(t, "exec sleep 60", "exit 3")
+
+		var stderr syncBuffer
+		started := time.Now()
+		err := runPrograms(context.Background(), ioutils.Streams{Stdout: &syncBuffer{}, Stderr: &stderr}, []string{"//a", "//b"}, scripts, false)
+
+		var exitErr *aspecterrors.ExitError
+		g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+		g.Expect(exitErr.ExitCode).To(Equal(3))
+		g.Expect(exitErr.Err).To(MatchError("//b exited with code 3"))
+		g.Expect(time.Since(started)).To(BeNumerically("<", stopTimeout))
+		g.Expect(stderr.String()).To(Equal("//b exited: exit status 3\n"))
+	})
+
+	t.Run("keeps the other programs running when one fails", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		scripts := writeScripts(t, "sleep 0.2; echo done", "exit 3")
+
+		var stdout syncBuffer
+		err := runPrograms(context.Background(), ioutils.Streams{Stdout: &stdout, Stderr: &syncBuffer{}}, []string{"//a", "//b"}, scripts, true)
+
+		var exitErr *aspecterrors.ExitError
+		g.Expect(errors.As(err, &exitErr)).To(BeTrue())
+		g.Expect(exitErr.ExitCode).To(Equal(3))
+		g.Expect(stdout.String()).To(Equal("//a | done\n"))
+	})
+
+	t.Run("stops the programs when interrupted", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		scripts := writeScripts(t, "exec sleep 60", "exec sleep 60")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := runPrograms(ctx, ioutils.Streams{Stdout: &syncBuffer{}, Stderr: &syncBuffer{}}, []string{"//a", "//b"}, scripts, false)
+		g.Expect(err).NotTo(HaveOccurred())
+	})
+}
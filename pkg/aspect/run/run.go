@@ -21,10 +21,12 @@ import (
 	"fmt"
 
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
 	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // Run represents the aspect run command.
@@ -49,36 +51,73 @@ func New(
 
 // Run runs the aspect run command, calling `bazel run` with a local Build
 // Event Protocol backend used by Aspect plugins to subscribe to build events.
+// With --run:multi, every target given is built and their programs run at the
+// same time.
 func (runner *Run) Run(ctx context.Context, cmd *cobra.Command, args []string) (exitErr error) {
+	multi, keepRunning, watching := false, false, false
+	var flagSet *pflag.FlagSet
+	if cmd != nil {
+		flagSet = cmd.Flags()
+		multi, _ = flagSet.GetBool(MultiFlagName)
+		keepRunning, _ = flagSet.GetBool(KeepRunningFlagName)
+		watching, _ = flagSet.GetBool(watch.FlagName)
+	}
+	args = bazel.RemoveFlags("run", flagSet, args, MultiFlagName, KeepRunningFlagName, watch.FlagName)
+	if multi {
+		if watching {
+			return fmt.Errorf("--%s can't be used with --%s", watch.FlagName, MultiFlagName)
+		}
+		targets, bazelFlags, err := multipleTargets(args)
+		if err != nil {
+			return err
+		}
+		return runner.runMultiple(ctx, cmd, targets, bazelFlags, keepRunning)
+	}
+
 	bazelCmd := []string{"run"}
 	bazelCmd = append(bazelCmd, args...)
 
 	// Currently Bazel only supports a single --bes_backend so adding ours after
 	// any user supplied value will result in our bes_backend taking precedence.
-	// There is a very old & stale issue to add support for multiple BES
-	// backends https://github.com/bazelbuild/bazel/issues/10908. In the future,
-	// we could build this support into the Aspect CLI and post on that issue
-	// that using the Aspect CLI resolves it.
+	// The user's --bes_backend, whether from the command line or a bazelrc, is
+	// still honored: the BES backend reads the one in effect from the
+	// OptionsParsed event and forwards the stream to it through a proxy, along
+	// with any bes.backends of the config.yaml.
+	// See https://github.com/bazelbuild/bazel/issues/10908.
 	if bep.HasBESBackend(ctx) {
 		besBackend := bep.BESBackendFromContext(ctx)
 		besBackendFlag := fmt.Sprintf("--bes_backend=%s", besBackend.Addr())
 		bazelCmd = flags.AddFlagToCommand(bazelCmd, besBackendFlag)
 	}
 
-	bzlCommandStreams := runner.streams
+	bzlCommandStreams, err := runner.commandStreams(cmd)
+	if err != nil {
+		return err
+	}
+
+	err = runner.bzl.RunCommand(bzlCommandStreams, nil, bazelCmd...)
+
+	return runner.checkSubscriberErrors(ctx, err)
+}
+
+// commandStreams returns the streams of the Bazel command, which go through
+// the hints when they are enabled.
+func (runner *Run) commandStreams(cmd *cobra.Command) (ioutils.Streams, error) {
 	if cmd != nil {
 		hints, err := cmd.Root().PersistentFlags().GetBool(flags.AspectHintsFlagName)
 		if err != nil {
-			return err
+			return ioutils.Streams{}, err
 		}
 		if hints {
-			bzlCommandStreams = runner.hstreams
+			return runner.hstreams, nil
 		}
 	}
+	return runner.streams, nil
+}
 
-	err := runner.bzl.RunCommand(bzlCommandStreams, nil, bazelCmd...)
-
-	// Check for subscriber errors
+// checkSubscriberErrors prints the errors of the BES subscribers, and returns
+// an error for them unless the Bazel command already failed.
+func (runner *Run) checkSubscriberErrors(ctx context.Context, err error) error {
 	subscriberErrors := bep.BESErrors(ctx)
 	if len(subscriberErrors) > 0 {
 		for _, err := range subscriberErrors {
@@ -88,6 +127,5 @@ func (runner *Run) Run(ctx context.Context, cmd *cobra.Command, args []string) (
 			err = fmt.Errorf("%v BES subscriber error(s)", len(subscriberErrors))
 		}
 	}
-
 	return err
 }
@@ -19,13 +19,18 @@ package run_test
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 
+	"github.com/fatih/color"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
 
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/run"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/watch"
 	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
 	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
@@ -33,6 +38,19 @@ import (
 	bep_mock "github.com/aspect-build/aspect-cli/pkg/plugin/system/bep/mock"
 )
 
+// multiCmd returns a run command with --run:multi set.
+func multiCmd(t *testing.T) *cobra.Command {
+	cmd := &cobra.Command{Use: "run"}
+	cmd.PersistentFlags().Bool(flags.AspectHintsFlagName, false, "")
+	cmd.Flags().Bool(run.MultiFlagName, false, "")
+	cmd.Flags().Bool(run.KeepRunningFlagName, false, "")
+	cmd.Flags().Bool(watch.FlagName, false, "")
+	if err := cmd.Flags().Set(run.MultiFlagName, "true"); err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
 func TestRun(t *testing.T) {
 	t.Run("when the bazel runner fails, the aspect run fails", func(t *testing.T) {
 		g := NewGomegaWithT(t)
@@ -135,4 +153,80 @@ func TestRun(t *testing.T) {
 
 		g.Expect(err).To(BeNil())
 	})
+	t.Run("without --run:multi, the arguments after the target are passed to its program", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		streams := ioutils.Streams{}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "run", "//tools:deploy", "//services/api").
+			Return(nil)
+
+		b := run.New(streams, streams, bzl)
+		err := b.Run(context.Background(), nil, []string{"//tools:deploy", "//services/api"})
+
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("with --run:multi, the targets are built together and run at the same time", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		color.NoColor = true
+		var stdout strings.Builder
+		streams := ioutils.Streams{Stdout: &stdout, Stderr: &strings.Builder{}}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "build", "//svc/a", "//svc/b", "--bes_backend=grpc://127.0.0.1:12345").
+			Return(nil)
+		for _, target := range []string{"//svc/a", "//svc/b"} {
+			bzl.
+				EXPECT().
+				RunCommand(gomock.Any(), nil, "run", gomock.Any(), target).
+				DoAndReturn(func(_ ioutils.Streams, _ *string, args ...string) error {
+					// Like `bazel run --script_path`, write a script that runs the program.
+					g.Expect(args[1]).To(HavePrefix("--script_path="))
+					script := strings.TrimPrefix(args[1], "--script_path=")
+					return os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\necho %s\n", target)), 0755)
+				})
+		}
+		besBackend := bep_mock.NewMockBESBackend(ctrl)
+		besBackend.
+			EXPECT().
+			Addr().
+			Return("grpc://127.0.0.1:12345").
+			Times(1)
+		besBackend.
+			EXPECT().
+			Errors().
+			Times(1)
+
+		ctx := bep.InjectBESBackend(context.Background(), besBackend)
+
+		b := run.New(streams, streams, bzl)
+		err := b.Run(ctx, multiCmd(t), []string{"//svc/a", "//svc/b"})
+
+		g.Expect(err).To(BeNil())
+		g.Expect(stdout.String()).To(ContainSubstring("//svc/a | //svc/a\n"))
+		g.Expect(stdout.String()).To(ContainSubstring("//svc/b | //svc/b\n"))
+	})
+	t.Run("several targets can't be watched", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		cmd := multiCmd(t)
+		g.Expect(cmd.Flags().Set(watch.FlagName, "true")).To(Succeed())
+
+		streams := ioutils.Streams{Stdout: &strings.Builder{}, Stderr: &strings.Builder{}}
+		b := run.New(streams, streams, bazel_mock.NewMockBazel(ctrl))
+		err := b.Run(context.Background(), cmd, []string{"//svc/a", "--run:multi", "--watch", "--run:keep_running", "//svc/b"})
+
+		g.Expect(err).To(MatchError("--watch can't be used with --run:multi"))
+	})
 }
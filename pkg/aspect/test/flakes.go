@@ -0,0 +1,165 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// WarnKnownFlakyConfigKey is the Aspect CLI config.yaml setting that enables
+// a warning naming the failed tests that are known to be flaky.
+const WarnKnownFlakyConfigKey = "test.warn_known_flaky"
+
+// Flakes represents the aspect history flakes command.
+type Flakes struct {
+	ioutils.Streams
+	bzl       bazel.Bazel
+	openStore func() (*history.Store, error)
+}
+
+// NewFlakes creates a Flakes command.
+func NewFlakes(streams ioutils.Streams, bzl bazel.Bazel) *Flakes {
+	return &Flakes{
+		Streams:   streams,
+		bzl:       bzl,
+		openStore: history.NewDefaultStore,
+	}
+}
+
+// Run prints the tests of the workspace that both passed and failed on the
+// same inputs in one of the recorded invocations.
+func (runner *Flakes) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	store, err := runner.openStore()
+	if err != nil {
+		return err
+	}
+	tests, err := store.TestHistory(runner.bzl.WorkspaceRoot())
+	if err != nil {
+		return err
+	}
+	flakes := tests.Flakes()
+	if len(flakes) == 0 {
+		fmt.Fprintln(runner.Stdout, "No flaky tests have been recorded.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(runner.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tFLAKY\tLAST FLAKY\tLAST STATUS")
+	for _, r := range flakes {
+		flaky := r.FlakyRuns()
+		last := r.Runs[len(r.Runs)-1]
+		fmt.Fprintf(w, "%s\t%d of %d invocations\t%s\t%s\n",
+			r.Label,
+			len(flaky),
+			len(r.Runs),
+			flaky[len(flaky)-1].Time.Local().Format(time.DateTime),
+			last.Status,
+		)
+	}
+	return w.Flush()
+}
+
+// flakyTestWarning collects the tests that failed during an invocation so
+// that the known-flaky ones can be named once it completes.
+type flakyTestWarning struct {
+	*buildCompletion
+	openStore func() (*history.Store, error)
+
+	mutex        sync.Mutex
+	invocationID string
+	workspace    string
+	failed       []string
+}
+
+func newFlakyTestWarning(openStore func() (*history.Store, error)) *flakyTestWarning {
+	return &flakyTestWarning{
+		buildCompletion: newBuildCompletion(),
+		openStore:       openStore,
+	}
+}
+
+func (f *flakyTestWarning) bepEventCallback(event *buildeventstream.BuildEvent, _ int64) error {
+	f.mutex.Lock()
+	switch {
+	case event.GetStarted() != nil:
+		f.invocationID = event.GetStarted().GetUuid()
+		f.workspace = event.GetStarted().GetWorkspaceDirectory()
+	case event.GetTestSummary() != nil:
+		switch event.GetTestSummary().GetOverallStatus() {
+		case buildeventstream.TestStatus_FAILED, buildeventstream.TestStatus_TIMEOUT:
+			f.failed = append(f.failed, event.GetId().GetTestSummary().GetLabel())
+		}
+	}
+	f.mutex.Unlock()
+	f.observe(event)
+	return nil
+}
+
+// print writes a warning naming the failed tests that were flaky in an
+// earlier invocation.
+func (f *flakyTestWarning) print(w io.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(f.failed) == 0 {
+		return
+	}
+	store, err := f.openStore()
+	if err != nil {
+		return
+	}
+	tests, err := store.TestHistory(f.workspace)
+	if err != nil {
+		return
+	}
+
+	known := []string{}
+	for _, label := range f.failed {
+		record, ok := tests[label]
+		if !ok {
+			continue
+		}
+		flaky := 0
+		for _, run := range record.FlakyRuns() {
+			if run.InvocationID != f.invocationID {
+				flaky++
+			}
+		}
+		if flaky > 0 {
+			known = append(known, fmt.Sprintf("  %s (flaky in %d of the last %d invocations)", label, flaky, len(record.Runs)))
+		}
+	}
+	if len(known) == 0 {
+		return
+	}
+	sort.Strings(known)
+	fmt.Fprintf(w, "%s the following failed tests are known to be flaky:\n%s\n", color.YellowString("WARNING:"), strings.Join(known, "\n"))
+}
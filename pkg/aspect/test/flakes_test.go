@@ -0,0 +1,137 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+func started(id string, start time.Time) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Started{Started: &buildeventstream.BuildEventId_BuildStartedId{}}},
+		Payload: &buildeventstream.BuildEvent_Started{Started: &buildeventstream.BuildStarted{
+			Uuid:      id,
+			Command:   "test",
+			StartTime: timestamppb.New(start),
+		}},
+	}
+}
+
+func testSummary(label string, status buildeventstream.TestStatus) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TestSummary{TestSummary: &buildeventstream.BuildEventId_TestSummaryId{Label: label}}},
+		Payload: &buildeventstream.BuildEvent_TestSummary{TestSummary: &buildeventstream.TestSummary{OverallStatus: status}},
+	}
+}
+
+func finished() *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id:          &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_BuildFinished{BuildFinished: &buildeventstream.BuildEventId_BuildFinishedId{}}},
+		Payload:     &buildeventstream.BuildEvent_Finished{Finished: &buildeventstream.BuildFinished{}},
+		LastMessage: true,
+	}
+}
+
+// flakyStore returns a store in which //:flaky was flaky in one of two
+// invocations.
+func flakyStore(t *testing.T) *history.Store {
+	store := history.NewStore(t.TempDir())
+	start := time.Now().Add(-time.Hour)
+	for i, events := range [][]*buildeventstream.BuildEvent{
+		{started("inv-1", start), testSummary("//:flaky", buildeventstream.TestStatus_FLAKY), testSummary("//:stable", buildeventstream.TestStatus_PASSED), finished()},
+		{started("inv-2", start.Add(time.Minute)), testSummary("//:flaky", buildeventstream.TestStatus_PASSED), testSummary("//:stable", buildeventstream.TestStatus_FAILED), finished()},
+	} {
+		r := history.NewRecorder(store, history.Retention{})
+		for seq, e := range events {
+			if err := r.Callback(e, int64(seq)); err != nil {
+				t.Fatalf("invocation %d: %v", i, err)
+			}
+		}
+	}
+	return store
+}
+
+func TestFlakes(t *testing.T) {
+	t.Run("lists the flaky tests", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := flakyStore(t)
+		var stdout strings.Builder
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return("")
+		flakes := NewFlakes(ioutils.Streams{Stdout: &stdout}, bzl)
+		flakes.openStore = func() (*history.Store, error) { return store, nil }
+
+		g.Expect(flakes.Run(context.Background(), nil, []string{})).To(Succeed())
+		g.Expect(stdout.String()).To(MatchRegexp(`//:flaky\s+1 of 2 invocations\s+.*\s+PASSED`))
+		g.Expect(stdout.String()).NotTo(ContainSubstring("//:stable"))
+	})
+
+	t.Run("warns when known flaky tests fail", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := flakyStore(t)
+		warning := newFlakyTestWarning(func() (*history.Store, error) { return store, nil })
+		for seq, e := range []*buildeventstream.BuildEvent{
+			started("inv-3", time.Now()),
+			testSummary("//:flaky", buildeventstream.TestStatus_FAILED),
+			testSummary("//:stable", buildeventstream.TestStatus_FAILED),
+			finished(),
+		} {
+			g.Expect(warning.bepEventCallback(e, int64(seq))).To(Succeed())
+		}
+
+		var stderr strings.Builder
+		g.Expect(warning.wait(time.Second)).To(Succeed())
+		warning.print(&stderr)
+		g.Expect(stderr.String()).To(ContainSubstring("the following failed tests are known to be flaky:\n  //:flaky (flaky in 1 of the last 2 invocations)\n"))
+		g.Expect(stderr.String()).NotTo(ContainSubstring("//:stable"))
+	})
+}
+
+func TestBuildCompletion(t *testing.T) {
+	t.Run("waits for the build finished event", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		c := newBuildCompletion()
+		c.observe(started("inv-1", time.Now()))
+		g.Expect(c.wait(time.Millisecond)).To(MatchError("timed out waiting for the build finished event"))
+
+		go c.observe(finished())
+		g.Expect(c.wait(time.Minute)).To(Succeed())
+	})
+
+	t.Run("doesn't wait when no build was started", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(newBuildCompletion().wait(time.Minute)).To(Succeed())
+	})
+}
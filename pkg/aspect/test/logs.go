@@ -0,0 +1,348 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+)
+
+const (
+	// FailedLogsFlagName prints the tail of the test.log of each failed test
+	// once the tests finish.
+	FailedLogsFlagName = "failed-logs"
+	// FailedLogsLinesFlagName is the number of lines printed from each log.
+	FailedLogsLinesFlagName = "failed-logs:lines"
+	// FailedLogsTestCasesFlagName prints the failed test cases from test.xml
+	// instead of the tail of test.log when they are known.
+	FailedLogsTestCasesFlagName = "failed-logs:test_cases"
+)
+
+// failedTestLogs collects the logs of the failed test attempts during an
+// invocation so that they can be printed, grouped by target, once it
+// completes.
+type failedTestLogs struct {
+	*buildCompletion
+	workspaceRoot string
+	lines         int
+	testCases     bool
+
+	mutex         sync.Mutex
+	localExecRoot string
+	attempts      map[string][]*failedAttempt
+	summaries     map[string]*buildeventstream.TestSummary
+}
+
+// failedAttempt is a failed attempt to run a shard of a test target.
+type failedAttempt struct {
+	id      *buildeventstream.BuildEventId_TestResultId
+	status  buildeventstream.TestStatus
+	log     *buildeventstream.File
+	testXML *buildeventstream.File
+}
+
+func newFailedTestLogs(workspaceRoot string, lines int, testCases bool) *failedTestLogs {
+	return &failedTestLogs{
+		buildCompletion: newBuildCompletion(),
+		workspaceRoot:   workspaceRoot,
+		lines:           lines,
+		testCases:       testCases,
+		attempts:        map[string][]*failedAttempt{},
+		summaries:       map[string]*buildeventstream.TestSummary{},
+	}
+}
+
+func (f *failedTestLogs) bepEventCallback(event *buildeventstream.BuildEvent, _ int64) error {
+	defer f.observe(event)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	id := event.GetId()
+	switch {
+	case event.GetWorkspaceInfo() != nil:
+		f.localExecRoot = event.GetWorkspaceInfo().GetLocalExecRoot()
+	case id.GetTestResult() != nil:
+		switch event.GetTestResult().GetStatus() {
+		case buildeventstream.TestStatus_FAILED, buildeventstream.TestStatus_TIMEOUT:
+			attempt := &failedAttempt{id: id.GetTestResult(), status: event.GetTestResult().GetStatus()}
+			for _, file := range event.GetTestResult().GetTestActionOutput() {
+				switch file.GetName() {
+				case "test.log":
+					attempt.log = file
+				case "test.xml":
+					attempt.testXML = file
+				}
+			}
+			label := id.GetTestResult().GetLabel()
+			f.attempts[label] = append(f.attempts[label], attempt)
+		}
+	case id.GetTestSummary() != nil:
+		f.summaries[id.GetTestSummary().GetLabel()] = event.GetTestSummary()
+	}
+	return nil
+}
+
+// print writes the failed test cases or the tail of the log of the last
+// failed attempt of every shard of the tests whose overall status is FAILED or
+// TIMEOUT.
+func (f *failedTestLogs) print(w io.Writer) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	labels := []string{}
+	for label, summary := range f.summaries {
+		switch summary.GetOverallStatus() {
+		case buildeventstream.TestStatus_FAILED, buildeventstream.TestStatus_TIMEOUT:
+			if len(f.attempts[label]) > 0 {
+				labels = append(labels, label)
+			}
+		}
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		summary := f.summaries[label]
+		for _, attempt := range lastAttempts(f.attempts[label]) {
+			fmt.Fprintf(w, "%s %s%s\n", color.RedString("%s:", attempt.status), label, describeAttempt(attempt.id, summary))
+			if f.testCases && f.printTestCases(w, attempt) {
+				continue
+			}
+			f.printLog(w, attempt)
+		}
+	}
+}
+
+// lastAttempts returns the last failed attempt of every run of every shard.
+func lastAttempts(attempts []*failedAttempt) []*failedAttempt {
+	type key struct{ run, shard int32 }
+	last := map[key]*failedAttempt{}
+	keys := []key{}
+	for _, a := range attempts {
+		k := key{a.id.GetRun(), a.id.GetShard()}
+		if _, ok := last[k]; !ok {
+			keys = append(keys, k)
+		}
+		if prev, ok := last[k]; !ok || a.id.GetAttempt() >= prev.id.GetAttempt() {
+			last[k] = a
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].run != keys[j].run {
+			return keys[i].run < keys[j].run
+		}
+		return keys[i].shard < keys[j].shard
+	})
+	result := make([]*failedAttempt, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, last[k])
+	}
+	return result
+}
+
+// describeAttempt names the run, shard and attempt when the test has more than
+// one of them.
+func describeAttempt(id *buildeventstream.BuildEventId_TestResultId, summary *buildeventstream.TestSummary) string {
+	parts := []string{}
+	if summary.GetRunCount() > 1 {
+		parts = append(parts, fmt.Sprintf("run %d of %d", id.GetRun(), summary.GetRunCount()))
+	}
+	if summary.GetShardCount() > 1 {
+		parts = append(parts, fmt.Sprintf("shard %d of %d", id.GetShard(), summary.GetShardCount()))
+	}
+	if id.GetAttempt() > 1 {
+		parts = append(parts, fmt.Sprintf("attempt %d", id.GetAttempt()))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// printTestCases writes the failed test cases of the attempt, returning false
+// when they aren't known.
+func (f *failedTestLogs) printTestCases(w io.Writer, attempt *failedAttempt) bool {
+	if attempt.testXML == nil {
+		return false
+	}
+	path, err := bep.LocalFilePath(attempt.testXML, f.workspaceRoot, f.localExecRoot)
+	if err != nil {
+		return false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	failures, err := parseTestCaseFailures(b)
+	if err != nil || len(failures) == 0 {
+		return false
+	}
+	for _, failure := range failures {
+		fmt.Fprintf(w, "  %s %s", color.RedString("✗"), failure.qualifiedName())
+		if failure.message != "" {
+			fmt.Fprintf(w, ": %s", failure.message)
+		}
+		fmt.Fprintln(w)
+		lines := strings.Split(strings.TrimSpace(failure.text), "\n")
+		if len(lines) > f.lines {
+			lines = lines[len(lines)-f.lines:]
+		}
+		for _, line := range lines {
+			if line != "" {
+				fmt.Fprintf(w, "      %s\n", line)
+			}
+		}
+	}
+	return true
+}
+
+// printLog writes the last lines of the test.log of the attempt.
+func (f *failedTestLogs) printLog(w io.Writer, attempt *failedAttempt) {
+	if attempt.log == nil {
+		fmt.Fprintf(w, "  The test.log was not reported\n")
+		return
+	}
+	path, err := bep.LocalFilePath(attempt.log, f.workspaceRoot, f.localExecRoot)
+	if err != nil {
+		fmt.Fprintf(w, "  The test.log is not available locally: %v\n", err)
+		return
+	}
+	lines, truncated, err := tailLines(path, f.lines)
+	if err != nil {
+		fmt.Fprintf(w, "  Failed to read %s: %v\n", path, err)
+		return
+	}
+	if truncated {
+		fmt.Fprintf(w, "  Last %d lines of %s:\n", len(lines), path)
+	} else {
+		fmt.Fprintf(w, "  %s:\n", path)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// tailChunkSize is how much of a log is read at once, from the end, to find
+// its last lines.
+const tailChunkSize = 64 * 1024
+
+// tailLines returns the last n lines of a file, and whether there are more.
+func tailLines(path string, n int) ([]string, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Read chunks from the end until the buffer holds more than n lines.
+	offset := info.Size()
+	var buf []byte
+	for offset > 0 && bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) < n {
+		size := min(int64(tailChunkSize), offset)
+		offset -= size
+		chunk := make([]byte, size)
+		if _, err := file.ReadAt(chunk, offset); err != nil {
+			return nil, false, err
+		}
+		buf = append(chunk, buf...)
+	}
+
+	text := strings.TrimSuffix(string(buf), "\n")
+	if text == "" {
+		return []string{}, false, nil
+	}
+	lines := strings.Split(text, "\n")
+	truncated := offset > 0
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+		truncated = true
+	}
+	return lines, truncated, nil
+}
+
+// testCaseFailure is a failed test case taken from a JUnit XML test.xml file.
+type testCaseFailure struct {
+	testCase
+	message string
+	text    string
+}
+
+// qualifiedName returns the name of the test case prefixed with its class
+// name, if any.
+func (f testCaseFailure) qualifiedName() string {
+	if f.className == "" {
+		return f.name
+	}
+	return f.className + "." + f.name
+}
+
+// parseTestCaseFailures returns the failures and errors of the test cases of a
+// JUnit XML report.
+func parseTestCaseFailures(b []byte) ([]testCaseFailure, error) {
+	root := junitTestSuite{}
+	if err := xml.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+	failures := []testCaseFailure{}
+	var walk func(suite junitTestSuite)
+	walk = func(suite junitTestSuite) {
+		for _, c := range suite.TestCases {
+			for _, f := range slices.Concat(c.Failures, c.Errors) {
+				failures = append(failures, testCaseFailure{
+					testCase: testCase{className: c.ClassName, name: c.Name},
+					message:  f.Message,
+					text:     f.Text,
+				})
+			}
+		}
+		for _, s := range suite.TestSuites {
+			walk(s)
+		}
+	}
+	walk(root)
+	return failures, nil
+}
+
+// streamsTestOutput returns whether Bazel already prints the logs of failed
+// tests because of --test_output.
+func streamsTestOutput(args []string) bool {
+	output := "summary"
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--test_output="); ok {
+			output = value
+		} else if arg == "--test_output" && i+1 < len(args) {
+			output = args[i+1]
+		}
+	}
+	return output != "summary"
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+	bep_mock "github.com/aspect-build/aspect-cli/pkg/plugin/system/bep/mock"
+)
+
+func testAttempt(label string, shard, attempt int32, status buildeventstream.TestStatus, outputs ...*buildeventstream.File) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TestResult{TestResult: &buildeventstream.BuildEventId_TestResultId{
+			Label: label, Run: 1, Shard: shard, Attempt: attempt,
+		}}},
+		Payload: &buildeventstream.BuildEvent_TestResult{TestResult: &buildeventstream.TestResult{Status: status, TestActionOutput: outputs}},
+	}
+}
+
+// writeTestOutput writes a test.log or test.xml and returns it as a file reported in the BEP.
+func writeTestOutput(t *testing.T, name string, content string) *buildeventstream.File {
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &buildeventstream.File{Name: name, File: &buildeventstream.File_Uri{Uri: "file://" + p}}
+}
+
+func numberedLines(n int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestFailedTestLogs(t *testing.T) {
+	color.NoColor = true
+
+	t.Run("prints the end of the log of the last failed attempt of each shard", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		first := writeTestOutput(t, "test.log", "first attempt\n")
+		last := writeTestOutput(t, "test.log", numberedLines(10))
+		passed := writeTestOutput(t, "test.log", "passed\n")
+		summary := testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED)
+		summary.GetTestSummary().ShardCount = 2
+		summary.GetTestSummary().RunCount = 1
+
+		logs := newFailedTestLogs("/workspace", 3, false)
+		for _, e := range []*buildeventstream.BuildEvent{
+			testAttempt("//foo:foo_test", 2, 1, buildeventstream.TestStatus_FAILED, first),
+			testAttempt("//foo:foo_test", 2, 2, buildeventstream.TestStatus_TIMEOUT, last),
+			testAttempt("//foo:foo_test", 1, 1, buildeventstream.TestStatus_PASSED, passed),
+			summary,
+			testAttempt("//bar:bar_test", 0, 1, buildeventstream.TestStatus_FAILED, first),
+			testSummary("//bar:bar_test", buildeventstream.TestStatus_FLAKY),
+		} {
+			g.Expect(logs.bepEventCallback(e, 0)).To(Succeed())
+		}
+
+		var out strings.Builder
+		logs.print(&out)
+		g.Expect(out.String()).To(Equal(fmt.Sprintf(`TIMEOUT: //foo:foo_test (shard 2 of 2, attempt 2)
+  Last 3 lines of %s:
+    line 8
+    line 9
+    line 10
+`, strings.TrimPrefix(last.GetUri(), "file://"))))
+	})
+
+	t.Run("prints the failed test cases from test.xml", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		testXML := writeTestOutput(t, "test.xml", `<testsuites><testsuite name="foo">
+<testcase classname="foo" name="TestA"><failure message="Failed">foo_test.go:12: got 1, want 2
+foo_test.go:13: got 3, want 4</failure></testcase>
+<testcase classname="foo" name="TestB"></testcase>
+</testsuite></testsuites>`)
+		logs := newFailedTestLogs("/workspace", 1, true)
+		g.Expect(logs.bepEventCallback(testAttempt("//foo:foo_test", 0, 1, buildeventstream.TestStatus_FAILED, testXML), 0)).To(Succeed())
+		g.Expect(logs.bepEventCallback(testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED), 0)).To(Succeed())
+
+		var out strings.Builder
+		logs.print(&out)
+		g.Expect(out.String()).To(Equal(`FAILED: //foo:foo_test
+  ✗ foo.TestA: Failed
+      foo_test.go:13: got 3, want 4
+`))
+	})
+
+	t.Run("resolves bytestream files in the local exec root", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		execRoot := t.TempDir()
+		logPath := filepath.Join(execRoot, "bazel-out/k8-fastbuild/testlogs/foo/foo_test/test.log")
+		g.Expect(os.MkdirAll(filepath.Dir(logPath), 0755)).To(Succeed())
+		g.Expect(os.WriteFile(logPath, []byte("remote\n"), 0644)).To(Succeed())
+
+		logs := newFailedTestLogs("/workspace", 5, false)
+		for _, e := range []*buildeventstream.BuildEvent{
+			{
+				Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Workspace{Workspace: &buildeventstream.BuildEventId_WorkspaceConfigId{}}},
+				Payload: &buildeventstream.BuildEvent_WorkspaceInfo{WorkspaceInfo: &buildeventstream.WorkspaceConfig{LocalExecRoot: execRoot}},
+			},
+			testAttempt("//foo:foo_test", 0, 1, buildeventstream.TestStatus_FAILED, &buildeventstream.File{
+				Name:       "test.log",
+				PathPrefix: []string{"bazel-out", "k8-fastbuild", "testlogs", "foo", "foo_test"},
+				File:       &buildeventstream.File_Uri{Uri: "bytestream://remote/blobs/abc/7"},
+			}),
+			testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED),
+		} {
+			g.Expect(logs.bepEventCallback(e, 0)).To(Succeed())
+		}
+
+		var out strings.Builder
+		logs.print(&out)
+		g.Expect(out.String()).To(Equal("FAILED: //foo:foo_test\n  " + logPath + ":\n    remote\n"))
+	})
+}
+
+func TestTailLines(t *testing.T) {
+	t.Run("reads the last lines of large files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		p := filepath.Join(t.TempDir(), "test.log")
+		g.Expect(os.WriteFile(p, []byte(numberedLines(20000)), 0644)).To(Succeed())
+		lines, truncated, err := tailLines(p, 2)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(lines).To(Equal([]string{"line 19999", "line 20000"}))
+		g.Expect(truncated).To(BeTrue())
+	})
+
+	t.Run("reads short files entirely", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		p := filepath.Join(t.TempDir(), "test.log")
+		g.Expect(os.WriteFile(p, []byte("a\nb"), 0644)).To(Succeed())
+		lines, truncated, err := tailLines(p, 5)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(lines).To(Equal([]string{"a", "b"}))
+		g.Expect(truncated).To(BeFalse())
+	})
+}
+
+func TestFailedLogsFlags(t *testing.T) {
+	t.Run("doesn't print logs that bazel already printed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(streamsTestOutput([]string{"//foo:all"})).To(BeFalse())
+		g.Expect(streamsTestOutput([]string{"--test_output=errors"})).To(BeTrue())
+		g.Expect(streamsTestOutput([]string{"--test_output", "all"})).To(BeTrue())
+		g.Expect(streamsTestOutput([]string{"--test_output=errors", "--test_output=summary"})).To(BeFalse())
+	})
+
+	t.Run("prints the logs of failed tests once the build event stream finished", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		color.NoColor = true
+
+		testLog := writeTestOutput(t, "test.log", "boom\n")
+		var callback bep.CallbackFn
+		besBackend := bep_mock.NewMockBESBackend(ctrl)
+		besBackend.EXPECT().Addr().Return("grpc://127.0.0.1:12345")
+		besBackend.EXPECT().Errors()
+		besBackend.EXPECT().RegisterSubscriber(gomock.Any(), false).Do(func(cb bep.CallbackFn, _ bool) { callback = cb })
+
+		var stderr strings.Builder
+		streams := ioutils.Streams{Stderr: &stderr}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return("/workspace")
+		bzl.EXPECT().
+			RunCommand(streams, nil, "test", "//foo:foo_test", "--bes_backend=grpc://127.0.0.1:12345").
+			DoAndReturn(func(ioutils.Streams, *string, ...string) error {
+				g.Expect(callback(started("inv-1", time.Now()), 0)).To(Succeed())
+				// The BES backend may still be delivering events when Bazel exits.
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					callback(testAttempt("//foo:foo_test", 0, 1, buildeventstream.TestStatus_FAILED, testLog), 1)
+					callback(testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED), 2)
+					callback(finished(), 3)
+				}()
+				return errors.New("tests failed")
+			})
+
+		cmd := &cobra.Command{}
+		cmd.PersistentFlags().Bool(flags.AspectHintsFlagName, false, "")
+		cmd.Flags().Bool(FailedLogsFlagName, true, "")
+		cmd.Flags().Int(FailedLogsLinesFlagName, 50, "")
+		cmd.Flags().Bool(FailedLogsTestCasesFlagName, false, "")
+
+		ctx := bep.InjectBESBackend(context.Background(), besBackend)
+		err := New(streams, streams, bzl).Run(ctx, cmd, []string{"--failed-logs:lines", "50", "//foo:foo_test"})
+		g.Expect(err).To(MatchError("tests failed"))
+		g.Expect(stderr.String()).To(ContainSubstring("FAILED: //foo:foo_test\n"))
+		g.Expect(stderr.String()).To(ContainSubstring("    boom\n"))
+	})
+	t.Run("only needs the build event stream when --failed-logs is given", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		newCmd := func(args ...string) *cobra.Command {
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool(FailedLogsFlagName, true, "")
+			cmd.Flags().Int(FailedLogsLinesFlagName, 50, "")
+			g.Expect(cmd.ParseFlags(args)).To(Succeed())
+			return cmd
+		}
+
+		g.Expect(NeedsBESBackend(newCmd())).To(BeFalse())
+		g.Expect(NeedsBESBackend(newCmd("--failed-logs:lines=20"))).To(BeFalse())
+		g.Expect(NeedsBESBackend(newCmd("--failed-logs=false"))).To(BeFalse())
+		g.Expect(NeedsBESBackend(newCmd("--failed-logs"))).To(BeTrue())
+	})
+}
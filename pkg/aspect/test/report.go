@@ -0,0 +1,393 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+)
+
+const (
+	// JUnitReportFlagName writes the test.xml files of every test attempt
+	// of the invocation to a single JUnit XML file.
+	JUnitReportFlagName = "junit-report"
+	// HTMLReportFlagName writes a static HTML summary of the test results.
+	HTMLReportFlagName = "html-report"
+)
+
+//go:embed report.html.tmpl
+var reportTemplateSource string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateSource))
+
+// testReport collects the results of every test attempt during an invocation
+// so that their test.xml files can be merged once it completes. Only the
+// attempts reported by the invocation are included, unlike a glob of
+// bazel-testlogs, which also finds stale results of earlier invocations.
+type testReport struct {
+	*buildCompletion
+	workspaceRoot string
+
+	mutex         sync.Mutex
+	localExecRoot string
+	attempts      []*testAttemptResult
+	summaries     map[string]*buildeventstream.TestSummary
+}
+
+type testAttemptResult struct {
+	id       *buildeventstream.BuildEventId_TestResultId
+	result   *buildeventstream.TestResult
+	duration time.Duration
+}
+
+func newTestReport(workspaceRoot string) *testReport {
+	return &testReport{
+		buildCompletion: newBuildCompletion(),
+		workspaceRoot:   workspaceRoot,
+		summaries:       map[string]*buildeventstream.TestSummary{},
+	}
+}
+
+func (r *testReport) bepEventCallback(event *buildeventstream.BuildEvent, _ int64) error {
+	defer r.observe(event)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	id := event.GetId()
+	switch {
+	case event.GetWorkspaceInfo() != nil:
+		r.localExecRoot = event.GetWorkspaceInfo().GetLocalExecRoot()
+	case id.GetTestResult() != nil:
+		result := event.GetTestResult()
+		duration := time.Duration(result.GetTestAttemptDurationMillis()) * time.Millisecond
+		if result.GetTestAttemptDuration() != nil {
+			duration = result.GetTestAttemptDuration().AsDuration()
+		}
+		r.attempts = append(r.attempts, &testAttemptResult{id: id.GetTestResult(), result: result, duration: duration})
+	case id.GetTestSummary() != nil:
+		r.summaries[id.GetTestSummary().GetLabel()] = event.GetTestSummary()
+	}
+	return nil
+}
+
+// reportedAttempt is a test attempt with the test suites of its test.xml.
+type reportedAttempt struct {
+	Label    string
+	Attempt  string
+	Status   string
+	Cached   bool
+	Duration time.Duration
+	Tests    int
+	Failures int
+	Errors   int
+	Skipped  int
+	// Note explains why the test suites aren't known.
+	Note        string
+	FailedCases []reportedCase
+
+	suites []*junitRawSuite
+}
+
+// Passed returns whether the attempt passed.
+func (a *reportedAttempt) Passed() bool {
+	return a.Status == buildeventstream.TestStatus_PASSED.String()
+}
+
+// reportedCase is a failed test case of a test attempt.
+type reportedCase struct {
+	Name    string
+	Message string
+	Text    string
+}
+
+// junitRawSuite is a <testsuite> element whose contents are copied as is.
+type junitRawSuite struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Inner []byte     `xml:",innerxml"`
+}
+
+type junitRawRoot struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr       `xml:",any,attr"`
+	Inner   []byte           `xml:",innerxml"`
+	Suites  []*junitRawSuite `xml:"testsuite"`
+}
+
+// reportedAttempts reads the test.xml files of the attempts, in the order of
+// their labels, runs, shards and attempts.
+func (r *testReport) reportedAttempts() []*reportedAttempt {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	attempts := make([]*testAttemptResult, len(r.attempts))
+	copy(attempts, r.attempts)
+	sort.SliceStable(attempts, func(i, j int) bool {
+		a, b := attempts[i].id, attempts[j].id
+		if a.GetLabel() != b.GetLabel() {
+			return a.GetLabel() < b.GetLabel()
+		}
+		if a.GetRun() != b.GetRun() {
+			return a.GetRun() < b.GetRun()
+		}
+		if a.GetShard() != b.GetShard() {
+			return a.GetShard() < b.GetShard()
+		}
+		return a.GetAttempt() < b.GetAttempt()
+	})
+
+	result := make([]*reportedAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		reported := &reportedAttempt{
+			Label:    a.id.GetLabel(),
+			Attempt:  describeAttempt(a.id, r.summaries[a.id.GetLabel()]),
+			Status:   a.result.GetStatus().String(),
+			Cached:   a.result.GetCachedLocally(),
+			Duration: a.duration,
+		}
+		if err := r.readTestXML(a, reported); err != nil {
+			reported.Note = err.Error()
+			reported.suites = nil
+		}
+		result = append(result, reported)
+	}
+	return result
+}
+
+func (r *testReport) readTestXML(a *testAttemptResult, reported *reportedAttempt) error {
+	var testXML *buildeventstream.File
+	for _, f := range a.result.GetTestActionOutput() {
+		if f.GetName() == "test.xml" {
+			testXML = f
+		}
+	}
+	if testXML == nil {
+		return fmt.Errorf("no test.xml was reported")
+	}
+	path, err := bep.LocalFilePath(testXML, r.workspaceRoot, r.localExecRoot)
+	if err != nil {
+		return fmt.Errorf("test.xml is not available locally: %w", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read test.xml: %w", err)
+	}
+
+	root := junitRawRoot{}
+	if err := xml.Unmarshal(b, &root); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	switch root.XMLName.Local {
+	case "testsuites":
+		reported.suites = root.Suites
+	case "testsuite":
+		reported.suites = []*junitRawSuite{{Attrs: root.Attrs, Inner: root.Inner}}
+	default:
+		return fmt.Errorf("%s is not a JUnit XML report", path)
+	}
+	for _, s := range reported.suites {
+		reported.Tests += intAttr(s.Attrs, "tests")
+		reported.Failures += intAttr(s.Attrs, "failures")
+		reported.Errors += intAttr(s.Attrs, "errors")
+		reported.Skipped += intAttr(s.Attrs, "skipped") + intAttr(s.Attrs, "disabled")
+	}
+	failures, _ := parseTestCaseFailures(b)
+	for _, f := range failures {
+		reported.FailedCases = append(reported.FailedCases, reportedCase{Name: f.qualifiedName(), Message: f.message, Text: f.text})
+	}
+	return nil
+}
+
+func attr(attrs []xml.Attr, name string) string {
+	for _, a := range attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func intAttr(attrs []xml.Attr, name string) int {
+	n, _ := strconv.Atoi(attr(attrs, name))
+	return n
+}
+
+// writeJUnit writes the test suites of every attempt as a single JUnit XML
+// report. The name of each suite is prefixed with the label of its target and
+// the run, shard and attempt. An attempt without a test.xml is reported as a
+// suite with a single test case named after the target.
+func writeJUnit(w io.Writer, attempts []*reportedAttempt) error {
+	var body bytes.Buffer
+	tests, failures, errors, skipped := 0, 0, 0, 0
+	var total time.Duration
+	for _, a := range attempts {
+		total += a.Duration
+		prefix := a.Label + a.Attempt
+		if a.suites == nil {
+			failed := !a.Passed()
+			tests++
+			attrs := []xml.Attr{
+				{Name: xml.Name{Local: "name"}, Value: prefix},
+				{Name: xml.Name{Local: "tests"}, Value: "1"},
+				{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(boolToInt(failed))},
+				{Name: xml.Name{Local: "errors"}, Value: "0"},
+				{Name: xml.Name{Local: "time"}, Value: seconds(a.Duration)},
+			}
+			writeElementStart(&body, "testsuite", attrs)
+			writeElementStart(&body, "testcase", []xml.Attr{
+				{Name: xml.Name{Local: "classname"}, Value: a.Label},
+				{Name: xml.Name{Local: "name"}, Value: a.Label},
+				{Name: xml.Name{Local: "time"}, Value: seconds(a.Duration)},
+			})
+			if failed {
+				failures++
+				writeElementStart(&body, "failure", []xml.Attr{{Name: xml.Name{Local: "message"}, Value: fmt.Sprintf("%s: %s", a.Status, a.Note)}})
+				body.WriteString("</failure>")
+			}
+			body.WriteString("</testcase></testsuite>\n")
+			continue
+		}
+		tests += a.Tests
+		failures += a.Failures
+		errors += a.Errors
+		skipped += a.Skipped
+		for _, s := range a.suites {
+			attrs := []xml.Attr{{Name: xml.Name{Local: "name"}, Value: prefix}}
+			for _, at := range s.Attrs {
+				if at.Name.Local == "name" {
+					if at.Value != "" {
+						attrs[0].Value = prefix + " > " + at.Value
+					}
+				} else {
+					attrs = append(attrs, at)
+				}
+			}
+			writeElementStart(&body, "testsuite", attrs)
+			body.Write(s.Inner)
+			body.WriteString("</testsuite>\n")
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	var root bytes.Buffer
+	writeElementStart(&root, "testsuites", []xml.Attr{
+		{Name: xml.Name{Local: "tests"}, Value: strconv.Itoa(tests)},
+		{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(failures)},
+		{Name: xml.Name{Local: "errors"}, Value: strconv.Itoa(errors)},
+		{Name: xml.Name{Local: "skipped"}, Value: strconv.Itoa(skipped)},
+		{Name: xml.Name{Local: "time"}, Value: seconds(total)},
+	})
+	root.WriteString("\n")
+	root.Write(body.Bytes())
+	root.WriteString("</testsuites>\n")
+	_, err := w.Write(root.Bytes())
+	return err
+}
+
+func writeElementStart(b *bytes.Buffer, name string, attrs []xml.Attr) {
+	b.WriteString("<" + name)
+	for _, a := range attrs {
+		b.WriteString(" " + a.Name.Local + `="`)
+		xml.EscapeText(b, []byte(a.Value))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type htmlReport struct {
+	Generated string
+	Targets   int
+	Failed    int
+	Tests     int
+	Failures  int
+	Attempts  []*reportedAttempt
+}
+
+// writeHTML renders a self-contained HTML page that summarizes the test
+// results, with the failed test cases of each attempt.
+func writeHTML(w io.Writer, attempts []*reportedAttempt, now time.Time) error {
+	page := htmlReport{Generated: now.Format(time.DateTime), Attempts: attempts}
+	targets := map[string]bool{}
+	failed := map[string]bool{}
+	for _, a := range attempts {
+		targets[a.Label] = true
+		if !a.Passed() {
+			failed[a.Label] = true
+		}
+		page.Tests += a.Tests
+		page.Failures += a.Failures + a.Errors
+	}
+	page.Targets = len(targets)
+	page.Failed = len(failed)
+	return reportTemplate.Execute(w, page)
+}
+
+// write writes the JUnit XML and HTML reports to the given paths, if set.
+func (r *testReport) write(junitPath string, htmlPath string, stderr io.Writer) error {
+	attempts := r.reportedAttempts()
+	if junitPath != "" {
+		if err := writeReportFile(junitPath, func(w io.Writer) error { return writeJUnit(w, attempts) }); err != nil {
+			return fmt.Errorf("failed to write the JUnit report: %w", err)
+		}
+		fmt.Fprintf(stderr, "Wrote the JUnit report of %d test attempts to %s\n", len(attempts), junitPath)
+	}
+	if htmlPath != "" {
+		if err := writeReportFile(htmlPath, func(w io.Writer) error { return writeHTML(w, attempts, time.Now()) }); err != nil {
+			return fmt.Errorf("failed to write the HTML report: %w", err)
+		}
+		fmt.Fprintf(stderr, "Wrote the HTML report of %d test attempts to %s\n", len(attempts), htmlPath)
+	}
+	return nil
+}
+
+func writeReportFile(path string, write func(w io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+	bep_mock "github.com/aspect-build/aspect-cli/pkg/plugin/system/bep/mock"
+)
+
+const passedTestXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="com.example.BarTest" tests="2" failures="0" errors="0" time="0.5">
+  <testcase classname="com.example.BarTest" name="testA" time="0.2"></testcase>
+  <testcase classname="com.example.BarTest" name="testB" time="0.3"></testcase>
+</testsuite>
+`
+
+func testReportOf(t *testing.T) *testReport {
+	failed := writeTestOutput(t, "test.xml", failedTestXML)
+	passed := writeTestOutput(t, "test.xml", passedTestXML)
+	shards := testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED)
+	shards.GetTestSummary().ShardCount = 2
+
+	report := newTestReport("/workspace")
+	for _, e := range []*buildeventstream.BuildEvent{
+		testAttempt("//foo:foo_test", 2, 1, buildeventstream.TestStatus_FAILED, failed),
+		testAttempt("//bar:bar_test", 0, 1, buildeventstream.TestStatus_PASSED, passed),
+		testAttempt("//foo:foo_test", 1, 1, buildeventstream.TestStatus_PASSED, passed),
+		testAttempt("//baz:baz_test", 0, 1, buildeventstream.TestStatus_TIMEOUT),
+		shards,
+		testSummary("//bar:bar_test", buildeventstream.TestStatus_PASSED),
+		testSummary("//baz:baz_test", buildeventstream.TestStatus_TIMEOUT),
+	} {
+		if result := e.GetTestResult(); result != nil {
+			result.TestAttemptDuration = durationpb.New(1500 * time.Millisecond)
+		}
+		if err := report.bepEventCallback(e, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return report
+}
+
+func TestJUnitReport(t *testing.T) {
+	t.Run("merges the test.xml files of every attempt", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out strings.Builder
+		g.Expect(writeJUnit(&out, testReportOf(t).reportedAttempts())).To(Succeed())
+
+		var merged struct {
+			Tests    int `xml:"tests,attr"`
+			Failures int `xml:"failures,attr"`
+			Suites   []struct {
+				Name      string `xml:"name,attr"`
+				TestCases []struct {
+					Name     string     `xml:"name,attr"`
+					Failures []struct{} `xml:"failure"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}
+		g.Expect(xml.Unmarshal([]byte(out.String()), &merged)).To(Succeed())
+		g.Expect(merged.Tests).To(Equal(8))
+		g.Expect(merged.Failures).To(Equal(3))
+
+		names := []string{}
+		for _, s := range merged.Suites {
+			names = append(names, s.Name)
+		}
+		g.Expect(names).To(Equal([]string{
+			"//bar:bar_test > com.example.BarTest",
+			"//baz:baz_test",
+			"//foo:foo_test (shard 1 of 2) > com.example.BarTest",
+			"//foo:foo_test (shard 2 of 2) > example.com/foo",
+		}))
+		g.Expect(merged.Suites[1].TestCases).To(HaveLen(1))
+		g.Expect(merged.Suites[1].TestCases[0].Failures).To(HaveLen(1))
+		g.Expect(merged.Suites[3].TestCases).To(HaveLen(3))
+		g.Expect(out.String()).To(ContainSubstring(`<failure message="TIMEOUT: no test.xml was reported">`))
+	})
+}
+
+func TestHTMLReport(t *testing.T) {
+	t.Run("summarizes the results and lists the failed test cases", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var out strings.Builder
+		g.Expect(writeHTML(&out, testReportOf(t).reportedAttempts(), time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))).To(Succeed())
+		g.Expect(out.String()).To(ContainSubstring("3 test targets, 2 failed. 7 test cases, 2 failed."))
+		g.Expect(out.String()).To(ContainSubstring(`<td class="label">//foo:foo_test (shard 2 of 2)`))
+		g.Expect(out.String()).To(ContainSubstring("<summary>foo.TestA/sub: Failed</summary>"))
+		g.Expect(out.String()).To(ContainSubstring("no test.xml was reported"))
+	})
+
+	t.Run("writes the reports to files", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		var stderr strings.Builder
+		g.Expect(testReportOf(t).write(filepath.Join(dir, "out/junit.xml"), filepath.Join(dir, "report.html"), &stderr)).To(Succeed())
+		g.Expect(filepath.Join(dir, "out/junit.xml")).To(BeAnExistingFile())
+		b, err := os.ReadFile(filepath.Join(dir, "report.html"))
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(string(b)).To(HavePrefix("<!DOCTYPE html>"))
+		g.Expect(stderr.String()).To(ContainSubstring("Wrote the JUnit report of 4 test attempts to"))
+	})
+
+	t.Run("writes the reports once the build event stream finished", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		passed := writeTestOutput(t, "test.xml", passedTestXML)
+		var callback bep.CallbackFn
+		besBackend := bep_mock.NewMockBESBackend(ctrl)
+		besBackend.EXPECT().Addr().Return("grpc://127.0.0.1:12345")
+		besBackend.EXPECT().Errors()
+		besBackend.EXPECT().RegisterSubscriber(gomock.Any(), false).Do(func(cb bep.CallbackFn, _ bool) { callback = cb })
+
+		var stderr strings.Builder
+		streams := ioutils.Streams{Stderr: &stderr}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return("/workspace")
+		bzl.EXPECT().
+			RunCommand(streams, nil, "test", "//bar:bar_test", "--bes_backend=grpc://127.0.0.1:12345").
+			DoAndReturn(func(ioutils.Streams, *string, ...string) error {
+				g.Expect(callback(started("inv-1", time.Now()), 0)).To(Succeed())
+				// The BES backend may still be delivering events when Bazel exits.
+				go func() {
+					time.Sleep(10 * time.Millisecond)
+					callback(testAttempt("//bar:bar_test", 0, 1, buildeventstream.TestStatus_PASSED, passed), 1)
+					callback(testSummary("//bar:bar_test", buildeventstream.TestStatus_PASSED), 2)
+					callback(finished(), 3)
+				}()
+				return nil
+			})
+
+		junit := filepath.Join(t.TempDir(), "junit.xml")
+		cmd := &cobra.Command{}
+		cmd.PersistentFlags().Bool(flags.AspectHintsFlagName, false, "")
+		cmd.Flags().String(JUnitReportFlagName, junit, "")
+
+		ctx := bep.InjectBESBackend(context.Background(), besBackend)
+		g.Expect(New(streams, streams, bzl).Run(ctx, cmd, []string{"--junit-report", junit, "//bar:bar_test"})).To(Succeed())
+		g.Expect(stderr.String()).To(ContainSubstring("Wrote the JUnit report of 1 test attempts to"))
+	})
+}
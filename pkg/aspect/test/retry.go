@@ -0,0 +1,252 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+)
+
+const (
+	// RetryFailedFlagName reruns the tests that failed in the last invocation.
+	RetryFailedFlagName = "retry-failed"
+	// RetryFailedTestFilterFlagName additionally restricts the rerun to the
+	// failed test cases with --test_filter.
+	RetryFailedTestFilterFlagName = "retry-failed:test_filter"
+)
+
+// testFilterStyle formats the --test_filter that selects a set of test cases
+// for the test runner of a rule kind.
+type testFilterStyle func(cases []testCase) string
+
+// testFilterStyles are the rule kinds whose test runners support selecting
+// test cases with --test_filter.
+var testFilterStyles = map[string]testFilterStyle{
+	// rules_go passes the filter to -test.run, which matches subtests
+	// separately, so the whole top-level test is selected.
+	"go_test": func(cases []testCase) string {
+		names := []string{}
+		seen := map[string]bool{}
+		for _, c := range cases {
+			name, _, _ := strings.Cut(c.name, "/")
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, regexp.QuoteMeta(name))
+			}
+		}
+		return "^(" + strings.Join(names, "|") + ")$"
+	},
+	// Bazel's JUnit runner matches the filter against "class#method".
+	"java_test": func(cases []testCase) string {
+		names := []string{}
+		for _, c := range cases {
+			names = append(names, regexp.QuoteMeta(c.className+"#"+c.name)+"$")
+		}
+		return strings.Join(names, "|")
+	},
+	// GoogleTest receives the filter as --gtest_filter.
+	"cc_test": func(cases []testCase) string {
+		names := []string{}
+		for _, c := range cases {
+			names = append(names, c.className+"."+c.name)
+		}
+		return strings.Join(names, ":")
+	},
+}
+
+// testCase is a test case taken from a JUnit XML test.xml file.
+type testCase struct {
+	className string
+	name      string
+}
+
+// failedTests are the tests to rerun for --retry-failed.
+type failedTests struct {
+	invocation *history.Invocation
+	targets    []string
+	// testFilter is empty when the rerun can't be restricted to the failed
+	// test cases.
+	testFilter string
+	// testFilterReason explains why there is no testFilter.
+	testFilterReason string
+}
+
+// findFailedTests returns the targets whose overall status was FAILED, TIMEOUT
+// or FLAKY in the last test invocation recorded for the workspace.
+func findFailedTests(store *history.Store, workspaceRoot string) (*failedTests, error) {
+	invocation, err := store.Latest(func(i *history.Invocation) bool {
+		return i.Command == "test" && i.WorkspaceDirectory == workspaceRoot
+	})
+	var notFound *history.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, fmt.Errorf("--%s requires an earlier 'aspect test' invocation in %s to be recorded; see 'aspect history'", RetryFailedFlagName, workspaceRoot)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	failed := map[string]bool{}
+	kinds := map[string]string{}
+	outputs := map[string][]*buildeventstream.File{}
+	err = store.ForEachEvent(invocation.ID, func(event *buildeventstream.BuildEvent) error {
+		id := event.GetId()
+		switch {
+		case id.GetTargetCompleted() != nil:
+			kinds[id.GetTargetCompleted().GetLabel()] = strings.TrimSuffix(event.GetCompleted().GetTargetKind(), " rule")
+		case id.GetTestResult() != nil:
+			switch event.GetTestResult().GetStatus() {
+			case buildeventstream.TestStatus_FAILED, buildeventstream.TestStatus_TIMEOUT:
+				label := id.GetTestResult().GetLabel()
+				outputs[label] = append(outputs[label], event.GetTestResult().GetTestActionOutput()...)
+			}
+		case id.GetTestSummary() != nil:
+			switch event.GetTestSummary().GetOverallStatus() {
+			case buildeventstream.TestStatus_FAILED, buildeventstream.TestStatus_TIMEOUT, buildeventstream.TestStatus_FLAKY:
+				failed[id.GetTestSummary().GetLabel()] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tests := &failedTests{invocation: invocation}
+	for label := range failed {
+		tests.targets = append(tests.targets, label)
+	}
+	sort.Strings(tests.targets)
+	tests.testFilter, tests.testFilterReason = failedTestFilter(tests.targets, kinds, outputs)
+	return tests, nil
+}
+
+// failedTestFilter returns a --test_filter that selects the failed test cases
+// of every target. Bazel passes the same filter to every test, so there is only
+// a filter when all targets use the same kind of test runner and their failed
+// test cases are known.
+func failedTestFilter(targets []string, kinds map[string]string, outputs map[string][]*buildeventstream.File) (string, string) {
+	if len(targets) == 0 {
+		return "", ""
+	}
+	kind := kinds[targets[0]]
+	style, ok := testFilterStyles[kind]
+	if !ok {
+		return "", fmt.Sprintf("%s is a %s, which doesn't support selecting test cases", targets[0], kindOrUnknown(kind))
+	}
+	cases := []testCase{}
+	seen := map[testCase]bool{}
+	for _, label := range targets {
+		if kinds[label] != kind {
+			return "", fmt.Sprintf("%s and %s use different test runners", targets[0], label)
+		}
+		targetCases, err := failedTestCases(outputs[label])
+		if err != nil {
+			return "", fmt.Sprintf("the failed test cases of %s are unknown: %v", label, err)
+		}
+		if len(targetCases) == 0 {
+			return "", fmt.Sprintf("the failed test cases of %s are unknown", label)
+		}
+		for _, c := range targetCases {
+			if !seen[c] {
+				seen[c] = true
+				cases = append(cases, c)
+			}
+		}
+	}
+	return style(cases), ""
+}
+
+func kindOrUnknown(kind string) string {
+	if kind == "" {
+		return "rule of unknown kind"
+	}
+	return kind
+}
+
+// failedTestCases reads the failed test cases from the test.xml files among
+// the outputs of failed test attempts.
+func failedTestCases(outputs []*buildeventstream.File) ([]testCase, error) {
+	cases := []testCase{}
+	for _, f := range outputs {
+		if f.GetName() != "test.xml" {
+			continue
+		}
+		u, err := url.Parse(f.GetUri())
+		if err != nil || u.Scheme != "file" {
+			return nil, fmt.Errorf("test.xml is not available locally")
+		}
+		b, err := os.ReadFile(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		fileCases, err := parseFailedTestCases(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", u.Path, err)
+		}
+		cases = append(cases, fileCases...)
+	}
+	return cases, nil
+}
+
+type junitTestCase struct {
+	ClassName string         `xml:"classname,attr"`
+	Name      string         `xml:"name,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	Errors    []junitFailure `xml:"error"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestSuite struct {
+	TestCases  []junitTestCase  `xml:"testcase"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+// parseFailedTestCases returns the failed test cases of a JUnit XML report,
+// whose root element is either <testsuites> or a single <testsuite>.
+func parseFailedTestCases(b []byte) ([]testCase, error) {
+	root := junitTestSuite{}
+	if err := xml.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+	cases := []testCase{}
+	var walk func(suite junitTestSuite)
+	walk = func(suite junitTestSuite) {
+		for _, c := range suite.TestCases {
+			if len(c.Failures) > 0 || len(c.Errors) > 0 {
+				cases = append(cases, testCase{className: c.ClassName, name: c.Name})
+			}
+		}
+		for _, s := range suite.TestSuites {
+			walk(s)
+		}
+	}
+	walk(root)
+	return cases, nil
+}
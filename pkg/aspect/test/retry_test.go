@@ -0,0 +1,178 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+const failedTestXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="example.com/foo" tests="3" failures="2">
+    <testcase classname="foo" name="TestA"><failure message="Failed"></failure></testcase>
+    <testcase classname="foo" name="TestA/sub"><failure message="Failed"></failure></testcase>
+    <testcase classname="foo" name="TestB"></testcase>
+  </testsuite>
+</testsuites>
+`
+
+func targetCompleted(label, kind string) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TargetCompleted{TargetCompleted: &buildeventstream.BuildEventId_TargetCompletedId{Label: label}}},
+		Payload: &buildeventstream.BuildEvent_Completed{Completed: &buildeventstream.TargetComplete{Success: true, TargetKind: kind + " rule"}},
+	}
+}
+
+func testResult(label string, status buildeventstream.TestStatus, testXML string) *buildeventstream.BuildEvent {
+	result := &buildeventstream.TestResult{Status: status}
+	if testXML != "" {
+		result.TestActionOutput = []*buildeventstream.File{{Name: "test.xml", File: &buildeventstream.File_Uri{Uri: "file://" + testXML}}}
+	}
+	return &buildeventstream.BuildEvent{
+		Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TestResult{TestResult: &buildeventstream.BuildEventId_TestResultId{Label: label, Attempt: 1}}},
+		Payload: &buildeventstream.BuildEvent_TestResult{TestResult: result},
+	}
+}
+
+func recordTestInvocation(t *testing.T, store *history.Store, id string, events ...*buildeventstream.BuildEvent) {
+	start := started(id, time.Now())
+	start.GetStarted().WorkspaceDirectory = "/workspace"
+	r := history.NewRecorder(store, history.Retention{})
+	for seq, e := range append(append([]*buildeventstream.BuildEvent{start}, events...), finished()) {
+		if err := r.Callback(e, int64(seq)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func retryCmd(testFilter bool) *cobra.Command {
+	cmd := &cobra.Command{}
+	cmd.PersistentFlags().Bool(flags.AspectHintsFlagName, false, "")
+	cmd.Flags().Bool(RetryFailedFlagName, true, "")
+	cmd.Flags().Bool(RetryFailedTestFilterFlagName, testFilter, "")
+	return cmd
+}
+
+func TestRetryFailed(t *testing.T) {
+	t.Run("reruns the failed test cases of the last invocation", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		testXML := filepath.Join(t.TempDir(), "test.xml")
+		g.Expect(os.WriteFile(testXML, []byte(failedTestXML), 0644)).To(Succeed())
+		store := history.NewStore(t.TempDir())
+		recordTestInvocation(t, store, "inv-1",
+			targetCompleted("//foo:foo_test", "go_test"),
+			testResult("//foo:foo_test", buildeventstream.TestStatus_FAILED, testXML),
+			testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED),
+			targetCompleted("//bar:bar_test", "go_test"),
+			testSummary("//bar:bar_test", buildeventstream.TestStatus_PASSED),
+		)
+
+		var stderr strings.Builder
+		streams := ioutils.Streams{Stderr: &stderr}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return("/workspace")
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "test", "--config=ci", "--test_filter=^(TestA)$", "//foo:foo_test").
+			Return(nil)
+
+		runner := New(streams, streams, bzl)
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		g.Expect(runner.Run(context.Background(), retryCmd(true), []string{"--retry-failed", "--retry-failed:test_filter", "--config=ci"})).To(Succeed())
+		g.Expect(stderr.String()).To(ContainSubstring("Retrying 1 failed test(s) from invocation inv-1"))
+	})
+
+	t.Run("reruns whole targets when the test runners differ", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		testXML := filepath.Join(t.TempDir(), "test.xml")
+		g.Expect(os.WriteFile(testXML, []byte(failedTestXML), 0644)).To(Succeed())
+		store := history.NewStore(t.TempDir())
+		recordTestInvocation(t, store, "inv-1",
+			targetCompleted("//foo:foo_test", "go_test"),
+			testResult("//foo:foo_test", buildeventstream.TestStatus_FAILED, testXML),
+			testSummary("//foo:foo_test", buildeventstream.TestStatus_FAILED),
+			targetCompleted("//py:py_test", "py_test"),
+			testSummary("//py:py_test", buildeventstream.TestStatus_TIMEOUT),
+			targetCompleted("//flaky:flaky_test", "go_test"),
+			testSummary("//flaky:flaky_test", buildeventstream.TestStatus_FLAKY),
+		)
+
+		var stderr strings.Builder
+		streams := ioutils.Streams{Stderr: &stderr}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return("/workspace")
+		bzl.
+			EXPECT().
+			RunCommand(streams, nil, "test", "//flaky:flaky_test", "//foo:foo_test", "//py:py_test").
+			Return(nil)
+
+		runner := New(streams, streams, bzl)
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		g.Expect(runner.Run(context.Background(), retryCmd(true), []string{"--retry-failed"})).To(Succeed())
+		g.Expect(stderr.String()).To(ContainSubstring("Rerunning every test case since the failed test cases of //flaky:flaky_test are unknown"))
+	})
+
+	t.Run("doesn't run bazel when no tests failed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := history.NewStore(t.TempDir())
+		recordTestInvocation(t, store, "inv-1", testSummary("//foo:foo_test", buildeventstream.TestStatus_PASSED))
+
+		var stderr strings.Builder
+		streams := ioutils.Streams{Stderr: &stderr}
+		bzl := bazel_mock.NewMockBazel(ctrl)
+		bzl.EXPECT().WorkspaceRoot().Return("/workspace")
+
+		runner := New(streams, streams, bzl)
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		g.Expect(runner.Run(context.Background(), retryCmd(false), []string{"--retry-failed"})).To(Succeed())
+		g.Expect(stderr.String()).To(ContainSubstring("No tests failed in the last test invocation inv-1"))
+	})
+}
+
+func TestParseFailedTestCases(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases, err := parseFailedTestCases([]byte(`<testsuite name="Suite"><testcase classname="com.example.FooTest" name="testA"><error/></testcase><testcase classname="com.example.FooTest" name="testB"/></testsuite>`))
+	g.Expect(err).To(Not(HaveOccurred()))
+	g.Expect(cases).To(Equal([]testCase{{className: "com.example.FooTest", name: "testA"}}))
+	g.Expect(testFilterStyles["java_test"](cases)).To(Equal(`com\.example\.FooTest#testA$`))
+	g.Expect(testFilterStyles["cc_test"](cases)).To(Equal("com.example.FooTest.testA"))
+}
@@ -19,44 +19,175 @@ package test
 import (
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/history"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
 	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 )
 
+// besCompletionTimeout is how long to wait, once Bazel has exited, for the
+// subscribers to receive the rest of the build event stream.
+const besCompletionTimeout = 60 * time.Second
+
+// buildCompletion signals when a build event subscriber has received the
+// BuildFinished event. The subscribers run on the goroutines of the BES
+// backend, which may still be delivering events when Bazel exits.
+type buildCompletion struct {
+	once     sync.Once
+	started  atomic.Bool
+	finished chan struct{}
+}
+
+func newBuildCompletion() *buildCompletion {
+	return &buildCompletion{finished: make(chan struct{})}
+}
+
+func (c *buildCompletion) observe(event *buildeventstream.BuildEvent) {
+	switch {
+	case event.GetStarted() != nil:
+		c.started.Store(true)
+	case event.GetFinished() != nil:
+		c.once.Do(func() { close(c.finished) })
+	}
+}
+
+// wait blocks until the BuildFinished event was received, or the timeout
+// expires. Bazel publishes BuildStarted well before it exits, so when it
+// hasn't been received Bazel never got to run a build and there is nothing to
+// wait for.
+func (c *buildCompletion) wait(timeout time.Duration) error {
+	if !c.started.Load() {
+		return nil
+	}
+	select {
+	case <-c.finished:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out waiting for the build finished event")
+	}
+}
+
 type Test struct {
-	streams  ioutils.Streams
-	hstreams ioutils.Streams
-	bzl      bazel.Bazel
+	streams   ioutils.Streams
+	hstreams  ioutils.Streams
+	bzl       bazel.Bazel
+	openStore func() (*history.Store, error)
 }
 
 func New(streams ioutils.Streams, hstreams ioutils.Streams, bzl bazel.Bazel) *Test {
 	return &Test{
-		streams:  streams,
-		hstreams: hstreams,
-		bzl:      bzl,
+		streams:   streams,
+		hstreams:  hstreams,
+		bzl:       bzl,
+		openStore: history.NewDefaultStore,
 	}
 }
 
+// NeedsBESBackend returns whether `aspect test` subscribes to the build events
+// for the flags it was given: to print the logs of failed tests when
+// --failed-logs is given, to write the test reports or to warn about known flaky
+// tests. The logs of failed tests are printed by default only when the build
+// events are received for another reason, such as plugins or the local history,
+// so that a plain `aspect test` doesn't start a BES backend.
+func NeedsBESBackend(cmd *cobra.Command) bool {
+	failedLogs, _ := cmd.Flags().GetBool(FailedLogsFlagName)
+	failedLogsLines, _ := cmd.Flags().GetInt(FailedLogsLinesFlagName)
+	junitReport, _ := cmd.Flags().GetString(JUnitReportFlagName)
+	htmlReport, _ := cmd.Flags().GetString(HTMLReportFlagName)
+	explicitFailedLogs := failedLogs && failedLogsLines > 0 && cmd.Flags().Changed(FailedLogsFlagName)
+	return explicitFailedLogs || junitReport != "" || htmlReport != "" || viper.GetBool(WarnKnownFlakyConfigKey)
+}
+
 func (runner *Test) Run(ctx context.Context, cmd *cobra.Command, args []string) (exitErr error) {
+	retryFailed, retryFailedTestFilter := false, false
+	failedLogs, failedLogsLines, failedLogsTestCases := false, 0, false
+	junitReport, htmlReport := "", ""
+	var flagSet *pflag.FlagSet
+	if cmd != nil {
+		flagSet = cmd.Flags()
+		retryFailed, _ = cmd.Flags().GetBool(RetryFailedFlagName)
+		retryFailedTestFilter, _ = cmd.Flags().GetBool(RetryFailedTestFilterFlagName)
+		failedLogs, _ = cmd.Flags().GetBool(FailedLogsFlagName)
+		failedLogsLines, _ = cmd.Flags().GetInt(FailedLogsLinesFlagName)
+		failedLogsTestCases, _ = cmd.Flags().GetBool(FailedLogsTestCasesFlagName)
+		junitReport, _ = cmd.Flags().GetString(JUnitReportFlagName)
+		htmlReport, _ = cmd.Flags().GetString(HTMLReportFlagName)
+	}
+	args = bazel.RemoveFlags("test", flagSet, args,
+		RetryFailedFlagName, RetryFailedTestFilterFlagName,
+		FailedLogsFlagName, FailedLogsLinesFlagName, FailedLogsTestCasesFlagName,
+		JUnitReportFlagName, HTMLReportFlagName)
+
 	bazelCmd := []string{"test"}
 	bazelCmd = append(bazelCmd, args...)
 
+	if retryFailed {
+		store, err := runner.openStore()
+		if err != nil {
+			return err
+		}
+		failed, err := findFailedTests(store, runner.bzl.WorkspaceRoot())
+		if err != nil {
+			return err
+		}
+		if len(failed.targets) == 0 {
+			fmt.Fprintf(runner.streams.Stderr, "No tests failed in the last test invocation %s\n", failed.invocation.ID)
+			return nil
+		}
+		fmt.Fprintf(runner.streams.Stderr, "Retrying %d failed test(s) from invocation %s\n", len(failed.targets), failed.invocation.ID)
+		if retryFailedTestFilter {
+			if failed.testFilter != "" {
+				bazelCmd = flags.AddFlagToCommand(bazelCmd, "--test_filter="+failed.testFilter)
+			} else {
+				fmt.Fprintf(runner.streams.Stderr, "Rerunning every test case since %s\n", failed.testFilterReason)
+			}
+		}
+		bazelCmd = append(bazelCmd, failed.targets...)
+	}
+
 	// Currently Bazel only supports a single --bes_backend so adding ours after
 	// any user supplied value will result in our bes_backend taking precedence.
-	// There is a very old & stale issue to add support for multiple BES
-	// backends https://github.com/bazelbuild/bazel/issues/10908. In the future,
-	// we could build this support into the Aspect CLI and post on that issue
-	// that using the Aspect CLI resolves it.
+	// The user's --bes_backend, whether from the command line or a bazelrc, is
+	// still honored: the BES backend reads the one in effect from the
+	// OptionsParsed event and forwards the stream to it through a proxy, along
+	// with any bes.backends of the config.yaml.
+	// See https://github.com/bazelbuild/bazel/issues/10908.
 	if bep.HasBESBackend(ctx) {
 		besBackend := bep.BESBackendFromContext(ctx)
 		besBackendFlag := fmt.Sprintf("--bes_backend=%s", besBackend.Addr())
 		bazelCmd = flags.AddFlagToCommand(bazelCmd, besBackendFlag)
 	}
 
+	var flakyWarning *flakyTestWarning
+	if viper.GetBool(WarnKnownFlakyConfigKey) && bep.HasBESBackend(ctx) {
+		flakyWarning = newFlakyTestWarning(runner.openStore)
+		bep.BESBackendFromContext(ctx).RegisterSubscriber(flakyWarning.bepEventCallback, false)
+	}
+
+	var failedTestLogs *failedTestLogs
+	if failedLogs && failedLogsLines > 0 && bep.HasBESBackend(ctx) && !streamsTestOutput(args) {
+		failedTestLogs = newFailedTestLogs(runner.bzl.WorkspaceRoot(), failedLogsLines, failedLogsTestCases)
+		bep.BESBackendFromContext(ctx).RegisterSubscriber(failedTestLogs.bepEventCallback, false)
+	}
+
+	var report *testReport
+	if junitReport != "" || htmlReport != "" {
+		if !bep.HasBESBackend(ctx) {
+			return fmt.Errorf("--%s and --%s require the build event stream", JUnitReportFlagName, HTMLReportFlagName)
+		}
+		report = newTestReport(runner.bzl.WorkspaceRoot())
+		bep.BESBackendFromContext(ctx).RegisterSubscriber(report.bepEventCallback, false)
+	}
+
 	bzlCommandStreams := runner.streams
 	if cmd != nil {
 		hints, err := cmd.Root().PersistentFlags().GetBool(flags.AspectHintsFlagName)
@@ -70,6 +201,36 @@ func (runner *Test) Run(ctx context.Context, cmd *cobra.Command, args []string)
 
 	err := runner.bzl.RunCommand(bzlCommandStreams, nil, bazelCmd...)
 
+	if report != nil {
+		reportErr := report.wait(besCompletionTimeout)
+		if reportErr == nil {
+			reportErr = report.write(junitReport, htmlReport, runner.streams.Stderr)
+		}
+		if reportErr != nil {
+			if err != nil {
+				fmt.Fprintf(runner.streams.Stderr, "Error: %v\n", reportErr)
+			} else {
+				err = reportErr
+			}
+		}
+	}
+
+	if err != nil && failedTestLogs != nil {
+		if waitErr := failedTestLogs.wait(besCompletionTimeout); waitErr != nil {
+			fmt.Fprintf(runner.streams.Stderr, "Error: %v\n", waitErr)
+		} else {
+			failedTestLogs.print(runner.streams.Stderr)
+		}
+	}
+
+	if err != nil && flakyWarning != nil {
+		if waitErr := flakyWarning.wait(besCompletionTimeout); waitErr != nil {
+			fmt.Fprintf(runner.streams.Stderr, "Error: %v\n", waitErr)
+		} else {
+			flakyWarning.print(runner.streams.Stderr)
+		}
+	}
+
 	// Check for subscriber errors
 	subscriberErrors := bep.BESErrors(ctx)
 	if len(subscriberErrors) > 0 {
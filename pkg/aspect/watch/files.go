@@ -0,0 +1,159 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// locationPattern matches a line of `bazel query --output=location`, which starts with the path
+// of the file that declares the target followed by a line and a column.
+var locationPattern = regexp.MustCompile(`^(.+):\d+:\d+: `)
+
+// parseLocations returns the paths of the files in the output of `bazel query --output=location`
+// that are in one of the roots. Files in external repositories are skipped since they only change
+// when the workspace configuration does.
+func parseLocations(r io.Reader, roots ...string) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := locationPattern.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		for _, root := range roots {
+			if strings.HasPrefix(m[1], root+string(filepath.Separator)) {
+				files = append(files, filepath.Clean(m[1]))
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// fileWatcher is notified when the source files of the targets change.
+//
+// inotify, and the other backends of fsnotify, watch directories rather than single files, so the
+// directories of the files are watched and the events of other files are filtered out, except for
+// new files, which may be matched by a glob of a BUILD file.
+type fileWatcher struct {
+	w     *fsnotify.Watcher
+	files map[string]bool
+	dirs  map[string]bool
+}
+
+func newFileWatcher() (*fileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch the source files: %w", err)
+	}
+	return &fileWatcher{w: w, files: map[string]bool{}, dirs: map[string]bool{}}, nil
+}
+
+func (fw *fileWatcher) Close() error {
+	return fw.w.Close()
+}
+
+// watch replaces the watched files by the given ones.
+func (fw *fileWatcher) watch(files []string) error {
+	fw.files = make(map[string]bool, len(files))
+	dirs := map[string]bool{}
+	for _, f := range files {
+		fw.files[f] = true
+		dirs[filepath.Dir(f)] = true
+	}
+	for dir := range fw.dirs {
+		if !dirs[dir] {
+			// The directory may have been deleted, which already removed the watch.
+			_ = fw.w.Remove(dir)
+			delete(fw.dirs, dir)
+		}
+	}
+	for dir := range dirs {
+		if fw.dirs[dir] {
+			continue
+		}
+		if err := fw.w.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		fw.dirs[dir] = true
+	}
+	return nil
+}
+
+// relevant returns whether an event changes the source files of the targets.
+func (fw *fileWatcher) relevant(event fsnotify.Event) bool {
+	if fw.files[filepath.Clean(event.Name)] {
+		// Touching a file, or changing its mode, leaves its contents alone.
+		return event.Op != fsnotify.Chmod
+	}
+	// Editors write backup and swap files next to the files being edited, which are hidden or
+	// end with a tilde.
+	name := filepath.Base(event.Name)
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+		return false
+	}
+	return event.Has(fsnotify.Create)
+}
+
+// wait blocks until a source file changes and no other change followed for the debounce
+// duration, so that saving many files at once reruns the command only once. It returns the paths
+// of the changed files.
+func (fw *fileWatcher) wait(ctx context.Context, debounce time.Duration) ([]string, error) {
+	changed := map[string]bool{}
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-fw.w.Events:
+			if !ok {
+				return nil, fmt.Errorf("the file watcher was closed")
+			}
+			if fw.relevant(event) {
+				changed[filepath.Clean(event.Name)] = true
+				settled = time.After(debounce)
+			}
+		case err, ok := <-fw.w.Errors:
+			if !ok {
+				return nil, fmt.Errorf("the file watcher was closed")
+			}
+			return nil, fmt.Errorf("failed to watch the source files: %w", err)
+		case <-settled:
+			paths := make([]string, 0, len(changed))
+			for p := range changed {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			return paths, nil
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseLocations(t *testing.T) {
+	t.Run("returns the files in the workspace", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		out := `/ws/foo/foo.go:1:1: source file //foo:foo.go
+/ws/foo/BUILD.bazel:1:1: source file //foo:BUILD.bazel
+/cache/external/rules_go/go/def.bzl:1:1: source file @rules_go//go:def.bzl
+/ws/defs.bzl:1:1: source file //:defs.bzl
+/ws2/bar.go:1:1: source file //:bar.go
+`
+		files, err := parseLocations(strings.NewReader(out), "/ws")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(files).To(Equal([]string{"/ws/defs.bzl", "/ws/foo/BUILD.bazel", "/ws/foo/foo.go"}))
+	})
+}
+
+func TestFileWatcher(t *testing.T) {
+	setup := func(t *testing.T) (*fileWatcher, string) {
+		dir := t.TempDir()
+		for _, name := range []string{"BUILD.bazel", "foo.go"} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fw, err := newFileWatcher()
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { fw.Close() })
+		if err := fw.watch([]string{filepath.Join(dir, "BUILD.bazel"), filepath.Join(dir, "foo.go")}); err != nil {
+			t.Fatal(err)
+		}
+		return fw, dir
+	}
+
+	wait := func(fw *fileWatcher) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return fw.wait(ctx, 100*time.Millisecond)
+	}
+
+	t.Run("returns the changed files once they settle", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		fw, dir := setup(t)
+
+		g.Expect(os.WriteFile(filepath.Join(dir, "foo.go"), []byte("package foo\n"), 0644)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(dir, "BUILD.bazel"), []byte("# foo\n"), 0644)).To(Succeed())
+
+		changed, err := wait(fw)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changed).To(Equal([]string{filepath.Join(dir, "BUILD.bazel"), filepath.Join(dir, "foo.go")}))
+	})
+
+	t.Run("returns new files which may be matched by a glob", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		fw, dir := setup(t)
+
+		g.Expect(os.WriteFile(filepath.Join(dir, ".foo.go.swp"), []byte{}, 0644)).To(Succeed())
+		g.Expect(os.WriteFile(filepath.Join(dir, "bar.go"), []byte{}, 0644)).To(Succeed())
+
+		changed, err := wait(fw)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(changed).To(Equal([]string{filepath.Join(dir, "bar.go")}))
+	})
+
+	t.Run("stops watching the directories of files that are no longer used", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		fw, dir := setup(t)
+
+		other := t.TempDir()
+		g.Expect(fw.watch([]string{filepath.Join(other, "bar.go")})).To(Succeed())
+		g.Expect(fw.w.WatchList()).To(Equal([]string{other}))
+		g.Expect(fw.files).NotTo(HaveKey(filepath.Join(dir, "foo.go")))
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		fw, _ := setup(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := fw.wait(ctx, 10*time.Millisecond)
+		g.Expect(err).To(MatchError(context.Canceled))
+	})
+}
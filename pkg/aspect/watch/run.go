@@ -0,0 +1,211 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+)
+
+const (
+	// notifyChangesTag is the tag of the targets that are told about rebuilds on stdin rather
+	// than restarted, with the protocol of ibazel.
+	notifyChangesTag = "ibazel_notify_changes"
+
+	// stopTimeout is how long a program has to exit after SIGTERM before it's killed.
+	stopTimeout = 5 * time.Second
+)
+
+// watchRun builds the target with `bazel run --script_path` and runs the script itself, so that
+// the program keeps running while the target is rebuilt. Once a rebuild succeeds, the program is
+// restarted, or told about it on stdin when the target is tagged ibazel_notify_changes.
+func (w *watcher) watchRun(ctx context.Context, cmd *cobra.Command, args []string, next interceptors.RunEContextFn) error {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--script_path" || strings.HasPrefix(arg, "--script_path=") {
+			return fmt.Errorf("--%s can't be combined with --script_path", FlagName)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "aspect-watch-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "run.sh")
+	args = flags.AddFlagToCommand(args, "--script_path="+script)
+
+	notify, err := w.notifiesChanges()
+	if err != nil {
+		return err
+	}
+
+	fw, err := newFileWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	var program *child
+	defer func() {
+		if program != nil {
+			program.stop()
+		}
+	}()
+
+	for {
+		if err := w.update(fw); err != nil {
+			return err
+		}
+
+		if notify && program.running() {
+			program.notify("IBAZEL_BUILD_STARTED")
+		}
+		buildErr := next(ctx, cmd, args)
+		w.report(buildErr)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		switch {
+		case notify && program.running():
+			if buildErr != nil {
+				program.notify("IBAZEL_BUILD_COMPLETED FAILURE")
+			} else {
+				program.notify("IBAZEL_BUILD_COMPLETED SUCCESS")
+			}
+		case buildErr == nil:
+			// The previous program keeps running until the target builds again.
+			if program != nil {
+				program.stop()
+			}
+			if program, err = w.start(script, notify); err != nil {
+				return err
+			}
+		}
+
+		if err := w.waitForChanges(ctx, fw); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// notifiesChanges returns whether the target is tagged ibazel_notify_changes.
+func (w *watcher) notifiesChanges() (bool, error) {
+	query := fmt.Sprintf(`attr("tags", "\b%s\b", %s)`, notifyChangesTag, queryExpression(w.patterns))
+	stdout, err := w.query("--output=label", query)
+	if err != nil {
+		return false, fmt.Errorf("failed to query the tags of %s: %w", w.patterns[0], err)
+	}
+	return strings.TrimSpace(stdout.String()) != "", nil
+}
+
+// child is the program of a target run from the script written by `bazel run --script_path`.
+type child struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	// exited is closed once the program exits.
+	exited  chan struct{}
+	stopped atomic.Bool
+}
+
+func (w *watcher) start(script string, notify bool) (*child, error) {
+	cmd := exec.Command(script)
+	cmd.Stdout = w.streams.Stdout
+	cmd.Stderr = w.streams.Stderr
+	c := &child{cmd: cmd, exited: make(chan struct{})}
+	if notify {
+		cmd.Env = append(os.Environ(), "IBAZEL_NOTIFY_CHANGES=y")
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		c.stdin = stdin
+	} else {
+		cmd.Stdin = w.streams.Stdin
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", w.patterns[0], err)
+	}
+
+	go func() {
+		err := cmd.Wait()
+		if !c.stopped.Load() {
+			if err != nil {
+				fmt.Fprintf(w.streams.Stderr, "%s exited: %v\n", w.patterns[0], err)
+			} else {
+				fmt.Fprintf(w.streams.Stderr, "%s exited\n", w.patterns[0])
+			}
+		}
+		close(c.exited)
+	}()
+	return c, nil
+}
+
+func (c *child) running() bool {
+	if c == nil {
+		return false
+	}
+	select {
+	case <-c.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// notify writes a line of the ibazel protocol to the stdin of the program. A program that closed
+// its stdin doesn't want to be notified.
+func (c *child) notify(message string) {
+	_, _ = fmt.Fprintln(c.stdin, message)
+}
+
+// stop sends SIGTERM to the program and waits for it to exit, killing it if it takes longer than
+// the stop timeout.
+func (c *child) stop() {
+	c.stopped.Store(true)
+	if !c.running() {
+		return
+	}
+	if err := c.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		_ = c.cmd.Process.Kill()
+	}
+	select {
+	case <-c.exited:
+	case <-time.After(stopTimeout):
+		_ = c.cmd.Process.Kill()
+		<-c.exited
+	}
+}
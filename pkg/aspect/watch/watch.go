@@ -0,0 +1,232 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/interceptors"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+const (
+	// FlagName reruns the command whenever a source file of the targets changes.
+	FlagName = "watch"
+
+	// debounce is how long the source files must stay unchanged before the command reruns.
+	debounce = 100 * time.Millisecond
+)
+
+type watcher struct {
+	streams  ioutils.Streams
+	bzl      bazel.Bazel
+	command  string
+	patterns []string
+}
+
+// Interceptor reruns the interceptors that follow it and the command whenever a source file of
+// the targets changes when --watch is set. It must come before the BES backend interceptor so
+// that every invocation of Bazel gets its own BES backend and plugin hooks.
+func Interceptor(streams ioutils.Streams, bzl bazel.Bazel) interceptors.Interceptor {
+	return func(ctx context.Context, cmd *cobra.Command, args []string, next interceptors.RunEContextFn) error {
+		enabled, command := false, ""
+		var flagSet *pflag.FlagSet
+		if cmd != nil {
+			flagSet, command = cmd.Flags(), cmd.Name()
+			enabled, _ = flagSet.GetBool(FlagName)
+		}
+		args = bazel.RemoveFlags(command, flagSet, args, FlagName)
+		if !enabled {
+			return next(ctx, cmd, args)
+		}
+
+		w := &watcher{streams: streams, bzl: bzl, command: command}
+		var err error
+		if w.patterns, err = targetPatterns(w.command, args); err != nil {
+			return err
+		}
+		if len(w.patterns) == 0 {
+			return fmt.Errorf("--%s requires a target pattern", FlagName)
+		}
+
+		// Ctrl+C stops watching. Bazel and the program of `aspect run` get the signal as well
+		// since they are in the same process group.
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if w.command == "run" {
+			return w.watchRun(ctx, cmd, args, next)
+		}
+		return w.watch(ctx, cmd, args, next)
+	}
+}
+
+func (w *watcher) watch(ctx context.Context, cmd *cobra.Command, args []string, next interceptors.RunEContextFn) error {
+	fw, err := newFileWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	for {
+		if err := w.update(fw); err != nil {
+			return err
+		}
+		w.report(next(ctx, cmd, args))
+		if err := w.waitForChanges(ctx, fw); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// update watches the source files that the targets depend on now. A query that fails, for
+// example because a BUILD file is being edited, keeps the files of the previous query.
+func (w *watcher) update(fw *fileWatcher) error {
+	files, err := w.sourceFiles()
+	if err == nil {
+		err = fw.watch(files)
+	}
+	if err != nil && len(fw.files) == 0 {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(w.streams.Stderr, "Error: %v\n", err)
+	}
+	return nil
+}
+
+// report prints the errors of a run that Bazel didn't already print.
+func (w *watcher) report(err error) {
+	var exitErr *aspecterrors.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		fmt.Fprintf(w.streams.Stderr, "Error: %v\n", err)
+	}
+}
+
+func (w *watcher) waitForChanges(ctx context.Context, fw *fileWatcher) error {
+	fmt.Fprintf(w.streams.Stderr, "Watching %d source files for changes to rerun 'aspect %s', press Ctrl+C to stop.\n", len(fw.files), w.command)
+	changed, err := fw.wait(ctx, debounce)
+	if err != nil {
+		return err
+	}
+	what := w.relativePath(changed[0])
+	if len(changed) > 1 {
+		what = fmt.Sprintf("%s and %d other files", what, len(changed)-1)
+	}
+	fmt.Fprintf(w.streams.Stderr, "%s changed, rerunning 'aspect %s'\n", what, w.command)
+	return nil
+}
+
+func (w *watcher) relativePath(p string) string {
+	if rel, err := filepath.Rel(w.bzl.WorkspaceRoot(), p); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return p
+}
+
+// sourceFiles returns the paths of the source files in the workspace that the targets depend on,
+// including the BUILD and .bzl files that declare them.
+func (w *watcher) sourceFiles() ([]string, error) {
+	query := fmt.Sprintf(`let targets = %s in kind("source file", deps($targets)) + buildfiles(deps($targets))`, queryExpression(w.patterns))
+	stdout, err := w.query("--output=location", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the source files of %s: %w", strings.Join(w.patterns, " "), err)
+	}
+
+	workspaceRoot := w.bzl.WorkspaceRoot()
+	roots := []string{workspaceRoot}
+	if resolved, err := filepath.EvalSymlinks(workspaceRoot); err == nil && resolved != workspaceRoot {
+		roots = append(roots, resolved)
+	}
+	return parseLocations(stdout, roots...)
+}
+
+func (w *watcher) query(args ...string) (*bytes.Buffer, error) {
+	var stdout, stderr bytes.Buffer
+	streams := ioutils.Streams{Stdin: w.streams.Stdin, Stdout: &stdout, Stderr: &stderr}
+	bazelCmd := append([]string{"query", "--order_output=no"}, args...)
+	if err := w.bzl.RunCommand(streams, nil, bazelCmd...); err != nil {
+		return nil, fmt.Errorf("%w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return &stdout, nil
+}
+
+// targetPatterns returns the target patterns of the arguments of a command. Everything after a
+// double dash is a target pattern too, except for `run`, which passes it to the program.
+func targetPatterns(command string, args []string) ([]string, error) {
+	before, after := args, []string(nil)
+	if i := slices.Index(args, "--"); i >= 0 {
+		before, after = args[:i], args[i+1:]
+	}
+	// Subtracted patterns, such as -//legacy/..., look like short flags to SeparateBazelFlags.
+	var subtracted []string
+	before = slices.DeleteFunc(slices.Clone(before), func(arg string) bool {
+		if strings.HasPrefix(arg, "-/") || strings.HasPrefix(arg, "-@") {
+			subtracted = append(subtracted, arg)
+			return true
+		}
+		return false
+	})
+	patterns, _, err := bazel.SeparateBazelFlags(command, before)
+	if err != nil {
+		return nil, err
+	}
+	if command == "run" {
+		// The arguments that follow the target are passed to the program.
+		return patterns[:min(len(patterns), 1)], nil
+	}
+	patterns = append(patterns, subtracted...)
+	return append(patterns, after...), nil
+}
+
+// queryExpression returns the query expression of target patterns. Patterns starting with - are
+// subtracted from the patterns that come before them, as they are on the command line of
+// `bazel build`.
+func queryExpression(patterns []string) string {
+	var b strings.Builder
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "-") {
+			if b.Len() > 0 {
+				fmt.Fprintf(&b, " - %q", p[1:])
+			}
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(" + ")
+		}
+		fmt.Fprintf(&b, "%q", p)
+	}
+	return b.String()
+}
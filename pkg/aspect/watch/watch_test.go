@@ -0,0 +1,205 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+
+	"github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+// syncBuffer is written to by the programs run in the background.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestTargetPatterns(t *testing.T) {
+	t.Run("includes the patterns after a double dash", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		patterns, err := targetPatterns("build", []string{"//foo/...", "-//foo/legacy/...", "--", "-//foo/bar"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(patterns).To(Equal([]string{"//foo/...", "-//foo/legacy/...", "-//foo/bar"}))
+		g.Expect(queryExpression(patterns)).To(Equal(`"//foo/..." - "//foo/legacy/..." - "//foo/bar"`))
+	})
+
+	t.Run("only includes the target of run", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		patterns, err := targetPatterns("run", []string{"//foo:bin", "arg", "--", "//not/a:target"})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(patterns).To(Equal([]string{"//foo:bin"}))
+	})
+
+	t.Run("adds up the patterns", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		g.Expect(queryExpression([]string{"-//a", "//b/...", "//c:c", "-//b/d/..."})).To(Equal(`"//b/..." + "//c:c" - "//b/d/..."`))
+	})
+}
+
+func TestInterceptor(t *testing.T) {
+	newCmd := func(name string, watch bool) *cobra.Command {
+		cmd := &cobra.Command{Use: name}
+		cmd.Flags().Bool(FlagName, false, "")
+		if watch {
+			cmd.Flags().Set(FlagName, "true")
+		}
+		return cmd
+	}
+
+	// expectSourceFiles makes the query of the source files of the targets return files.
+	expectSourceFiles := func(bzl *mock.MockBazel, workspaceRoot string, files ...string) {
+		bzl.EXPECT().WorkspaceRoot().Return(workspaceRoot).AnyTimes()
+		bzl.
+			EXPECT().
+			RunCommand(gomock.Any(), nil, "query", "--order_output=no", "--output=location", gomock.Any()).
+			DoAndReturn(func(streams ioutils.Streams, _ *string, _ ...string) error {
+				for _, f := range files {
+					fmt.Fprintf(streams.Stdout, "%s:1:1: source file //:%s\n", f, filepath.Base(f))
+				}
+				return nil
+			}).
+			AnyTimes()
+	}
+
+	t.Run("runs the command once without --watch", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		calls := 0
+		err := Interceptor(ioutils.Streams{}, mock.NewMockBazel(ctrl))(context.Background(), newCmd("build", false),
+			[]string{"//foo", "--watch=false", "--nowatch", "--watchfs", "--", "--watch"},
+			func(_ context.Context, _ *cobra.Command, args []string) error {
+				calls++
+				g.Expect(args).To(Equal([]string{"//foo", "--watchfs", "--", "--watch"}))
+				return nil
+			})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls).To(Equal(1))
+	})
+
+	t.Run("reruns the command when a source file changes", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		workspaceRoot := t.TempDir()
+		src := filepath.Join(workspaceRoot, "foo.go")
+		g.Expect(os.WriteFile(src, []byte{}, 0644)).To(Succeed())
+
+		bzl := mock.NewMockBazel(ctrl)
+		expectSourceFiles(bzl, workspaceRoot, src)
+
+		var stderr syncBuffer
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		calls := 0
+		err := Interceptor(ioutils.Streams{Stderr: &stderr}, bzl)(ctx, newCmd("build", true), []string{"--watch", "//:foo"},
+			func(_ context.Context, _ *cobra.Command, args []string) error {
+				calls++
+				g.Expect(args).To(Equal([]string{"//:foo"}))
+				if calls == 1 {
+					go func() {
+						time.Sleep(50 * time.Millisecond)
+						os.WriteFile(src, []byte("package foo\n"), 0644)
+					}()
+				} else {
+					cancel()
+				}
+				return nil
+			})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls).To(Equal(2))
+		g.Expect(stderr.String()).To(ContainSubstring("Watching 1 source files for changes to rerun 'aspect build'"))
+		g.Expect(stderr.String()).To(ContainSubstring("foo.go changed, rerunning 'aspect build'"))
+	})
+
+	t.Run("restarts the program of run once it builds again", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		workspaceRoot := t.TempDir()
+		src := filepath.Join(workspaceRoot, "main.sh")
+		g.Expect(os.WriteFile(src, []byte{}, 0644)).To(Succeed())
+
+		bzl := mock.NewMockBazel(ctrl)
+		expectSourceFiles(bzl, workspaceRoot, src)
+		bzl.
+			EXPECT().
+			RunCommand(gomock.Any(), nil, "query", "--order_output=no", "--output=label", `attr("tags", "\bibazel_notify_changes\b", "//:bin")`).
+			Return(nil)
+
+		var stdout, stderr syncBuffer
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		calls := 0
+		err := Interceptor(ioutils.Streams{Stdout: &stdout, Stderr: &stderr}, bzl)(ctx, newCmd("run", true), []string{"//:bin", "--", "arg"},
+			func(_ context.Context, _ *cobra.Command, args []string) error {
+				calls++
+				g.Expect(args).To(HaveLen(4))
+				g.Expect(args[1]).To(HavePrefix("--script_path="))
+				g.Expect(args[2:]).To(Equal([]string{"--", "arg"}))
+
+				// Like `bazel run --script_path`, write a script that runs the program.
+				script := strings.TrimPrefix(args[1], "--script_path=")
+				g.Expect(os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\necho run %d\nexec sleep 60\n", calls)), 0755)).To(Succeed())
+				go func() {
+					if calls == 1 {
+						g.Eventually(stdout.String).Should(ContainSubstring("run 1"))
+						os.WriteFile(src, []byte("echo\n"), 0644)
+					} else {
+						g.Eventually(stdout.String).Should(ContainSubstring("run 2"))
+						cancel()
+					}
+				}()
+				return nil
+			})
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(calls).To(Equal(2))
+		g.Expect(stdout.String()).To(Equal("run 1\nrun 2\n"))
+		// The program was stopped rather than exiting by itself.
+		g.Expect(stderr.String()).NotTo(ContainSubstring("exited"))
+	})
+}
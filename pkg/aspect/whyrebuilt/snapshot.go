@@ -0,0 +1,231 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package whyrebuilt
+
+import (
+	"sort"
+	"strings"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+)
+
+const besBackendPrefix = "--bes_backend="
+
+// snapshot is the state of an invocation that determines which actions Bazel
+// had to execute.
+type snapshot struct {
+	invocation     *history.Invocation
+	startupOptions []string
+	options        []string
+	configurations map[string]*buildeventstream.Configuration
+	status         map[string]string
+	executed       []*buildeventstream.ActionExecuted
+}
+
+func newSnapshot(invocation *history.Invocation, events []*buildeventstream.BuildEvent) *snapshot {
+	s := &snapshot{
+		invocation:     invocation,
+		configurations: map[string]*buildeventstream.Configuration{},
+		status:         map[string]string{},
+	}
+	aspectBesBackend := ""
+	for _, event := range events {
+		switch {
+		case event.GetOptionsParsed() != nil:
+			s.startupOptions = event.GetOptionsParsed().GetStartupOptions()
+			s.options = event.GetOptionsParsed().GetCmdLine()
+		case event.GetStructuredCommandLine() != nil:
+			if event.GetId().GetStructuredCommandLine().GetCommandLineLabel() != "canonical" {
+				continue
+			}
+			for _, section := range event.GetStructuredCommandLine().GetSections() {
+				for _, o := range section.GetOptionList().GetOption() {
+					if value, ok := strings.CutPrefix(o.GetCombinedForm(), besBackendPrefix); ok {
+						aspectBesBackend = value
+					}
+				}
+			}
+		case event.GetConfiguration() != nil:
+			s.configurations[event.GetId().GetConfiguration().GetId()] = event.GetConfiguration()
+		case event.GetWorkspaceStatus() != nil:
+			for _, item := range event.GetWorkspaceStatus().GetItem() {
+				s.status[item.GetKey()] = item.GetValue()
+			}
+		case event.GetAction() != nil:
+			s.executed = append(s.executed, event.GetAction())
+		}
+	}
+
+	// The BES backend that Aspect CLI adds listens on a new port for every
+	// invocation and doesn't affect the build.
+	if aspectBesBackend != "" {
+		options := []string{}
+		for _, o := range s.options {
+			if o != besBackendPrefix+aspectBesBackend {
+				options = append(options, o)
+			}
+		}
+		s.options = options
+	}
+	return s
+}
+
+// diffOptions returns the options that only occur in a and the options that
+// only occur in b. Repeated options are compared by their number of
+// occurrences.
+func diffOptions(a, b []string) (removed, added []string) {
+	counts := map[string]int{}
+	for _, o := range a {
+		counts[o]++
+	}
+	for _, o := range b {
+		if counts[o] > 0 {
+			counts[o]--
+			continue
+		}
+		added = append(added, o)
+	}
+	for _, o := range a {
+		if counts[o] > 0 {
+			counts[o]--
+			removed = append(removed, o)
+		}
+	}
+	return removed, added
+}
+
+// valueChange is a key whose value differs between two invocations.
+type valueChange struct {
+	key           string
+	before, after string
+	// added and removed are set when the key is missing from one of the
+	// invocations.
+	added, removed bool
+}
+
+func diffValues(a, b map[string]string) []valueChange {
+	changes := []valueChange{}
+	for k, before := range a {
+		after, ok := b[k]
+		if !ok || after != before {
+			changes = append(changes, valueChange{key: k, before: before, after: after, removed: !ok})
+		}
+	}
+	for k, after := range b {
+		if _, ok := a[k]; !ok {
+			changes = append(changes, valueChange{key: k, after: after, added: true})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].key < changes[j].key })
+	return changes
+}
+
+// configurationChange is a configuration that is only used by one of the
+// invocations. A configuration of b is paired with the configuration of a that
+// it most likely replaced, so that their differences can be shown.
+type configurationChange struct {
+	beforeID, afterID string
+	before, after     *buildeventstream.Configuration
+}
+
+func diffConfigurations(a, b map[string]*buildeventstream.Configuration) []configurationChange {
+	removed := map[string]*buildeventstream.Configuration{}
+	for id, c := range a {
+		if _, ok := b[id]; !ok {
+			removed[id] = c
+		}
+	}
+	added := []string{}
+	for id := range b {
+		if _, ok := a[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	sort.Strings(added)
+
+	changes := []configurationChange{}
+	pair := func(matches func(before, after *buildeventstream.Configuration) bool) {
+		unpaired := []string{}
+		for _, id := range added {
+			found := ""
+			for beforeID, before := range removed {
+				if matches(before, b[id]) && (found == "" || beforeID < found) {
+					found = beforeID
+				}
+			}
+			if found == "" {
+				unpaired = append(unpaired, id)
+				continue
+			}
+			changes = append(changes, configurationChange{beforeID: found, before: removed[found], afterID: id, after: b[id]})
+			delete(removed, found)
+		}
+		added = unpaired
+	}
+	// Prefer configurations with the same mnemonic, then fall back to the same
+	// kind of configuration, since changing the compilation mode or CPU
+	// changes the mnemonic.
+	pair(func(before, after *buildeventstream.Configuration) bool {
+		return before.GetMnemonic() == after.GetMnemonic()
+	})
+	pair(func(before, after *buildeventstream.Configuration) bool {
+		return before.GetIsTool() == after.GetIsTool()
+	})
+	for _, id := range added {
+		changes = append(changes, configurationChange{afterID: id, after: b[id]})
+	}
+	for id, c := range removed {
+		changes = append(changes, configurationChange{beforeID: id, before: c})
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].mnemonic() < changes[j].mnemonic()
+	})
+	return changes
+}
+
+func (c configurationChange) mnemonic() string {
+	if c.after != nil {
+		return c.after.GetMnemonic()
+	}
+	return c.before.GetMnemonic()
+}
+
+// configurationValues flattens the fields of a configuration that affect
+// action keys so that they can be compared.
+func configurationValues(c *buildeventstream.Configuration) map[string]string {
+	values := map[string]string{
+		"platform": c.GetPlatformName(),
+		"cpu":      c.GetCpu(),
+	}
+	for k, v := range c.GetMakeVariable() {
+		values["make variable "+k] = v
+	}
+	return values
+}
+
+// isStableStatusKey reports whether a change to a workspace status key
+// invalidates the actions that depend on it. See
+// https://bazel.build/docs/user-manual#workspace-status.
+func isStableStatusKey(key string) bool {
+	switch key {
+	case "BUILD_EMBED_LABEL", "BUILD_HOST", "BUILD_USER":
+		return true
+	}
+	return strings.HasPrefix(key, "STABLE_")
+}
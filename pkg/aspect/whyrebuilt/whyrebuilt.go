@@ -0,0 +1,281 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package whyrebuilt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+var (
+	bold  = color.New(color.Bold)
+	faint = color.New(color.Faint)
+	red   = color.New(color.FgRed)
+	green = color.New(color.FgGreen)
+)
+
+// maxListedActions is the number of executed actions that are listed
+// individually before only the counts per mnemonic are printed.
+const maxListedActions = 50
+
+// WhyRebuilt represents the aspect why-rebuilt command.
+type WhyRebuilt struct {
+	ioutils.Streams
+	bzl       bazel.Bazel
+	openStore func() (*history.Store, error)
+}
+
+// New creates a WhyRebuilt command.
+func New(streams ioutils.Streams, bzl bazel.Bazel) *WhyRebuilt {
+	return &WhyRebuilt{
+		Streams:   streams,
+		bzl:       bzl,
+		openStore: history.NewDefaultStore,
+	}
+}
+
+// Run compares two recorded invocations and prints the differences that
+// cause Bazel to execute actions again.
+func (runner *WhyRebuilt) Run(ctx context.Context, cmd *cobra.Command, args []string) error {
+	store, err := runner.openStore()
+	if err != nil {
+		return err
+	}
+	a, b, err := resolveInvocations(store, runner.bzl.WorkspaceRoot(), args)
+	if err != nil {
+		return err
+	}
+
+	before, err := loadSnapshot(store, a)
+	if err != nil {
+		return err
+	}
+	after, err := loadSnapshot(store, b)
+	if err != nil {
+		return err
+	}
+
+	w := runner.Stdout
+	bold.Fprintln(w, "Comparing invocations")
+	printInvocation(w, "A", a)
+	printInvocation(w, "B", b)
+
+	causes := 0
+	causes += printOptions(w, "Startup options", before.startupOptions, after.startupOptions)
+	causes += printOptions(w, "Options", before.options, after.options)
+	causes += printConfigurations(w, before, after)
+	causes += printWorkspaceStatus(w, before, after)
+	printActions(w, before, after)
+
+	if causes == 0 {
+		fmt.Fprintf(w, "\nNo option, configuration or workspace status changes were found. Actions that were executed\n")
+		fmt.Fprintf(w, "again were likely invalidated by changes to their source files.\n")
+	}
+	return nil
+}
+
+// resolveInvocations returns the invocations to compare. B defaults to the
+// latest invocation in the workspace and A to the invocation before B in the
+// same workspace. Outside of a workspace, B defaults to the latest invocation.
+func resolveInvocations(store *history.Store, workspaceRoot string, args []string) (*history.Invocation, *history.Invocation, error) {
+	var a, b *history.Invocation
+	var err error
+	if len(args) == 2 {
+		if b, err = store.Get(args[1]); err != nil {
+			return nil, nil, err
+		}
+	} else if b, err = store.Latest(func(i *history.Invocation) bool {
+		return workspaceRoot == "" || i.WorkspaceDirectory == workspaceRoot
+	}); err != nil {
+		if workspaceRoot == "" {
+			return nil, nil, fmt.Errorf("no invocations have been recorded yet")
+		}
+		return nil, nil, fmt.Errorf("no invocations have been recorded in %s yet", workspaceRoot)
+	}
+	if len(args) > 0 {
+		if a, err = store.Get(args[0]); err != nil {
+			return nil, nil, err
+		}
+		if a.ID == b.ID {
+			return nil, nil, fmt.Errorf("can't compare invocation %s with itself", a.ID)
+		}
+		return a, b, nil
+	}
+	a, err = store.Latest(func(i *history.Invocation) bool {
+		return i.ID != b.ID && i.WorkspaceDirectory == b.WorkspaceDirectory && i.StartTime.Before(b.StartTime)
+	})
+	var notFound *history.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil, fmt.Errorf("no invocation was recorded before %s in %s", b.ID, b.WorkspaceDirectory)
+	}
+	return a, b, err
+}
+
+func loadSnapshot(store *history.Store, invocation *history.Invocation) (*snapshot, error) {
+	events, err := store.Events(invocation.ID)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshot(invocation, events), nil
+}
+
+func printInvocation(w io.Writer, name string, i *history.Invocation) {
+	fmt.Fprintf(w, "  %s: %s  %s  %s %s\n", name, i.ID, i.StartTime.Local().Format(time.DateTime), i.Command, strings.Join(i.Targets, " "))
+}
+
+func printOptions(w io.Writer, title string, a, b []string) int {
+	removed, added := diffOptions(a, b)
+	if len(removed) == 0 && len(added) == 0 {
+		return 0
+	}
+	bold.Fprintf(w, "\n%s:\n", title)
+	for _, o := range removed {
+		red.Fprintf(w, "  - %s\n", o)
+	}
+	for _, o := range added {
+		green.Fprintf(w, "  + %s\n", o)
+	}
+	return len(removed) + len(added)
+}
+
+func printConfigurations(w io.Writer, a, b *snapshot) int {
+	changes := diffConfigurations(a.configurations, b.configurations)
+	if len(changes) == 0 {
+		return 0
+	}
+	bold.Fprintf(w, "\nConfigurations:\n")
+	for _, c := range changes {
+		switch {
+		case c.before == nil:
+			green.Fprintf(w, "  + %s %s\n", configurationName(c.after), c.afterID)
+		case c.after == nil:
+			red.Fprintf(w, "  - %s %s\n", configurationName(c.before), c.beforeID)
+		default:
+			fmt.Fprintf(w, "  %s %s -> %s %s\n", configurationName(c.before), c.beforeID, configurationName(c.after), c.afterID)
+			printValueChanges(w, "    ", diffValues(configurationValues(c.before), configurationValues(c.after)))
+		}
+	}
+	return len(changes)
+}
+
+func configurationName(c *buildeventstream.Configuration) string {
+	if c.GetIsTool() {
+		return c.GetMnemonic() + " (exec)"
+	}
+	return c.GetMnemonic()
+}
+
+func printWorkspaceStatus(w io.Writer, a, b *snapshot) int {
+	causes := 0
+	stable := []valueChange{}
+	volatile := []string{}
+	for _, c := range diffValues(a.status, b.status) {
+		if isStableStatusKey(c.key) {
+			stable = append(stable, c)
+		} else {
+			volatile = append(volatile, c.key)
+		}
+	}
+	if len(stable) == 0 && len(volatile) == 0 {
+		return 0
+	}
+	bold.Fprintf(w, "\nWorkspace status:\n")
+	printValueChanges(w, "  ", stable)
+	causes += len(stable)
+	if len(volatile) > 0 {
+		faint.Fprintf(w, "  Volatile keys changed, which doesn't cause actions to rerun: %s\n", strings.Join(volatile, ", "))
+	}
+	return causes
+}
+
+func printValueChanges(w io.Writer, indent string, changes []valueChange) {
+	for _, c := range changes {
+		switch {
+		case c.added:
+			green.Fprintf(w, "%s+ %s: %s\n", indent, c.key, c.after)
+		case c.removed:
+			red.Fprintf(w, "%s- %s: %s\n", indent, c.key, c.before)
+		default:
+			fmt.Fprintf(w, "%s%s: %s -> %s\n", indent, c.key, c.before, c.after)
+		}
+	}
+}
+
+func printActions(w io.Writer, a, b *snapshot) {
+	bold.Fprintf(w, "\nActions:\n")
+	printActionStats(w, "A", a.invocation.Actions)
+	printActionStats(w, "B", b.invocation.Actions)
+
+	if len(b.executed) == 0 {
+		if b.invocation.Actions != nil && b.invocation.Actions.Executed > 0 {
+			faint.Fprintf(w, "  Pass --build_event_publish_all_actions to list the actions that were executed.\n")
+		}
+		return
+	}
+
+	byMnemonic := map[string]int{}
+	for _, action := range b.executed {
+		byMnemonic[action.GetType()]++
+	}
+	mnemonics := make([]string, 0, len(byMnemonic))
+	for m := range byMnemonic {
+		mnemonics = append(mnemonics, m)
+	}
+	sort.Slice(mnemonics, func(i, j int) bool {
+		if byMnemonic[mnemonics[i]] != byMnemonic[mnemonics[j]] {
+			return byMnemonic[mnemonics[i]] > byMnemonic[mnemonics[j]]
+		}
+		return mnemonics[i] < mnemonics[j]
+	})
+	fmt.Fprintf(w, "\n  Executed in B by mnemonic:\n")
+	for _, m := range mnemonics {
+		fmt.Fprintf(w, "  %6d %s\n", byMnemonic[m], m)
+	}
+
+	if len(b.executed) > maxListedActions {
+		return
+	}
+	fmt.Fprintf(w, "\n  Executed in B:\n")
+	for _, action := range b.executed {
+		fmt.Fprintf(w, "    %s %s\n", action.GetType(), action.GetLabel())
+	}
+}
+
+func printActionStats(w io.Writer, name string, stats *history.ActionStats) {
+	if stats == nil {
+		fmt.Fprintf(w, "  %s: no build metrics were recorded\n", name)
+		return
+	}
+	fmt.Fprintf(w, "  %s: %d of %d actions executed", name, stats.Executed, stats.Created)
+	if rate, ok := stats.CacheHitRate(); ok {
+		fmt.Fprintf(w, ", %.0f%% cache hits", rate*100)
+	}
+	fmt.Fprintln(w)
+}
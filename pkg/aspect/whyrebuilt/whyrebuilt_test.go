@@ -0,0 +1,272 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package whyrebuilt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/bazel/command_line"
+	"github.com/aspect-build/aspect-cli/pkg/bazel"
+	bazel_mock "github.com/aspect-build/aspect-cli/pkg/bazel/mock"
+	"github.com/aspect-build/aspect-cli/pkg/history"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils"
+)
+
+type invocation struct {
+	id           string
+	start        time.Time
+	workspace    string
+	options      []string
+	besBackend   string
+	configID     string
+	compileMode  string
+	status       map[string]string
+	executed     []string
+	actionsTotal int64
+}
+
+func (i invocation) events() []*buildeventstream.BuildEvent {
+	events := []*buildeventstream.BuildEvent{
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Started{Started: &buildeventstream.BuildEventId_BuildStartedId{}}},
+			Payload: &buildeventstream.BuildEvent_Started{Started: &buildeventstream.BuildStarted{
+				Uuid:               i.id,
+				Command:            "build",
+				StartTime:          timestamppb.New(i.start),
+				WorkspaceDirectory: i.workspace,
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_OptionsParsed{OptionsParsed: &buildeventstream.BuildEventId_OptionsParsedId{}}},
+			Payload: &buildeventstream.BuildEvent_OptionsParsed{OptionsParsed: &buildeventstream.OptionsParsed{
+				CmdLine: append(i.options, "--bes_backend="+i.besBackend),
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_StructuredCommandLine{StructuredCommandLine: &buildeventstream.BuildEventId_StructuredCommandLineId{CommandLineLabel: "canonical"}}},
+			Payload: &buildeventstream.BuildEvent_StructuredCommandLine{StructuredCommandLine: &command_line.CommandLine{
+				Sections: []*command_line.CommandLineSection{{
+					SectionLabel: "command options",
+					SectionType: &command_line.CommandLineSection_OptionList{OptionList: &command_line.OptionList{
+						Option: []*command_line.Option{{CombinedForm: "--bes_backend=" + i.besBackend}},
+					}},
+				}},
+			}},
+		},
+		{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Configuration{Configuration: &buildeventstream.BuildEventId_ConfigurationId{Id: i.configID}}},
+			Payload: &buildeventstream.BuildEvent_Configuration{Configuration: &buildeventstream.Configuration{
+				Mnemonic:     "k8-" + i.compileMode,
+				Cpu:          "k8",
+				MakeVariable: map[string]string{"COMPILATION_MODE": i.compileMode},
+			}},
+		},
+	}
+	status := &buildeventstream.WorkspaceStatus{}
+	for k, v := range i.status {
+		status.Item = append(status.Item, &buildeventstream.WorkspaceStatus_Item{Key: k, Value: v})
+	}
+	events = append(events, &buildeventstream.BuildEvent{
+		Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_WorkspaceStatus{WorkspaceStatus: &buildeventstream.BuildEventId_WorkspaceStatusId{}}},
+		Payload: &buildeventstream.BuildEvent_WorkspaceStatus{WorkspaceStatus: status},
+	})
+	for _, label := range i.executed {
+		events = append(events, &buildeventstream.BuildEvent{
+			Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_ActionCompleted{ActionCompleted: &buildeventstream.BuildEventId_ActionCompletedId{Label: label}}},
+			Payload: &buildeventstream.BuildEvent_Action{Action: &buildeventstream.ActionExecuted{Label: label, Type: "GoCompile", Success: true}},
+		})
+	}
+	events = append(events, &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_BuildMetrics{BuildMetrics: &buildeventstream.BuildEventId_BuildMetricsId{}}},
+		Payload: &buildeventstream.BuildEvent_BuildMetrics{BuildMetrics: &buildeventstream.BuildMetrics{
+			ActionSummary: &buildeventstream.BuildMetrics_ActionSummary{
+				ActionsCreated:  i.actionsTotal,
+				ActionsExecuted: int64(len(i.executed)),
+			},
+		}},
+	}, &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_BuildFinished{BuildFinished: &buildeventstream.BuildEventId_BuildFinishedId{}}},
+		Payload: &buildeventstream.BuildEvent_Finished{Finished: &buildeventstream.BuildFinished{
+			ExitCode:   &buildeventstream.BuildFinished_ExitCode{Name: "SUCCESS"},
+			FinishTime: timestamppb.New(i.start.Add(time.Minute)),
+		}},
+		LastMessage: true,
+	})
+	return events
+}
+
+func record(t *testing.T, store *history.Store, invocations ...invocation) {
+	for _, i := range invocations {
+		recorder := history.NewRecorder(store, history.Retention{})
+		for seq, e := range i.events() {
+			if err := recorder.Callback(e, int64(seq)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// workspace returns a Bazel whose workspace root is root.
+func workspace(ctrl *gomock.Controller, root string) bazel.Bazel {
+	bzl := bazel_mock.NewMockBazel(ctrl)
+	bzl.EXPECT().WorkspaceRoot().Return(root).AnyTimes()
+	return bzl
+}
+
+func TestWhyRebuilt(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := invocation{
+		id:           "aaaa",
+		start:        start,
+		workspace:    "/workspace",
+		options:      []string{"--config=ci", "--copt=-O1"},
+		besBackend:   "grpc://127.0.0.1:1111",
+		configID:     "cfg1",
+		compileMode:  "fastbuild",
+		status:       map[string]string{"STABLE_GIT_COMMIT": "abc", "BUILD_TIMESTAMP": "1"},
+		actionsTotal: 10,
+	}
+
+	t.Run("compares the latest invocation with the one before it", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		b := invocation{
+			id:           "bbbb",
+			start:        start.Add(time.Hour),
+			workspace:    "/workspace",
+			options:      []string{"--config=ci", "--copt=-O2"},
+			besBackend:   "grpc://127.0.0.1:2222",
+			configID:     "cfg2",
+			compileMode:  "opt",
+			status:       map[string]string{"STABLE_GIT_COMMIT": "def", "BUILD_TIMESTAMP": "2"},
+			executed:     []string{"//foo:foo", "//bar:bar"},
+			actionsTotal: 10,
+		}
+		store := history.NewStore(t.TempDir())
+		record(t, store, a, b)
+
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, workspace(ctrl, "/workspace"))
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		g.Expect(runner.Run(context.Background(), nil, []string{})).To(Succeed())
+
+		out := stdout.String()
+		g.Expect(out).To(ContainSubstring("A: aaaa"))
+		g.Expect(out).To(ContainSubstring("B: bbbb"))
+		g.Expect(out).To(ContainSubstring("  - --copt=-O1\n  + --copt=-O2\n"))
+		g.Expect(out).NotTo(ContainSubstring("--bes_backend"))
+		g.Expect(out).To(ContainSubstring("make variable COMPILATION_MODE: fastbuild -> opt"))
+		g.Expect(out).To(ContainSubstring("STABLE_GIT_COMMIT: abc -> def"))
+		g.Expect(out).To(ContainSubstring("Volatile keys changed, which doesn't cause actions to rerun: BUILD_TIMESTAMP"))
+		g.Expect(out).To(ContainSubstring("B: 2 of 10 actions executed, 80% cache hits"))
+		g.Expect(out).To(ContainSubstring(fmt.Sprintf("%6d GoCompile", 2)))
+		g.Expect(out).To(ContainSubstring("GoCompile //bar:bar"))
+	})
+
+	t.Run("reports when no causes were found", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		b := a
+		b.id = "bbbb"
+		b.start = start.Add(time.Hour)
+		store := history.NewStore(t.TempDir())
+		record(t, store, a, b)
+
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, workspace(ctrl, "/workspace"))
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		g.Expect(runner.Run(context.Background(), nil, []string{"aaaa", "bbbb"})).To(Succeed())
+
+		g.Expect(stdout.String()).To(ContainSubstring("No option, configuration or workspace status changes were found"))
+	})
+
+	t.Run("only compares invocations in the current workspace", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		b := a
+		b.id = "bbbb"
+		b.start = start.Add(time.Hour)
+		other := a
+		other.id = "cccc"
+		other.start = start.Add(2 * time.Hour)
+		other.workspace = "/other"
+		store := history.NewStore(t.TempDir())
+		record(t, store, a, b, other)
+
+		var stdout strings.Builder
+		runner := New(ioutils.Streams{Stdout: &stdout}, workspace(ctrl, "/workspace"))
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		g.Expect(runner.Run(context.Background(), nil, []string{})).To(Succeed())
+
+		g.Expect(stdout.String()).To(ContainSubstring("A: aaaa"))
+		g.Expect(stdout.String()).To(ContainSubstring("B: bbbb"))
+	})
+
+	t.Run("rejects comparing an invocation with itself", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := history.NewStore(t.TempDir())
+		record(t, store, a)
+
+		runner := New(ioutils.Streams{}, workspace(ctrl, "/workspace"))
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		err := runner.Run(context.Background(), nil, []string{"aaaa"})
+		g.Expect(err).To(MatchError("can't compare invocation aaaa with itself"))
+	})
+
+	t.Run("fails when there is no earlier invocation to compare with", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := history.NewStore(t.TempDir())
+		record(t, store, a)
+
+		runner := New(ioutils.Streams{}, workspace(ctrl, "/workspace"))
+		runner.openStore = func() (*history.Store, error) { return store, nil }
+		err := runner.Run(context.Background(), nil, []string{})
+		g.Expect(err).To(MatchError("no invocation was recorded before aaaa in /workspace"))
+	})
+}
+
+func TestDiffOptions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	removed, added := diffOptions(
+		[]string{"--copt=-g", "--copt=-g", "--config=ci"},
+		[]string{"--config=ci", "--copt=-g", "--define=x=1"},
+	)
+	g.Expect(removed).To(Equal([]string{"--copt=-g"}))
+	g.Expect(added).To(Equal([]string{"--define=x=1"}))
+}
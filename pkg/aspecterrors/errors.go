@@ -26,10 +26,13 @@ const (
 	UnhandledOrInternalError = 37
 
 	// Aspect CLI specific exit codes: 100 - ~200
-	ConfigureFixed    = 110
-	ConfigureDiff     = 111
-	ConfigureNoConfig = 112
-	LintFailure       = 113
+	ConfigureFixed       = 110
+	ConfigureDiff        = 111
+	ConfigureNoConfig    = 112
+	LintFailure          = 113
+	OutputsChanged       = 114
+	DependencyViolations = 115
+	CoverageBelowMinimum = 116
 
 	// Aspect Workflows specific exit codes: 200+
 )
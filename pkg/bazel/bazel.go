@@ -505,6 +505,8 @@ type Output struct {
 	Label    string
 	Mnemonic string
 	Path     string
+	// Configuration is the mnemonic of the configuration of the action, such as k8-fastbuild.
+	Configuration string
 }
 
 // ParseOutputs reads the proto result of AQuery and extracts the output file paths with their generator mnemonics.
@@ -523,6 +525,10 @@ func ParseOutputs(agc *analysis.ActionGraphContainer) []Output {
 	for _, t := range agc.Targets {
 		targets[t.Id] = t
 	}
+	configurations := make(map[uint32]*analysis.Configuration)
+	for _, c := range agc.Configuration {
+		configurations[c.Id] = c
+	}
 
 	// The paths in the proto data are organized as a trie
 	// to make the representation more compact.
@@ -562,9 +568,10 @@ func ParseOutputs(agc *analysis.ActionGraphContainer) []Output {
 				}
 			}
 			result = append(result, Output{
-				targets[a.TargetId].Label,
-				a.Mnemonic,
-				path.String(),
+				Label:         targets[a.TargetId].Label,
+				Mnemonic:      a.Mnemonic,
+				Path:          path.String(),
+				Configuration: configurations[a.ConfigurationId].GetMnemonic(),
 			})
 		}
 	}
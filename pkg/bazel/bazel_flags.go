@@ -74,8 +74,11 @@ var (
 
 	// List of all commands with label as inputs
 	commandsWithLabelInput = map[string]struct{}{
+		"affected":       {},
 		"aquery":         {},
+		"aquery-diff":    {},
 		"build":          {},
+		"check-deps":     {},
 		"coverage":       {},
 		"cquery":         {},
 		"fetch":          {},
@@ -175,8 +178,12 @@ func (b *bazel) AddBazelFlags(cmd *cobra.Command) error {
 		for _, commandName := range flag.Commands {
 			commandNames := []string{commandName}
 			if commandName == "aquery" {
-				// outputs call aquery under the hood and accept all aquery flags
-				commandNames = append(commandNames, "outputs")
+				// outputs and aquery-diff call aquery under the hood and accept all aquery flags
+				commandNames = append(commandNames, "outputs", "aquery-diff")
+			}
+			if commandName == "query" {
+				// affected and check-deps call query under the hood and accept all query flags
+				commandNames = append(commandNames, "affected", "check-deps")
 			}
 			if commandName == "build" {
 				// lint calls build under the hood and accepts all build flags
@@ -185,7 +192,10 @@ func (b *bazel) AddBazelFlags(cmd *cobra.Command) error {
 			for _, n := range commandNames {
 				if c, ok := commands[n]; ok {
 					c.DisableFlagParsing = true // only want to disable flag parsing on commands that call out to bazel
-					addFlagToFlagSet(flag, c.Flags(), !documented)
+					// Flags defined by Aspect CLI, such as `outputs --output`, shadow the Bazel flag of the same name.
+					if c.Flags().Lookup(flagName) == nil {
+						addFlagToFlagSet(flag, c.Flags(), !documented)
+					}
 
 					// Collect all the commands that have at least one flag defined for completion.
 					// The subset of commands with label inputs (commandsWithLabelInput) for
@@ -493,3 +503,80 @@ func isDocumented(flag string) bool {
 	}
 	return false
 }
+
+// RemoveFlags removes the named flags of Aspect CLI, and their values, from the arguments of the
+// given bazel command, keeping the order of the remaining arguments. aspectFlags defines whether
+// each named flag takes a value, or the flags of the bazel command for a named flag that Aspect CLI
+// doesn't define. A named flag may be given as --name, --name=value, --name value when it takes a
+// value, and --noname when it doesn't.
+//
+// Bazel flags are kept along with their values, which may look like one of the named flags when
+// given as a separate argument; they are recognized by the same flag sets as SeparateBazelFlags.
+// Arguments after a double dash are kept as they are.
+func RemoveFlags(command string, aspectFlags *pflag.FlagSet, args []string, names ...string) []string {
+	bazelFlags := bazelFlagSets[command]
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		var flag *pflag.Flag
+		long, isLong := strings.CutPrefix(arg, "--")
+		name, _, hasValue := strings.Cut(long, "=")
+		switch {
+		case isLong && isNamedFlag(aspectFlags, bazelFlags, name, hasValue, names):
+			if !hasValue && takesValue(lookupNamedFlag(aspectFlags, bazelFlags, name)) && i+1 < len(args) {
+				// The value is the next argument.
+				i++
+			}
+			continue
+		case isLong && !hasValue:
+			flag = lookupFlag(bazelFlags, name)
+		case len(arg) == 2 && arg[0] == '-' && bazelFlags != nil:
+			flag = bazelFlags.ShorthandLookup(arg[1:])
+		}
+		result = append(result, arg)
+		if flag != nil && takesValue(flag) && i+1 < len(args) {
+			// Keep the value of the Bazel flag, whatever it looks like.
+			i++
+			result = append(result, args[i])
+		}
+	}
+	return result
+}
+
+// isNamedFlag returns whether the flag called name, as given on the command line, is one of names
+// or the negation of one of them that doesn't take a value.
+func isNamedFlag(aspectFlags *pflag.FlagSet, bazelFlags *pflag.FlagSet, name string, hasValue bool, names []string) bool {
+	for _, n := range names {
+		if name == n {
+			return true
+		}
+		if !hasValue && name == rootFlags.NoFlagName(n) && !takesValue(lookupNamedFlag(aspectFlags, bazelFlags, n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupNamedFlag returns the Aspect CLI flag called name, or else the Bazel flag.
+func lookupNamedFlag(aspectFlags *pflag.FlagSet, bazelFlags *pflag.FlagSet, name string) *pflag.Flag {
+	if flag := lookupFlag(aspectFlags, name); flag != nil {
+		return flag
+	}
+	return lookupFlag(bazelFlags, name)
+}
+
+func lookupFlag(flagSet *pflag.FlagSet, name string) *pflag.Flag {
+	if flagSet == nil {
+		return nil
+	}
+	return flagSet.Lookup(name)
+}
+
+// takesValue returns whether flag takes a value, which is the next argument unless it is given as
+// --flag=value. Flags that aren't defined don't take one.
+func takesValue(flag *pflag.Flag) bool {
+	return flag != nil && flag.NoOptDefVal == ""
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bazel
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/pflag"
+
+	rootFlags "github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
+)
+
+func TestRemoveFlags(t *testing.T) {
+	testFlags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	testFlags.StringP("compilation_mode", "c", "", "")
+	testFlags.String("output", "", "")
+	testFlags.Var(&rootFlags.MultiString{}, "test_arg", "")
+	rootFlags.RegisterNoableBool(testFlags, "keep_going", false, "")
+
+	aspectFlags := pflag.NewFlagSet("aspect", pflag.ContinueOnError)
+	aspectFlags.Bool("retry-failed", false, "")
+	aspectFlags.Int("failed-logs:lines", 50, "")
+
+	t.Run("removes the named flags in each of their forms", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		args := []string{"--retry-failed", "//foo:all", "--noretry-failed", "--retry-failed=false", "--failed-logs:lines", "20", "--failed-logs:lines=10", "--", "--retry-failed"}
+		g.Expect(RemoveFlags("test", aspectFlags, args, "retry-failed", "failed-logs:lines")).To(Equal([]string{"//foo:all", "--", "--retry-failed"}))
+	})
+
+	t.Run("keeps bazel flags and their values in order", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		bazelFlagSets = map[string]*pflag.FlagSet{"test": testFlags}
+		t.Cleanup(func() { bazelFlagSets = map[string]*pflag.FlagSet{} })
+
+		args := []string{"--test_arg", "--retry-failed", "-c", "opt", "--keep_going", "--retry-failed", "//foo:all", "--test_arg=--failed-logs:lines"}
+		g.Expect(RemoveFlags("test", aspectFlags, args, "retry-failed", "failed-logs:lines")).To(Equal([]string{"--test_arg", "--retry-failed", "-c", "opt", "--keep_going", "//foo:all", "--test_arg=--failed-logs:lines"}))
+	})
+	t.Run("removes named bazel flags and their values", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		bazelFlagSets = map[string]*pflag.FlagSet{"test": testFlags}
+		t.Cleanup(func() { bazelFlagSets = map[string]*pflag.FlagSet{} })
+
+		args := []string{"--output", "--retry-failed", "//foo:all", "--output=label", "--keep_going"}
+		g.Expect(RemoveFlags("test", nil, args, "output")).To(Equal([]string{"//foo:all", "--keep_going"}))
+	})
+}
@@ -0,0 +1,340 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+)
+
+func startedEvent(id string, start time.Time) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Started{Started: &buildeventstream.BuildEventId_BuildStartedId{}}},
+		Payload: &buildeventstream.BuildEvent_Started{Started: &buildeventstream.BuildStarted{
+			Uuid:      id,
+			Command:   "build",
+			StartTime: timestamppb.New(start),
+		}},
+	}
+}
+
+func patternEvent(patterns ...string) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Pattern{Pattern: &buildeventstream.BuildEventId_PatternExpandedId{Pattern: patterns}}},
+	}
+}
+
+func finishedEvent(code int32, finish time.Time) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_BuildFinished{BuildFinished: &buildeventstream.BuildEventId_BuildFinishedId{}}},
+		Payload: &buildeventstream.BuildEvent_Finished{Finished: &buildeventstream.BuildFinished{
+			ExitCode:   &buildeventstream.BuildFinished_ExitCode{Code: code, Name: "SUCCESS"},
+			FinishTime: timestamppb.New(finish),
+		}},
+		LastMessage: true,
+	}
+}
+
+func record(t *testing.T, r *Recorder, events ...*buildeventstream.BuildEvent) {
+	for i, e := range events {
+		if err := r.Callback(e, int64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	t.Run("records the events and a summary of an invocation", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		r := NewRecorder(store, Retention{})
+		record(t, r, startedEvent("inv-1", start), patternEvent("//foo:bar"), finishedEvent(0, start.Add(time.Minute)))
+
+		invocation, err := store.Get("inv")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(invocation.ID).To(Equal("inv-1"))
+		g.Expect(invocation.Command).To(Equal("build"))
+		g.Expect(invocation.Targets).To(Equal([]string{"//foo:bar"}))
+		g.Expect(invocation.Duration()).To(Equal(time.Minute))
+		g.Expect(invocation.Success()).To(BeTrue())
+
+		events, err := store.Events("inv-1")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(events).To(HaveLen(3))
+		g.Expect(events[1].GetId().GetPattern().GetPattern()).To(Equal([]string{"//foo:bar"}))
+	})
+
+	t.Run("records interrupted invocations as incomplete", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		r := NewRecorder(store, Retention{})
+		record(t, r, startedEvent("inv-1", time.Now()))
+		g.Expect(r.Close()).To(Succeed())
+
+		invocation, err := store.Get("inv-1")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(invocation.Complete).To(BeFalse())
+		g.Expect(invocation.Success()).To(BeFalse())
+	})
+
+	t.Run("redacts the values of the header flags", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		r := NewRecorder(store, Retention{})
+		options := &buildeventstream.BuildEvent{
+			Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_OptionsParsed{OptionsParsed: &buildeventstream.BuildEventId_OptionsParsedId{}}},
+			Payload: &buildeventstream.BuildEvent_OptionsParsed{OptionsParsed: &buildeventstream.OptionsParsed{
+				CmdLine: []string{"--remote_header=x-api-key=secret", "--config=ci"},
+			}},
+		}
+		record(t, r, startedEvent("inv-1", time.Now()), options, finishedEvent(0, time.Now()))
+
+		events, err := store.Events("inv-1")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(events[1].GetOptionsParsed().GetCmdLine()).To(Equal([]string{"--remote_header=<REDACTED>", "--config=ci"}))
+		g.Expect(options.GetOptionsParsed().GetCmdLine()[0]).To(Equal("--remote_header=x-api-key=secret"))
+	})
+
+	t.Run("warns instead of failing when the store can't be written", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		var stderr bytes.Buffer
+		r := NewRecorder(NewStore("/dev/null/history"), Retention{})
+		r.stderr = &stderr
+		record(t, r, startedEvent("inv-1", time.Now()), finishedEvent(0, time.Now()))
+
+		g.Expect(stderr.String()).To(ContainSubstring("failed to create history directory"))
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("prunes invocations outside of the retention limits", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+		r := NewRecorder(store, Retention{})
+		for day := 1; day <= 5; day++ {
+			start := now.Add(-time.Duration(day) * 24 * time.Hour)
+			record(t, r, startedEvent(fmt.Sprintf("inv-%d", day), start), finishedEvent(0, start))
+		}
+
+		g.Expect(store.Prune(Retention{MaxInvocations: 3, MaxAge: 60 * time.Hour}, now)).To(Succeed())
+
+		invocations, err := store.List()
+		g.Expect(err).To(Not(HaveOccurred()))
+		ids := []string{}
+		for _, i := range invocations {
+			ids = append(ids, i.ID)
+		}
+		g.Expect(ids).To(Equal([]string{"inv-1", "inv-2"}))
+	})
+
+	t.Run("prunes event files without a summary", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		now := time.Now()
+		old := filepath.Join(store.Dir(), "old"+eventsExt)
+		recent := filepath.Join(store.Dir(), "recent"+eventsExt)
+		for _, path := range []string{old, recent} {
+			g.Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+		}
+		g.Expect(os.Chtimes(old, now.Add(-48*time.Hour), now.Add(-48*time.Hour))).To(Succeed())
+
+		g.Expect(store.Prune(Retention{}, now)).To(Succeed())
+
+		g.Expect(old).To(Not(BeAnExistingFile()))
+		g.Expect(recent).To(BeAnExistingFile())
+	})
+
+	t.Run("rejects ambiguous and unknown invocation IDs", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		r := NewRecorder(store, Retention{})
+		record(t, r, startedEvent("inv-1", time.Now()), finishedEvent(0, time.Now()))
+		record(t, r, startedEvent("inv-2", time.Now()), finishedEvent(0, time.Now()))
+
+		_, err := store.Get("inv")
+		g.Expect(err).To(MatchError(ContainSubstring("more than one")))
+
+		_, err = store.Get("other")
+		g.Expect(err).To(BeAssignableToTypeOf(&NotFoundError{}))
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("doesn't record unless enabled", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		viper.Reset()
+		t.Cleanup(viper.Reset)
+
+		config, err := LoadConfig()
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(config.Enabled).To(BeFalse())
+
+		viper.Set("history.enabled", true)
+		config, err = LoadConfig()
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(config.Enabled).To(BeTrue())
+	})
+}
+
+func TestActionStats(t *testing.T) {
+	t.Run("counts local and remote cache hits", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rate, ok := (&ActionStats{CacheHits: 6, CacheMisses: 4, RemoteCacheHits: 2}).CacheHitRate()
+		g.Expect(ok).To(BeTrue())
+		g.Expect(rate).To(BeNumerically("~", 0.8))
+	})
+
+	t.Run("has no rate without actions", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, ok := (*ActionStats)(nil).CacheHitRate()
+		g.Expect(ok).To(BeFalse())
+		_, ok = (&ActionStats{}).CacheHitRate()
+		g.Expect(ok).To(BeFalse())
+	})
+}
+
+func testResultEvent(label string, shard int32, attempt int32, status buildeventstream.TestStatus) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id: &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TestResult{TestResult: &buildeventstream.BuildEventId_TestResultId{
+			Label:         label,
+			Shard:         shard,
+			Attempt:       attempt,
+			Configuration: &buildeventstream.BuildEventId_ConfigurationId{Id: "k8-fastbuild"},
+		}}},
+		Payload: &buildeventstream.BuildEvent_TestResult{TestResult: &buildeventstream.TestResult{Status: status}},
+	}
+}
+
+func testSummaryEvent(label string, status buildeventstream.TestStatus) *buildeventstream.BuildEvent {
+	return &buildeventstream.BuildEvent{
+		Id:      &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_TestSummary{TestSummary: &buildeventstream.BuildEventId_TestSummaryId{Label: label}}},
+		Payload: &buildeventstream.BuildEvent_TestSummary{TestSummary: &buildeventstream.TestSummary{OverallStatus: status}},
+	}
+}
+
+func TestTestHistory(t *testing.T) {
+	t.Run("flags tests that passed and failed on the same inputs", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		r := NewRecorder(store, Retention{})
+		record(t, r,
+			startedEvent("inv-1", start),
+			// Retried by --flaky_test_attempts
+			testResultEvent("//:retried", 0, 1, buildeventstream.TestStatus_FAILED),
+			testResultEvent("//:retried", 0, 2, buildeventstream.TestStatus_PASSED),
+			testSummaryEvent("//:retried", buildeventstream.TestStatus_FLAKY),
+			// Different shards run different test cases
+			testResultEvent("//:sharded", 0, 1, buildeventstream.TestStatus_FAILED),
+			testResultEvent("//:sharded", 1, 1, buildeventstream.TestStatus_PASSED),
+			testSummaryEvent("//:sharded", buildeventstream.TestStatus_FAILED),
+			finishedEvent(3, start.Add(time.Minute)),
+		)
+		record(t, r,
+			startedEvent("inv-2", start.Add(time.Hour)),
+			testResultEvent("//:retried", 0, 1, buildeventstream.TestStatus_PASSED),
+			testSummaryEvent("//:retried", buildeventstream.TestStatus_PASSED),
+			testResultEvent("//:sharded", 0, 1, buildeventstream.TestStatus_PASSED),
+			testResultEvent("//:sharded", 1, 1, buildeventstream.TestStatus_PASSED),
+			testSummaryEvent("//:sharded", buildeventstream.TestStatus_PASSED),
+			finishedEvent(0, start.Add(time.Hour+time.Minute)),
+		)
+
+		tests, err := store.TestHistory("")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(tests).To(HaveKey("//:retried"))
+		g.Expect(tests["//:sharded"].Runs).To(HaveLen(2))
+		g.Expect(tests["//:sharded"].FlakyRuns()).To(BeEmpty())
+
+		flakes := tests.Flakes()
+		g.Expect(flakes).To(HaveLen(1))
+		g.Expect(flakes[0].Label).To(Equal("//:retried"))
+		g.Expect(flakes[0].FlakyRuns()[0].InvocationID).To(Equal("inv-1"))
+		g.Expect(flakes[0].Runs[1].Status).To(Equal("PASSED"))
+	})
+
+	t.Run("drops test runs outside of the retention limits", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		now := time.Now()
+		r := NewRecorder(store, Retention{MaxAge: 48 * time.Hour})
+		record(t, r,
+			startedEvent("inv-1", now.Add(-72*time.Hour)),
+			testSummaryEvent("//:old", buildeventstream.TestStatus_PASSED),
+			finishedEvent(0, now),
+		)
+		record(t, r,
+			startedEvent("inv-2", now),
+			testSummaryEvent("//:new", buildeventstream.TestStatus_PASSED),
+			finishedEvent(0, now),
+		)
+
+		tests, err := store.TestHistory("")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(tests).To(HaveLen(1))
+		g.Expect(tests).To(HaveKey("//:new"))
+
+		invocations, err := store.List()
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(invocations).To(HaveLen(1))
+	})
+
+	t.Run("keeps the history of each workspace apart", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		store := NewStore(t.TempDir())
+		r := NewRecorder(store, Retention{})
+		for _, name := range []string{"a", "b"} {
+			started := startedEvent("inv-"+name, time.Now())
+			started.GetStarted().WorkspaceDirectory = "/" + name
+			record(t, r,
+				started,
+				testSummaryEvent("//:test", buildeventstream.TestStatus_FLAKY),
+				finishedEvent(0, time.Now()),
+			)
+		}
+
+		tests, err := store.TestHistory("/a")
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(tests["//:test"].Runs).To(HaveLen(1))
+		g.Expect(tests["//:test"].Runs[0].InvocationID).To(Equal("inv-a"))
+	})
+}
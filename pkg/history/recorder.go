@@ -0,0 +1,253 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/besproxy"
+)
+
+const (
+	defaultMaxInvocations = 100
+	defaultMaxAge         = 30 * 24 * time.Hour
+)
+
+// Config is the `history` attribute of the Aspect CLI config.yaml:
+//
+//	history:
+//	  enabled: true
+//	  max_invocations: 100
+//	  max_age: 720h
+type Config struct {
+	Enabled   bool
+	Retention Retention
+}
+
+// LoadConfig reads the history configuration. Recording is disabled unless
+// explicitly turned on, since it needs a BES backend in front of the user's.
+func LoadConfig() (*Config, error) {
+	config := &Config{
+		Enabled: false,
+		Retention: Retention{
+			MaxInvocations: defaultMaxInvocations,
+			MaxAge:         defaultMaxAge,
+		},
+	}
+	if viper.IsSet("history.enabled") {
+		config.Enabled = viper.GetBool("history.enabled")
+	}
+	if viper.IsSet("history.max_invocations") {
+		config.Retention.MaxInvocations = viper.GetInt("history.max_invocations")
+	}
+	if viper.IsSet("history.max_age") {
+		maxAge, err := time.ParseDuration(viper.GetString("history.max_age"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid history.max_age: %w", err)
+		}
+		config.Retention.MaxAge = maxAge
+	}
+	return config, nil
+}
+
+// Recorder is a build event subscriber that records every invocation to a
+// Store. Failing to record never fails the invocation itself, so errors are
+// reported as warnings and recording stops.
+type Recorder struct {
+	store     *Store
+	retention Retention
+	stderr    io.Writer
+	// transformer redacts the events before they are written to disk.
+	transformer *besproxy.EventTransformer
+
+	mutex      sync.Mutex
+	invocation *Invocation
+	tests      *testAttempts
+	file       *os.File
+	gz         *gzip.Writer
+	w          *bufio.Writer
+	failed     bool
+}
+
+// NewRecorder creates a Recorder that writes to store. The values of the
+// header flags, which carry credentials, are redacted from the recorded events.
+func NewRecorder(store *Store, retention Retention) *Recorder {
+	return &Recorder{
+		store:       store,
+		retention:   retention,
+		stderr:      os.Stderr,
+		transformer: besproxy.NewLocalEventTransformer(nil),
+	}
+}
+
+// RedactWith also redacts the values matched by the bes.rules of the Aspect CLI
+// config.yaml that apply to every backend.
+func (r *Recorder) RedactWith(rules []*besproxy.EventRule) {
+	r.transformer = besproxy.NewLocalEventTransformer(rules)
+}
+
+// Callback records a single build event. Its signature matches bep.CallbackFn.
+func (r *Recorder) Callback(event *buildeventstream.BuildEvent, _ int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.failed {
+		return nil
+	}
+	if err := r.record(event); err != nil {
+		r.fail(err)
+	}
+	return nil
+}
+
+// Close finalizes an invocation whose build event stream ended without a last
+// message.
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.invocation == nil {
+		return nil
+	}
+	if err := r.finish(); err != nil {
+		r.fail(err)
+	}
+	return nil
+}
+
+func (r *Recorder) record(event *buildeventstream.BuildEvent) error {
+	if started := event.GetStarted(); started != nil {
+		if r.invocation != nil {
+			if err := r.finish(); err != nil {
+				return err
+			}
+		}
+		if err := r.start(started); err != nil {
+			return err
+		}
+	}
+	if r.invocation == nil {
+		// Events before BuildStarted can't be attributed to an invocation.
+		return nil
+	}
+	event = r.transformer.Redact(event)
+
+	if _, err := protodelim.MarshalTo(r.w, event); err != nil {
+		return fmt.Errorf("failed to record build event: %w", err)
+	}
+
+	r.tests.add(r.invocation, event)
+
+	switch {
+	case event.GetId().GetPattern() != nil:
+		r.invocation.Targets = append(r.invocation.Targets, event.GetId().GetPattern().GetPattern()...)
+	case event.GetFinished() != nil:
+		finished := event.GetFinished()
+		r.invocation.ExitCode = finished.GetExitCode().GetCode()
+		r.invocation.ExitName = finished.GetExitCode().GetName()
+		if finished.GetFinishTime() != nil {
+			r.invocation.FinishTime = finished.GetFinishTime().AsTime()
+		}
+	case event.GetBuildMetrics() != nil:
+		summary := event.GetBuildMetrics().GetActionSummary()
+		r.invocation.Actions = &ActionStats{
+			Created:         summary.GetActionsCreated(),
+			Executed:        summary.GetActionsExecuted(),
+			CacheHits:       int64(summary.GetActionCacheStatistics().GetHits()),
+			CacheMisses:     int64(summary.GetActionCacheStatistics().GetMisses()),
+			RemoteCacheHits: summary.GetRemoteCacheHits(),
+		}
+	}
+
+	if event.GetLastMessage() {
+		r.invocation.Complete = true
+		return r.finish()
+	}
+	return nil
+}
+
+func (r *Recorder) start(started *buildeventstream.BuildStarted) error {
+	if started.GetUuid() == "" {
+		return nil
+	}
+	if err := os.MkdirAll(r.store.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create history directory %s: %w", r.store.dir, err)
+	}
+	f, err := os.OpenFile(filepath.Join(r.store.dir, started.GetUuid()+eventsExt), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to record invocation %s: %w", started.GetUuid(), err)
+	}
+	r.file = f
+	r.gz = gzip.NewWriter(f)
+	r.w = bufio.NewWriter(r.gz)
+	r.tests = newTestAttempts()
+	r.invocation = &Invocation{
+		ID:                 started.GetUuid(),
+		Command:            started.GetCommand(),
+		Targets:            []string{},
+		WorkspaceDirectory: started.GetWorkspaceDirectory(),
+		StartTime:          started.GetStartTime().AsTime(),
+	}
+	return nil
+}
+
+func (r *Recorder) finish() error {
+	invocation := r.invocation
+	r.invocation = nil
+
+	err := r.w.Flush()
+	if closeErr := r.gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record invocation %s: %w", invocation.ID, err)
+	}
+	if err := r.store.writeSummary(invocation); err != nil {
+		return fmt.Errorf("failed to record invocation %s: %w", invocation.ID, err)
+	}
+	if err := r.store.addTestRuns(invocation.WorkspaceDirectory, r.tests.runs, r.retention, time.Now()); err != nil {
+		return fmt.Errorf("failed to record test results of invocation %s: %w", invocation.ID, err)
+	}
+	if err := r.store.Prune(r.retention, time.Now()); err != nil {
+		return fmt.Errorf("failed to prune invocation history: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) fail(err error) {
+	r.failed = true
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.invocation = nil
+	fmt.Fprintf(r.stderr, "%s %s\n", color.YellowString("WARNING:"), err.Error())
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"strings"
+	"time"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/bazel/command_line"
+)
+
+// CommandLine is a command line taken from a structured_command_line event.
+type CommandLine struct {
+	StartupOptions []string
+	Command        string
+	CommandOptions []string
+	Residual       []string
+}
+
+// Args returns the arguments to pass to Bazel to replay the command line.
+func (c *CommandLine) Args() []string {
+	args := append([]string{}, c.StartupOptions...)
+	args = append(args, c.Command)
+	args = append(args, c.CommandOptions...)
+	for _, r := range c.Residual {
+		if strings.HasPrefix(r, "-") {
+			// Negative target patterns and arguments of `run` would otherwise
+			// be parsed as options.
+			args = append(args, "--")
+			break
+		}
+	}
+	return append(args, c.Residual...)
+}
+
+func newCommandLine(cl *command_line.CommandLine) *CommandLine {
+	c := &CommandLine{}
+	for _, section := range cl.GetSections() {
+		var values []string
+		if options := section.GetOptionList(); options != nil {
+			for _, o := range options.GetOption() {
+				values = append(values, o.GetCombinedForm())
+			}
+		} else {
+			values = section.GetChunkList().GetChunk()
+		}
+		switch section.GetSectionLabel() {
+		case "startup options":
+			c.StartupOptions = values
+		case "command":
+			if len(values) > 0 {
+				c.Command = values[0]
+			}
+		case "command options":
+			c.CommandOptions = values
+		case "residual":
+			c.Residual = values
+		}
+	}
+	return c
+}
+
+// TargetResult is the outcome of building a single configured target.
+type TargetResult struct {
+	Label   string
+	Kind    string
+	Success bool
+}
+
+// FailedAction is an action that failed to execute.
+type FailedAction struct {
+	Label    string
+	Mnemonic string
+	ExitCode int32
+	Stderr   *buildeventstream.File
+}
+
+// TestResult is the overall result of a test target.
+type TestResult struct {
+	Label    string
+	Status   buildeventstream.TestStatus
+	Runs     int32
+	Cached   int32
+	Duration time.Duration
+}
+
+// Report is the detail of a recorded invocation derived from its build events.
+type Report struct {
+	WorkingDirectory string
+	// CanonicalCommandLine is nil when Bazel didn't report one.
+	CanonicalCommandLine *CommandLine
+	// CmdLine is the cmd_line of the OptionsParsed event, which includes the
+	// options that were expanded from bazelrc files.
+	CmdLine       []string
+	Targets       []*TargetResult
+	FailedActions []*FailedAction
+	Tests         []*TestResult
+}
+
+// NewReport builds a Report from the build events of an invocation.
+func NewReport(events []*buildeventstream.BuildEvent) *Report {
+	report := &Report{}
+	for _, event := range events {
+		report.add(event)
+	}
+	return report
+}
+
+// Report reads the build events of the invocation id into a Report.
+func (s *Store) Report(id string) (*Report, error) {
+	report := &Report{}
+	if err := s.ForEachEvent(id, func(event *buildeventstream.BuildEvent) error {
+		report.add(event)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (r *Report) add(event *buildeventstream.BuildEvent) {
+	id := event.GetId()
+	switch {
+	case event.GetStarted() != nil:
+		r.WorkingDirectory = event.GetStarted().GetWorkingDirectory()
+	case event.GetStructuredCommandLine() != nil:
+		if id.GetStructuredCommandLine().GetCommandLineLabel() == "canonical" {
+			r.CanonicalCommandLine = newCommandLine(event.GetStructuredCommandLine())
+		}
+	case event.GetOptionsParsed() != nil:
+		r.CmdLine = event.GetOptionsParsed().GetCmdLine()
+	case id.GetTargetCompleted() != nil:
+		if id.GetTargetCompleted().GetAspect() != "" {
+			return
+		}
+		r.Targets = append(r.Targets, &TargetResult{
+			Label:   id.GetTargetCompleted().GetLabel(),
+			Kind:    event.GetCompleted().GetTargetKind(),
+			Success: event.GetCompleted().GetSuccess(),
+		})
+	case event.GetAction() != nil:
+		action := event.GetAction()
+		if action.GetSuccess() {
+			return
+		}
+		r.FailedActions = append(r.FailedActions, &FailedAction{
+			Label:    action.GetLabel(),
+			Mnemonic: action.GetType(),
+			ExitCode: action.GetExitCode(),
+			Stderr:   action.GetStderr(),
+		})
+	case event.GetTestSummary() != nil:
+		summary := event.GetTestSummary()
+		r.Tests = append(r.Tests, &TestResult{
+			Label:    id.GetTestSummary().GetLabel(),
+			Status:   summary.GetOverallStatus(),
+			Runs:     summary.GetTotalRunCount(),
+			Cached:   summary.GetTotalNumCached(),
+			Duration: summary.GetTotalRunDuration().AsDuration(),
+		})
+	}
+}
@@ -0,0 +1,360 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/pkg/ioutils/cache"
+)
+
+const (
+	summaryExt = ".json"
+	eventsExt  = ".bep.gz"
+)
+
+// Invocation summarizes a recorded invocation. It is stored next to the
+// invocation's build events so that listing the history doesn't require
+// reading every event.
+type Invocation struct {
+	ID                 string    `json:"id"`
+	Command            string    `json:"command"`
+	Targets            []string  `json:"targets"`
+	WorkspaceDirectory string    `json:"workspace_directory"`
+	StartTime          time.Time `json:"start_time"`
+	FinishTime         time.Time `json:"finish_time"`
+	ExitCode           int32     `json:"exit_code"`
+	ExitName           string    `json:"exit_name"`
+	// Complete is false when the build event stream ended before its last
+	// message, for example because Bazel was interrupted.
+	Complete bool `json:"complete"`
+	// Actions is nil when the invocation didn't report build metrics.
+	Actions *ActionStats `json:"actions,omitempty"`
+}
+
+// ActionStats are the action counts taken from the BuildMetrics event.
+type ActionStats struct {
+	Created         int64 `json:"created"`
+	Executed        int64 `json:"executed"`
+	CacheHits       int64 `json:"cache_hits"`
+	CacheMisses     int64 `json:"cache_misses"`
+	RemoteCacheHits int64 `json:"remote_cache_hits"`
+}
+
+// CacheHitRate returns the fraction of actions that were served from the local
+// action cache or a remote cache. It returns false when no actions were
+// checked against a cache.
+func (s *ActionStats) CacheHitRate() (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	checked := s.CacheHits + s.CacheMisses
+	if checked == 0 {
+		// Older Bazel versions don't report action cache statistics.
+		checked = s.Created
+		if checked == 0 {
+			return 0, false
+		}
+		return float64(checked-s.Executed+s.RemoteCacheHits) / float64(checked), true
+	}
+	return float64(s.CacheHits+s.RemoteCacheHits) / float64(checked), true
+}
+
+// Success reports whether the invocation finished successfully.
+func (i *Invocation) Success() bool {
+	return i.Complete && i.ExitCode == 0
+}
+
+// Duration returns the wall time of the invocation.
+func (i *Invocation) Duration() time.Duration {
+	if i.FinishTime.IsZero() {
+		return 0
+	}
+	return i.FinishTime.Sub(i.StartTime)
+}
+
+// NotFoundError is returned when no recorded invocation matches an ID.
+type NotFoundError struct {
+	ID string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no recorded invocation matches '%s'", e.ID)
+}
+
+// Store is a directory of recorded invocations.
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the history directory under the Aspect cache dir.
+func DefaultDir() (string, error) {
+	aspectCacheDir, err := cache.AspectCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(aspectCacheDir, "history"), nil
+}
+
+// NewStore creates a Store backed by dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// NewDefaultStore creates a Store backed by the default history directory.
+func NewDefaultStore() (*Store, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(dir), nil
+}
+
+// Dir returns the directory backing the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// List returns the recorded invocations, most recent first.
+func (s *Store) List() ([]*Invocation, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Invocation{}, nil
+		}
+		return nil, fmt.Errorf("failed to read history directory %s: %w", s.dir, err)
+	}
+	invocations := []*Invocation{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), summaryExt) {
+			continue
+		}
+		invocation, err := s.readSummary(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			// Skip summaries that are being written or were corrupted.
+			continue
+		}
+		invocations = append(invocations, invocation)
+	}
+	sort.Slice(invocations, func(i, k int) bool {
+		return invocations[i].StartTime.After(invocations[k].StartTime)
+	})
+	return invocations, nil
+}
+
+// Get returns the invocation whose ID is id, or starts with id if that
+// prefix is unambiguous.
+func (s *Store) Get(id string) (*Invocation, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, &NotFoundError{ID: id}
+	}
+	invocation, err := s.readSummary(filepath.Join(s.dir, id+summaryExt))
+	if err == nil {
+		return invocation, nil
+	}
+	invocations, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var match *Invocation
+	for _, i := range invocations {
+		if strings.HasPrefix(i.ID, id) {
+			if match != nil {
+				return nil, fmt.Errorf("'%s' matches more than one recorded invocation", id)
+			}
+			match = i
+		}
+	}
+	if match == nil {
+		return nil, &NotFoundError{ID: id}
+	}
+	return match, nil
+}
+
+// Latest returns the most recent invocation for which include returns true.
+// A nil include matches every invocation.
+func (s *Store) Latest(include func(*Invocation) bool) (*Invocation, error) {
+	invocations, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range invocations {
+		if include == nil || include(i) {
+			return i, nil
+		}
+	}
+	return nil, &NotFoundError{ID: "latest"}
+}
+
+// ForEachEvent calls fn with every build event recorded for the invocation,
+// in the order they were received.
+func (s *Store) ForEachEvent(id string, fn func(*buildeventstream.BuildEvent) error) error {
+	f, err := os.Open(filepath.Join(s.dir, id+eventsExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &NotFoundError{ID: id}
+		}
+		return fmt.Errorf("failed to read build events of %s: %w", id, err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read build events of %s: %w", id, err)
+	}
+	defer gz.Close()
+	r := bufio.NewReader(gz)
+	for {
+		event := &buildeventstream.BuildEvent{}
+		if err := protodelim.UnmarshalFrom(r, event); err != nil {
+			// An invocation that was interrupted may end with a partial record.
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read build events of %s: %w", id, err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+}
+
+// Events returns every build event recorded for the invocation.
+func (s *Store) Events(id string) ([]*buildeventstream.BuildEvent, error) {
+	events := []*buildeventstream.BuildEvent{}
+	err := s.ForEachEvent(id, func(event *buildeventstream.BuildEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	return events, err
+}
+
+// Retention limits how many invocations are kept. Zero values are unlimited.
+type Retention struct {
+	MaxInvocations int
+	MaxAge         time.Duration
+}
+
+// Prune removes the invocations that fall outside of the retention limits.
+func (s *Store) Prune(retention Retention, now time.Time) error {
+	invocations, err := s.List()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for n, i := range invocations {
+		expired := retention.MaxAge > 0 && now.Sub(i.StartTime) > retention.MaxAge
+		excess := retention.MaxInvocations > 0 && n >= retention.MaxInvocations
+		if expired || excess {
+			if err := s.Remove(i.ID); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := s.pruneOrphans(retention, now); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// orphanGracePeriod is how long an event file without a summary is kept when
+// the retention has no age limit. Such a file is either still being recorded,
+// and then recently modified, or was left behind by an interrupted build.
+const orphanGracePeriod = 24 * time.Hour
+
+// pruneOrphans removes the event files that have no summary, which List never
+// returns, once they haven't been written to for longer than the retention
+// age.
+func (s *Store) pruneOrphans(retention Retention, now time.Time) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read history directory %s: %w", s.dir, err)
+	}
+	maxAge := retention.MaxAge
+	if maxAge <= 0 {
+		maxAge = orphanGracePeriod
+	}
+	var errs []error
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), eventsExt)
+		if e.IsDir() || !ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.dir, id+summaryExt)); !os.IsNotExist(err) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || now.Sub(info.ModTime()) <= maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Remove deletes a recorded invocation.
+func (s *Store) Remove(id string) error {
+	for _, ext := range []string{summaryExt, eventsExt} {
+		if err := os.Remove(filepath.Join(s.dir, id+ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) readSummary(path string) (*Invocation, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	invocation := &Invocation{}
+	if err := json.Unmarshal(b, invocation); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return invocation, nil
+}
+
+func (s *Store) writeSummary(invocation *Invocation) error {
+	b, err := json.Marshal(invocation)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, invocation.ID+summaryExt)
+	// Write to a temporary file first so that List never sees a partial summary.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,221 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+)
+
+// maxTestRuns is the number of invocations kept in the history of each test.
+const maxTestRuns = 100
+
+// TestRun is the outcome of a test target in a single invocation.
+type TestRun struct {
+	InvocationID string    `json:"invocation_id"`
+	Time         time.Time `json:"time"`
+	Status       string    `json:"status"`
+	Passed       int       `json:"passed"`
+	Failed       int       `json:"failed"`
+	// Flaky is set when the test both passed and failed on the same inputs,
+	// which is only known when Bazel ran the same test action more than once
+	// because of --flaky_test_attempts or --runs_per_test.
+	Flaky bool `json:"flaky"`
+}
+
+// TestRecord is the history of a test target across invocations.
+type TestRecord struct {
+	Label string     `json:"label"`
+	Runs  []*TestRun `json:"runs"`
+}
+
+// FlakyRuns returns the runs in which the test was flaky.
+func (r *TestRecord) FlakyRuns() []*TestRun {
+	flaky := []*TestRun{}
+	for _, run := range r.Runs {
+		if run.Flaky {
+			flaky = append(flaky, run)
+		}
+	}
+	return flaky
+}
+
+// TestHistory is the history of every test target, keyed by label.
+type TestHistory map[string]*TestRecord
+
+// Flakes returns the tests that were flaky at least once, most recently
+// flaky first.
+func (h TestHistory) Flakes() []*TestRecord {
+	flakes := []*TestRecord{}
+	for _, r := range h {
+		if len(r.FlakyRuns()) > 0 {
+			flakes = append(flakes, r)
+		}
+	}
+	last := func(r *TestRecord) time.Time {
+		runs := r.FlakyRuns()
+		return runs[len(runs)-1].Time
+	}
+	sort.Slice(flakes, func(i, j int) bool {
+		if !last(flakes[i]).Equal(last(flakes[j])) {
+			return last(flakes[i]).After(last(flakes[j]))
+		}
+		return flakes[i].Label < flakes[j].Label
+	})
+	return flakes
+}
+
+// TestHistory returns the recorded history of every test target in the
+// workspace. The same label names different tests in different workspaces, so
+// each workspace has its own history.
+func (s *Store) TestHistory(workspace string) (TestHistory, error) {
+	history := TestHistory{}
+	path := s.testHistoryPath(workspace)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return nil, fmt.Errorf("failed to read test history: %w", err)
+	}
+	if err := json.Unmarshal(b, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return history, nil
+}
+
+// addTestRuns merges the test runs of an invocation into the test history of
+// its workspace and drops runs that fall outside of the retention limits.
+func (s *Store) addTestRuns(workspace string, runs map[string]*TestRun, retention Retention, now time.Time) error {
+	if len(runs) == 0 {
+		return nil
+	}
+	history, err := s.TestHistory(workspace)
+	if err != nil {
+		return err
+	}
+	for label, run := range runs {
+		record, ok := history[label]
+		if !ok {
+			record = &TestRecord{Label: label}
+			history[label] = record
+		}
+		record.Runs = append(record.Runs, run)
+	}
+	for label, record := range history {
+		kept := []*TestRun{}
+		for _, run := range record.Runs {
+			if retention.MaxAge == 0 || now.Sub(run.Time) <= retention.MaxAge {
+				kept = append(kept, run)
+			}
+		}
+		if len(kept) > maxTestRuns {
+			kept = kept[len(kept)-maxTestRuns:]
+		}
+		if len(kept) == 0 {
+			delete(history, label)
+			continue
+		}
+		record.Runs = kept
+	}
+
+	b, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	path := s.testHistoryPath(workspace)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *Store) testHistoryPath(workspace string) string {
+	hash := sha256.Sum256([]byte(workspace))
+	return filepath.Join(s.dir, "tests", fmt.Sprintf("%x.json", hash[:8]))
+}
+
+// testAttempts accumulates the TestResult and TestSummary events of an
+// invocation into TestRuns.
+type testAttempts struct {
+	runs map[string]*TestRun
+	// outcomes of the same test action, keyed by label, configuration and
+	// shard, since only those are guaranteed to have the same inputs.
+	passed map[string]bool
+	failed map[string]bool
+}
+
+func newTestAttempts() *testAttempts {
+	return &testAttempts{
+		runs:   map[string]*TestRun{},
+		passed: map[string]bool{},
+		failed: map[string]bool{},
+	}
+}
+
+func (t *testAttempts) add(invocation *Invocation, event *buildeventstream.BuildEvent) {
+	id := event.GetId()
+	switch {
+	case id.GetTestResult() != nil:
+		resultID := id.GetTestResult()
+		run := t.run(invocation, resultID.GetLabel())
+		key := fmt.Sprintf("%s %s %d", resultID.GetLabel(), resultID.GetConfiguration().GetId(), resultID.GetShard())
+		switch event.GetTestResult().GetStatus() {
+		case buildeventstream.TestStatus_PASSED:
+			run.Passed++
+			t.passed[key] = true
+		case buildeventstream.TestStatus_FAILED, buildeventstream.TestStatus_TIMEOUT:
+			run.Failed++
+			t.failed[key] = true
+		default:
+			return
+		}
+		if t.passed[key] && t.failed[key] {
+			run.Flaky = true
+		}
+	case id.GetTestSummary() != nil:
+		status := event.GetTestSummary().GetOverallStatus()
+		run := t.run(invocation, id.GetTestSummary().GetLabel())
+		run.Status = status.String()
+		if status == buildeventstream.TestStatus_FLAKY {
+			run.Flaky = true
+		}
+	}
+}
+
+func (t *testAttempts) run(invocation *Invocation, label string) *TestRun {
+	run, ok := t.runs[label]
+	if !ok {
+		run = &TestRun{
+			InvocationID: invocation.ID,
+			Time:         invocation.StartTime,
+		}
+		t.runs[label] = run
+	}
+	return run
+}
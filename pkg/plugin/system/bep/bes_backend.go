@@ -24,6 +24,7 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
@@ -135,11 +136,16 @@ type besBackend struct {
 	subscribers           *subscriberList
 	mtSubscribers         *subscriberList
 	ignoreBesUploadErrors bool
+	config                *besConfig
+	configErr             error
 }
 
 // NewBESBackend creates a new Build Event Protocol backend.
 func NewBESBackend(ctx context.Context) BESBackend {
 	ignoreBesUploadErrors := os.Getenv("IGNORE_BES_UPLOAD_ERRORS") == "1"
+
+	config, configErr := loadBESConfig()
+
 	return &besBackend{
 		besProxies:            []besproxy.BESProxy{},
 		ctx:                   ctx,
@@ -151,6 +157,8 @@ func NewBESBackend(ctx context.Context) BESBackend {
 		subscribers:           &subscriberList{},
 		mtSubscribers:         &subscriberList{},
 		ignoreBesUploadErrors: ignoreBesUploadErrors,
+		config:                config,
+		configErr:             configErr,
 	}
 }
 
@@ -322,30 +330,97 @@ func (bb *besBackend) SendEventsToSubscribers(c <-chan *buildv1.PublishBuildTool
 	}
 }
 
-func (bb *besBackend) setupBesUpstreamBackends(ctx context.Context, optionsparsed *buildeventstream.OptionsParsed) error {
-	backends := []string{}
-	remoteHeaders := map[string]string{}
-	hasNoWaitForUpload := false
-	for _, arg := range optionsparsed.CmdLine {
-		if strings.HasPrefix(arg, "--bes_backend=") {
+// besUpstreams holds the user's BES configuration as reported by Bazel in the
+// OptionsParsed event, which includes both command line and bazelrc options.
+type besUpstreams struct {
+	// backend is the --bes_backend in effect, if any.
+	backend            string
+	headers            map[string]string
+	hasNoWaitForUpload bool
+}
+
+// parseBesUpstreams extracts the user's --bes_backend, --bes_header and
+// --remote_header values from the canonical command line. Like Bazel, the last
+// --bes_backend wins, so a bazelrc value overridden on the command line isn't
+// forwarded to. Our own backend at selfAddr is skipped to avoid recursive
+// uploads. Headers given with --bes_header take precedence over --remote_header
+// ones with the same name.
+func parseBesUpstreams(cmdLine []string, selfAddr string) (*besUpstreams, error) {
+	upstreams := &besUpstreams{
+		headers: map[string]string{},
+	}
+	besHeaders := map[string]string{}
+	for _, arg := range cmdLine {
+		if value, ok := strings.CutPrefix(arg, "--bes_backend="); ok {
 			// Always skip our bes_backend to avoid recursive uploads.
-			if arg == fmt.Sprintf("--bes_backend=%s", bb.Addr()) {
+			if value == selfAddr {
 				continue
 			}
-			backends = append(backends, strings.TrimLeft(arg, "--bes_backend="))
-		} else if strings.HasPrefix(arg, "--remote_header=") {
-			remoteHeader := strings.SplitN(strings.TrimLeft(arg, "--remote_header="), "=", 2)
-			if len(remoteHeader) != 2 {
-				return fmt.Errorf("invalid ---remote_header flag value '%v'; value must be in the form of a 'name=value' assignment", arg)
+			// An empty value resets any previously set backend, e.g. one from a bazelrc.
+			upstreams.backend = value
+		} else if value, ok := strings.CutPrefix(arg, "--remote_header="); ok {
+			name, headerValue, found := strings.Cut(value, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid --remote_header flag value '%v'; value must be in the form of a 'name=value' assignment", arg)
+			}
+			upstreams.headers[name] = headerValue
+		} else if value, ok := strings.CutPrefix(arg, "--bes_header="); ok {
+			name, headerValue, found := strings.Cut(value, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid --bes_header flag value '%v'; value must be in the form of a 'name=value' assignment", arg)
 			}
-			remoteHeaders[remoteHeader[0]] = remoteHeader[1]
-		} else if strings.HasPrefix(arg, "--bes_upload_mode=") {
-			mode := arg[len("--bes_upload_mode="):]
-			hasNoWaitForUpload = mode == "nowait_for_upload_complete" || mode == "fully_async"
+			besHeaders[name] = headerValue
+		} else if mode, ok := strings.CutPrefix(arg, "--bes_upload_mode="); ok {
+			upstreams.hasNoWaitForUpload = mode == "nowait_for_upload_complete" || mode == "fully_async"
+		}
+	}
+	for name, value := range besHeaders {
+		upstreams.headers[name] = value
+	}
+	return upstreams, nil
+}
+
+// forwardTargets returns the backends that the build events are forwarded to:
+// the --bes_backend in effect with the headers of the command line, and the
+// backends of the config.yaml with their own headers.
+func forwardTargets(upstreams *besUpstreams, configured []*besUpstream) []*besUpstream {
+	targets := []*besUpstream{}
+	if upstreams.backend != "" {
+		targets = append(targets, &besUpstream{backend: upstreams.backend, headers: upstreams.headers})
+	}
+	for _, c := range configured {
+		if !slices.ContainsFunc(targets, func(t *besUpstream) bool { return t.backend == c.backend }) {
+			targets = append(targets, c)
 		}
 	}
+	return targets
+}
+
+func (bb *besBackend) setupBesUpstreamBackends(ctx context.Context, optionsparsed *buildeventstream.OptionsParsed) error {
+	// Always signal readiness, even on failure, so that buffered events are
+	// released and the stream can complete.
+	defer close(bb.ready)
+
+	upstreams, err := parseBesUpstreams(optionsparsed.CmdLine, bb.Addr())
+	if err != nil {
+		return err
+	}
 
-	if hasNoWaitForUpload {
+	// Never forward events when the rules that should apply to them can't be loaded.
+	if bb.configErr != nil {
+		if upstreams.backend != "" {
+			fmt.Fprintf(
+				os.Stderr,
+				"%s Not forwarding build event stream to %s: %s\n",
+				color.RedString("ERROR:"),
+				upstreams.backend,
+				bb.configErr.Error(),
+			)
+		}
+		return nil
+	}
+
+	if upstreams.hasNoWaitForUpload {
 		fmt.Fprintf(
 			os.Stderr,
 			"%s --bes_upload_mode nowait_for_upload_complete|fully_async may lead to incomplete BES uploads with Aspect CLI\n\t"+
@@ -354,34 +429,27 @@ func (bb *besBackend) setupBesUpstreamBackends(ctx context.Context, optionsparse
 		)
 	}
 
-	// Supporting multiple backends as easy flipping this.
-	// See: https://github.com/aspect-build/silo/issues/3485
-	if len(backends) > 1 {
+	// Each user backend gets its own proxy and therefore its own upload stream.
+	for _, target := range forwardTargets(upstreams, bb.config.backends) {
+		userBesBackend := target.backend
+		var besProxy besproxy.BESProxy
+		if bb.config.spool != nil {
+			besProxy = besproxy.NewSpoolingBesProxy(userBesBackend, target.headers, bb.config.rules, *bb.config.spool)
+		} else {
+			besProxy = besproxy.NewBesProxy(userBesBackend, target.headers, bb.config.rules)
+		}
+		if err := besProxy.Connect(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to connect to build event stream backend %s: %s\n", userBesBackend, err.Error())
+			continue
+		}
 		fmt.Fprintf(
 			os.Stderr,
-			"%s Multiple BES backends were specified: %s. Forwarding to %s.\n",
+			"%s Forwarding build event stream to %v\n",
 			color.GreenString("INFO:"),
-			strings.Join(backends, ", "),
-			backends[len(backends)-1],
+			userBesBackend,
 		)
+		bb.RegisterBesProxy(ctx, besProxy)
 	}
-
-	if len(backends) > 0 {
-		userBesBackend := backends[len(backends)-1]
-		besProxy := besproxy.NewBesProxy(userBesBackend, remoteHeaders)
-		if err := besProxy.Connect(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to connect to build event stream backend %s: %s", userBesBackend, err.Error())
-		} else {
-			fmt.Fprintf(
-				os.Stderr,
-				"%s Forwarding build event stream to %v\n",
-				color.GreenString("INFO:"),
-				userBesBackend,
-			)
-			bb.RegisterBesProxy(ctx, besProxy)
-		}
-	}
-	close(bb.ready)
 	return nil
 }
 
@@ -587,3 +587,123 @@ func TestPublishBuildToolEventStream(t *testing.T) {
 		g.Expect(err).To(Not(HaveOccurred()))
 	})
 }
+
+func TestParseBesUpstreams(t *testing.T) {
+	t.Run("keeps the last user backend and skips our own", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstreams, err := parseBesUpstreams([]string{
+			"--bes_backend=grpcs://remote.buildbuddy.io",
+			"--bes_backend=grpcs://bes.engflow.com",
+			"--bes_backend=grpc://127.0.0.1:1234",
+		}, "grpc://127.0.0.1:1234")
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(upstreams.backend).To(Equal("grpcs://bes.engflow.com"))
+	})
+
+	t.Run("an empty --bes_backend resets earlier values", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstreams, err := parseBesUpstreams([]string{
+			"--bes_backend=grpcs://remote.buildbuddy.io",
+			"--bes_backend=",
+		}, "grpc://127.0.0.1:1234")
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(upstreams.backend).To(BeEmpty())
+	})
+
+	t.Run("merges headers with --bes_header taking precedence", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstreams, err := parseBesUpstreams([]string{
+			"--bes_header=x-buildbuddy-api-key=bes",
+			"--remote_header=x-buildbuddy-api-key=remote",
+			"--remote_header=authorization=Bearer a=b",
+		}, "grpc://127.0.0.1:1234")
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(upstreams.headers).To(Equal(map[string]string{
+			"x-buildbuddy-api-key": "bes",
+			"authorization":        "Bearer a=b",
+		}))
+	})
+
+	t.Run("keeps the headers of a bazelrc backend overridden on the command line", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstreams, err := parseBesUpstreams([]string{
+			"--bes_backend=grpcs://remote.buildbuddy.io",
+			"--bes_header=Authorization=secret",
+			"--bes_backend=grpcs://bes.engflow.com",
+		}, "grpc://127.0.0.1:1234")
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(upstreams.backend).To(Equal("grpcs://bes.engflow.com"))
+		g.Expect(upstreams.headers).To(Equal(map[string]string{"Authorization": "secret"}))
+	})
+
+	t.Run("fails on a malformed header", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := parseBesUpstreams([]string{"--bes_header=novalue"}, "grpc://127.0.0.1:1234")
+
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestForwardTargets(t *testing.T) {
+	t.Run("forwards to the backend in effect and the configured ones", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		upstreams := &besUpstreams{
+			backend: "grpcs://remote.buildbuddy.io",
+			headers: map[string]string{"x-buildbuddy-api-key": "secret"},
+		}
+		configured := []*besUpstream{
+			{backend: "grpcs://bes.engflow.com", headers: map[string]string{"x-engflow-auth-token": "token"}},
+			{backend: "grpcs://remote.buildbuddy.io", headers: map[string]string{}},
+		}
+
+		g.Expect(forwardTargets(upstreams, configured)).To(Equal([]*besUpstream{
+			{backend: "grpcs://remote.buildbuddy.io", headers: map[string]string{"x-buildbuddy-api-key": "secret"}},
+			{backend: "grpcs://bes.engflow.com", headers: map[string]string{"x-engflow-auth-token": "token"}},
+		}))
+	})
+
+	t.Run("forwards to the configured backends without --bes_backend", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		configured := []*besUpstream{{backend: "grpcs://bes.engflow.com", headers: map[string]string{}}}
+
+		g.Expect(forwardTargets(&besUpstreams{headers: map[string]string{}}, configured)).To(Equal(configured))
+	})
+}
+
+func TestUnmarshalBackends(t *testing.T) {
+	t.Run("expands the environment in the headers", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+		t.Setenv("ENGFLOW_TOKEN", "token")
+
+		backends, err := unmarshalBackends([]interface{}{
+			map[string]interface{}{
+				"backend": "grpcs://bes.engflow.com",
+				"headers": map[string]interface{}{"x-engflow-auth-token": "${ENGFLOW_TOKEN}"},
+			},
+		})
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(backends).To(Equal([]*besUpstream{
+			{backend: "grpcs://bes.engflow.com", headers: map[string]string{"x-engflow-auth-token": "token"}},
+		}))
+	})
+
+	t.Run("fails without a backend", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := unmarshalBackends([]interface{}{map[string]interface{}{"headers": map[string]interface{}{}}})
+
+		g.Expect(err).To(MatchError("expected bes.backends entry 0 to have a 'backend' attribute"))
+	})
+}
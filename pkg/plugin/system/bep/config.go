@@ -0,0 +1,133 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bep
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/besproxy"
+)
+
+// besConfig holds the `bes` attribute of the Aspect CLI config.yaml, which
+// controls how build events are forwarded to the user's BES backends:
+//
+//	bes:
+//	  spool: true
+//	  spool_max_attempts: 5
+//	  backends:
+//	    - backend: grpcs://bes.engflow.com
+//	      headers:
+//	        x-engflow-auth-token: ${ENGFLOW_TOKEN}
+//	  rules:
+//	    - drop_events:
+//	        - workspace_status
+//
+// The backends are forwarded the build events in addition to the --bes_backend
+// in effect for the invocation, each with its own headers. Environment
+// variables are expanded in the header values so that the credentials don't
+// need to be written in the config.yaml.
+//
+// See besproxy.EventRule for the format of the rules.
+type besConfig struct {
+	spool    *besproxy.SpoolOptions
+	backends []*besUpstream
+	rules    []*besproxy.EventRule
+}
+
+// besUpstream is a BES backend that the build events are forwarded to, along
+// with the headers sent to it.
+type besUpstream struct {
+	backend string
+	headers map[string]string
+}
+
+func loadBESConfig() (*besConfig, error) {
+	config := &besConfig{}
+
+	if viper.GetBool("bes.spool") {
+		spoolDir, err := besproxy.DefaultSpoolDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s BES spooling disabled: %s\n", color.YellowString("WARNING:"), err.Error())
+		} else {
+			config.spool = &besproxy.SpoolOptions{
+				Dir:         spoolDir,
+				MaxAttempts: viper.GetInt("bes.spool_max_attempts"),
+			}
+		}
+	}
+
+	backends, err := unmarshalBackends(viper.Get("bes.backends"))
+	if err != nil {
+		return config, fmt.Errorf("invalid BES configuration: %w", err)
+	}
+	config.backends = backends
+
+	rules, err := besproxy.UnmarshalEventRules(viper.Get("bes.rules"))
+	if err != nil {
+		return config, fmt.Errorf("invalid BES configuration: %w", err)
+	}
+	config.rules = rules
+
+	return config, nil
+}
+
+func unmarshalBackends(data interface{}) ([]*besUpstream, error) {
+	result := []*besUpstream{}
+
+	if data == nil {
+		return result, nil
+	}
+
+	entries, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected bes.backends config to be a list")
+	}
+
+	for i, e := range entries {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected bes.backends entry %v to be a map", i)
+		}
+
+		upstream := &besUpstream{headers: map[string]string{}}
+		if upstream.backend, ok = m["backend"].(string); !ok || upstream.backend == "" {
+			return nil, fmt.Errorf("expected bes.backends entry %v to have a 'backend' attribute", i)
+		}
+
+		if headers, ok := m["headers"]; ok {
+			h, ok := headers.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected bes.backends entry %v 'headers' attribute to be a map", i)
+			}
+			for name, value := range h {
+				v, ok := value.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected bes.backends entry %v header '%v' to be a string", i, name)
+				}
+				upstream.headers[name] = os.ExpandEnv(v)
+			}
+		}
+
+		result = append(result, upstream)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bep
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+
+	"github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+)
+
+// LocalFilePath returns the path on the local disk of a file reported in the Build Event Protocol.
+//
+// Files uploaded to a remote cache have a bytestream:// URI. They are expected to have been
+// downloaded to the output tree, for example because of --experimental_remote_download_regex.
+// If possible, the localExecRoot from the WorkspaceInfo event is used when constructing the path
+// in case the convenience symlinks are not present, e.g. if --experimental_convenience_symlinks=ignore
+// is specified. Otherwise the path is relative to the workspaceRoot.
+func LocalFilePath(file *buildeventstream.File, workspaceRoot string, localExecRoot string) (string, error) {
+	switch f := file.File.(type) {
+	case *buildeventstream.File_Uri:
+		uri, err := url.Parse(f.Uri)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse URI %s: %v", f.Uri, err)
+		}
+		if uri.Scheme == "file" {
+			return filepath.Clean(uri.Path), nil
+		} else if uri.Scheme == "bytestream" {
+			root := workspaceRoot
+			if localExecRoot != "" {
+				root = localExecRoot
+			}
+			return path.Join(root, path.Join(file.PathPrefix...), file.Name), nil
+		}
+		return "", fmt.Errorf("unsupported BES file uri %v", f.Uri)
+	default:
+		return "", fmt.Errorf("unsupported BES file type")
+	}
+}
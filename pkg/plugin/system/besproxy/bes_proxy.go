@@ -43,20 +43,22 @@ type BESProxy interface {
 	Send(req *buildv1.PublishBuildToolEventStreamRequest) error
 }
 
-func NewBesProxy(host string, headers map[string]string) *besProxy {
+func NewBesProxy(host string, headers map[string]string, rules []*EventRule) *besProxy {
 	return &besProxy{
-		host:    host,
-		headers: headers,
+		host:        host,
+		headers:     headers,
+		transformer: NewEventTransformer(rules, host),
 	}
 }
 
 type besProxy struct {
 	hadError bool
 
-	client  buildv1.PublishBuildEventClient
-	stream  buildv1.PublishBuildEvent_PublishBuildToolEventStreamClient
-	host    string
-	headers map[string]string
+	client      buildv1.PublishBuildEventClient
+	stream      buildv1.PublishBuildEvent_PublishBuildToolEventStreamClient
+	host        string
+	headers     map[string]string
+	transformer *EventTransformer
 }
 
 func (bp *besProxy) Connect() error {
@@ -104,11 +106,16 @@ func (bp *besProxy) Send(req *buildv1.PublishBuildToolEventStreamRequest) error
 		return fmt.Errorf("stream to %v is dead", bp.host)
 	}
 
-	// If we want to mutate the BES events in the future before they are sent out to external consumers, this is the place
-	// to do it. See https://github.com/aspect-build/silo/blob/7f13ab16fa10ffcec71b09737f0370f22a508823/pkg/plugin/system/besproxy/bes_proxy.go#L103
-	// as an example.
+	// Apply the configured event rules before the event leaves the machine.
+	req, keep, err := bp.transformer.Transform(req)
+	if err != nil {
+		return err
+	}
+	if !keep {
+		return nil
+	}
 
-	err := bp.stream.Send(req)
+	err = bp.stream.Send(req)
 
 	// EOF indicates the server sent an error which must be received.
 	if err == io.EOF {
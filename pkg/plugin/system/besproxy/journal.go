@@ -0,0 +1,260 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package besproxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	buildv1 "google.golang.org/genproto/googleapis/devtools/build/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	journalExt         = ".journal"
+	journalMetadataExt = ".json"
+)
+
+// JournalMetadata is stored next to each journal so that pending uploads can
+// be resumed by a later invocation. Headers are intentionally not persisted
+// since they commonly carry credentials.
+type JournalMetadata struct {
+	Backend             string    `json:"backend"`
+	Created             time.Time `json:"created"`
+	AckedSequenceNumber int64     `json:"acked_sequence_number"`
+}
+
+// journal is an append-only log of length-delimited build event stream
+// requests. Readers may follow the journal while it is still being written.
+type journal struct {
+	path   string
+	file   *os.File
+	mu     sync.Mutex
+	cond   *sync.Cond
+	size   int64
+	closed bool
+	// lock is held while the journal is written or uploaded, so that another
+	// invocation doesn't upload it at the same time.
+	lock *flock.Flock
+}
+
+// errJournalInUse is returned when another invocation holds the lock of a journal.
+var errJournalInUse = errors.New("build event journal is in use by another invocation")
+
+// createJournal creates a new, empty journal at path for writing.
+func createJournal(path string) (*journal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build event journal %s: %w", path, err)
+	}
+	j := &journal{path: path, file: f, lock: flock.New(path)}
+	j.cond = sync.NewCond(&j.mu)
+	if err := j.tryLock(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// openJournal opens an existing journal left behind by an earlier invocation.
+// The returned journal is read-only.
+func openJournal(path string) (*journal, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open build event journal %s: %w", path, err)
+	}
+	j := &journal{path: path, size: info.Size(), closed: true, lock: flock.New(path)}
+	j.cond = sync.NewCond(&j.mu)
+	if err := j.tryLock(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *journal) tryLock() error {
+	locked, err := j.lock.TryLock()
+	if err != nil {
+		return fmt.Errorf("failed to lock build event journal %s: %w", j.path, err)
+	}
+	if !locked {
+		return fmt.Errorf("%s: %w", j.path, errJournalInUse)
+	}
+	return nil
+}
+
+// Unlock releases the journal for other invocations once it is no longer
+// written or uploaded.
+func (j *journal) Unlock() error {
+	return j.lock.Unlock()
+}
+
+// journalInUse returns whether another invocation holds the lock of the
+// journal at path, such as a build that is still running.
+func journalInUse(path string) bool {
+	lock := flock.New(path)
+	locked, err := lock.TryLock()
+	if err != nil || !locked {
+		return true
+	}
+	lock.Unlock()
+	return false
+}
+
+// Append writes req to the end of the journal and wakes up any followers.
+func (j *journal) Append(req *buildv1.PublishBuildToolEventStreamRequest) error {
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal build event: %w", err)
+	}
+	record := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(b)), uint64(len(b)))
+	record = append(record, b...)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return fmt.Errorf("build event journal %s is closed", j.path)
+	}
+	if _, err := j.file.Write(record); err != nil {
+		return fmt.Errorf("failed to write build event journal %s: %w", j.path, err)
+	}
+	j.size += int64(len(record))
+	j.cond.Broadcast()
+	return nil
+}
+
+// Close marks the journal as complete. Followers return io.EOF once they have
+// read every record.
+func (j *journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	j.cond.Broadcast()
+	if j.file == nil {
+		return nil
+	}
+	if err := j.file.Sync(); err != nil {
+		j.file.Close()
+		return fmt.Errorf("failed to sync build event journal %s: %w", j.path, err)
+	}
+	return j.file.Close()
+}
+
+// Remove deletes the journal and its metadata from disk.
+func (j *journal) Remove() error {
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(metadataPath(j.path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// NewReader returns a reader that starts at the beginning of the journal.
+func (j *journal) NewReader() (*journalReader, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read build event journal %s: %w", j.path, err)
+	}
+	return &journalReader{journal: j, file: f}, nil
+}
+
+type journalReader struct {
+	journal *journal
+	file    *os.File
+	offset  int64
+}
+
+// Next returns the next record, blocking until one is written or the journal
+// is closed. A record truncated by a crash is treated as the end of the
+// journal.
+func (r *journalReader) Next() (*buildv1.PublishBuildToolEventStreamRequest, error) {
+	j := r.journal
+	j.mu.Lock()
+	for r.offset >= j.size && !j.closed {
+		j.cond.Wait()
+	}
+	size := j.size
+	j.mu.Unlock()
+
+	if r.offset >= size {
+		return nil, io.EOF
+	}
+
+	header := make([]byte, binary.MaxVarintLen64)
+	n, err := r.file.ReadAt(header, r.offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	length, k := binary.Uvarint(header[:n])
+	if k <= 0 || r.offset+int64(k)+int64(length) > size {
+		return nil, io.EOF
+	}
+	b := make([]byte, length)
+	if _, err := r.file.ReadAt(b, r.offset+int64(k)); err != nil {
+		return nil, fmt.Errorf("failed to read build event journal %s: %w", j.path, err)
+	}
+	req := &buildv1.PublishBuildToolEventStreamRequest{}
+	if err := proto.Unmarshal(b, req); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal build event from journal %s: %w", j.path, err)
+	}
+	r.offset += int64(k) + int64(length)
+	return req, nil
+}
+
+func (r *journalReader) Close() error {
+	return r.file.Close()
+}
+
+func metadataPath(journalPath string) string {
+	return journalPath[:len(journalPath)-len(journalExt)] + journalMetadataExt
+}
+
+func readJournalMetadata(journalPath string) (*JournalMetadata, error) {
+	b, err := os.ReadFile(metadataPath(journalPath))
+	if err != nil {
+		return nil, err
+	}
+	m := &JournalMetadata{}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", metadataPath(journalPath), err)
+	}
+	return m, nil
+}
+
+func writeJournalMetadata(journalPath string, m *JournalMetadata) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first so a crash never leaves partial metadata behind.
+	tmp := metadataPath(journalPath) + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, metadataPath(journalPath))
+}
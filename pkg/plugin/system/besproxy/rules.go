@@ -0,0 +1,424 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package besproxy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	buildv1 "google.golang.org/genproto/googleapis/devtools/build/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+)
+
+// Redacted replaces any value removed by an EventRule.
+const Redacted = "<REDACTED>"
+
+// envFlags are the Bazel flags whose values are NAME=VALUE environment
+// variable assignments.
+var envFlags = map[string]struct{}{
+	"action_env":      {},
+	"client_env":      {},
+	"host_action_env": {},
+	"repo_env":        {},
+	"run_env":         {},
+	"test_env":        {},
+}
+
+// credentialFlags matches the Bazel flags whose values carry credentials.
+var credentialFlags = regexp.MustCompile(`^(bes|remote|remote_cache|remote_downloader|remote_exec)_header$`)
+
+// EventRule describes how build events are changed before they are uploaded to
+// a BES backend. Rules are configured under `bes.rules` in the Aspect CLI
+// config.yaml:
+//
+//	bes:
+//	  rules:
+//	    - backend: grpcs://remote.buildbuddy.io  # optional, defaults to all backends
+//	      drop_events:
+//	        - workspace_status
+//	        - structured_command_line
+//	      redact_env:
+//	        - .*TOKEN.*
+//	      redact_flags:
+//	        - remote_header
+//	        - bes_header
+//	      redact:
+//	        - ghp_[A-Za-z0-9]+
+//	      rewrite_uris:
+//	        - pattern: ^file:///home/ci/
+//	          replacement: file:///workspace/
+type EventRule struct {
+	// Backend limits the rule to a single BES backend; empty matches all.
+	Backend string
+	// DropEvents are the build event kinds, named after the fields of the
+	// BuildEventId.id oneof, that are never uploaded.
+	DropEvents map[string]struct{}
+	// RedactEnv matches the names of environment variables whose values are
+	// redacted from --*_env flags.
+	RedactEnv []*regexp.Regexp
+	// RedactFlags matches the names of flags whose values are redacted.
+	RedactFlags []*regexp.Regexp
+	// Redact matches arbitrary substrings to redact from every string.
+	Redact []*regexp.Regexp
+	// RewriteURIs are applied to every file URI.
+	RewriteURIs []URIRewrite
+}
+
+// URIRewrite replaces the matches of Pattern in a file URI with Replacement.
+type URIRewrite struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// UnmarshalEventRules parses the `bes.rules` config.yaml attribute.
+func UnmarshalEventRules(data interface{}) ([]*EventRule, error) {
+	result := []*EventRule{}
+
+	if data == nil {
+		return result, nil
+	}
+
+	entries, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected bes.rules config to be a list")
+	}
+
+	eventKinds := (&buildeventstream.BuildEventId{}).ProtoReflect().Descriptor().Oneofs().ByName("id").Fields()
+
+	for i, e := range entries {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected bes.rules entry %v to be a map", i)
+		}
+
+		rule := &EventRule{DropEvents: map[string]struct{}{}}
+		if backend, ok := m["backend"]; ok {
+			if rule.Backend, ok = backend.(string); !ok {
+				return nil, fmt.Errorf("expected bes.rules entry %v 'backend' attribute to be a string", i)
+			}
+		}
+
+		dropEvents, err := stringList(m, "drop_events", i)
+		if err != nil {
+			return nil, err
+		}
+		for _, kind := range dropEvents {
+			if eventKinds.ByName(protoreflect.Name(kind)) == nil {
+				return nil, fmt.Errorf("bes.rules entry %v has an unknown build event kind '%v' in 'drop_events'", i, kind)
+			}
+			rule.DropEvents[kind] = struct{}{}
+		}
+
+		if rule.RedactEnv, err = regexpList(m, "redact_env", i, true); err != nil {
+			return nil, err
+		}
+		if rule.RedactFlags, err = regexpList(m, "redact_flags", i, true); err != nil {
+			return nil, err
+		}
+		if rule.Redact, err = regexpList(m, "redact", i, false); err != nil {
+			return nil, err
+		}
+
+		if rewrites, ok := m["rewrite_uris"]; ok {
+			list, ok := rewrites.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("expected bes.rules entry %v 'rewrite_uris' attribute to be a list", i)
+			}
+			for k, r := range list {
+				rm, ok := r.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("expected bes.rules entry %v 'rewrite_uris' item %v to be a map", i, k)
+				}
+				pattern, ok := rm["pattern"].(string)
+				if !ok {
+					return nil, fmt.Errorf("expected bes.rules entry %v 'rewrite_uris' item %v to have a 'pattern' attribute", i, k)
+				}
+				replacement, ok := rm["replacement"].(string)
+				if !ok {
+					return nil, fmt.Errorf("expected bes.rules entry %v 'rewrite_uris' item %v to have a 'replacement' attribute", i, k)
+				}
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("bes.rules entry %v 'rewrite_uris' item %v: %w", i, k, err)
+				}
+				rule.RewriteURIs = append(rule.RewriteURIs, URIRewrite{Pattern: re, Replacement: replacement})
+			}
+		}
+
+		result = append(result, rule)
+	}
+
+	return result, nil
+}
+
+func stringList(m map[string]interface{}, attr string, i int) ([]string, error) {
+	value, ok := m[attr]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected bes.rules entry %v '%v' attribute to be a list", i, attr)
+	}
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected bes.rules entry %v '%v' attribute to be a list of strings", i, attr)
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+// regexpList compiles a list of patterns. Name patterns must match the whole
+// name rather than a substring of it.
+func regexpList(m map[string]interface{}, attr string, i int, anchored bool) ([]*regexp.Regexp, error) {
+	patterns, err := stringList(m, attr, i)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		if anchored {
+			p = "^(?:" + p + ")$"
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("bes.rules entry %v '%v': %w", i, attr, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// EventTransformer applies the event rules for a single BES backend. Since
+// dropped events leave gaps that the Build Event Service would reject, the
+// remaining events are renumbered, so a transformer must only be used for a
+// single build event stream.
+type EventTransformer struct {
+	dropEvents  map[string]struct{}
+	redactEnv   []*regexp.Regexp
+	redactFlags []*regexp.Regexp
+	redact      []*regexp.Regexp
+	rewriteURIs []URIRewrite
+
+	dropped int64
+}
+
+// NewEventTransformer combines the rules that apply to backend. It returns nil
+// if no rule applies.
+func NewEventTransformer(rules []*EventRule, backend string) *EventTransformer {
+	var t *EventTransformer
+	for _, r := range rules {
+		if r.Backend != "" && r.Backend != backend {
+			continue
+		}
+		if t == nil {
+			t = &EventTransformer{dropEvents: map[string]struct{}{}}
+		}
+		for kind := range r.DropEvents {
+			t.dropEvents[kind] = struct{}{}
+		}
+		t.redactEnv = append(t.redactEnv, r.RedactEnv...)
+		t.redactFlags = append(t.redactFlags, r.RedactFlags...)
+		t.redact = append(t.redact, r.Redact...)
+		t.rewriteURIs = append(t.rewriteURIs, r.RewriteURIs...)
+	}
+	return t
+}
+
+// NewLocalEventTransformer combines the rules that apply to every backend for
+// build events that Aspect CLI writes to disk itself, such as the local
+// history. The values of the header flags, which carry credentials, are always
+// redacted.
+func NewLocalEventTransformer(rules []*EventRule) *EventTransformer {
+	rules = append(slices.Clone(rules), &EventRule{RedactFlags: []*regexp.Regexp{credentialFlags}})
+	return NewEventTransformer(rules, "")
+}
+
+// Redact returns a copy of event with the values matched by the rules
+// redacted. Unlike Transform, no event is dropped. The given event is never
+// modified since it is shared with the subscribers.
+func (t *EventTransformer) Redact(event *buildeventstream.BuildEvent) *buildeventstream.BuildEvent {
+	if t == nil {
+		return event
+	}
+	out := proto.Clone(event).(*buildeventstream.BuildEvent)
+	t.redactMessage(out.ProtoReflect())
+	return out
+}
+
+// Transform returns the request to upload in place of req, or false if the
+// event must be dropped. The event that carries last_message is never dropped. The given request is never modified since it is
+// shared with the other proxies and the subscribers.
+func (t *EventTransformer) Transform(req *buildv1.PublishBuildToolEventStreamRequest) (*buildv1.PublishBuildToolEventStreamRequest, bool, error) {
+	if t == nil {
+		return req, true, nil
+	}
+
+	out := proto.Clone(req).(*buildv1.PublishBuildToolEventStreamRequest)
+	if bazelEvent := out.GetOrderedBuildEvent().GetEvent().GetBazelEvent(); bazelEvent != nil {
+		event := &buildeventstream.BuildEvent{}
+		if err := bazelEvent.UnmarshalTo(event); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal build event %v: %w", req.GetOrderedBuildEvent().GetSequenceNumber(), err)
+		}
+		if t.isDropped(event.GetId()) {
+			if !event.GetLastMessage() {
+				t.dropped++
+				return nil, false, nil
+			}
+			// The last message ends the stream, so it's forwarded without its
+			// payload rather than dropped.
+			event = &buildeventstream.BuildEvent{Id: event.GetId(), LastMessage: true}
+		}
+
+		children := event.Children[:0]
+		for _, child := range event.Children {
+			if !t.isDropped(child) {
+				children = append(children, child)
+			}
+		}
+		event.Children = children
+		t.redactMessage(event.ProtoReflect())
+
+		b, err := anypb.New(event)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal build event %v: %w", req.GetOrderedBuildEvent().GetSequenceNumber(), err)
+		}
+		out.OrderedBuildEvent.Event.Event = &buildv1.BuildEvent_BazelEvent{BazelEvent: b}
+	}
+	if out.OrderedBuildEvent != nil {
+		out.OrderedBuildEvent.SequenceNumber -= t.dropped
+	}
+	return out, true, nil
+}
+
+func (t *EventTransformer) isDropped(id *buildeventstream.BuildEventId) bool {
+	if id == nil || len(t.dropEvents) == 0 {
+		return false
+	}
+	m := id.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("id"))
+	if field == nil {
+		return false
+	}
+	_, ok := t.dropEvents[string(field.Name())]
+	return ok
+}
+
+func (t *EventTransformer) redactMessage(m protoreflect.Message) {
+	fields := []protoreflect.FieldDescriptor{}
+	m.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		fields = append(fields, fd)
+		return true
+	})
+
+	for _, fd := range fields {
+		v := m.Get(fd)
+		switch {
+		case fd.IsList():
+			list := v.List()
+			// The value of a flag may be the next element of a command line, as in
+			// --remote_header x-api-key=secret.
+			previous := ""
+			for i := 0; i < list.Len(); i++ {
+				if fd.Kind() == protoreflect.StringKind {
+					s := list.Get(i).String()
+					redacted := t.redactString(fd, s)
+					if name, ok := strings.CutPrefix(previous, "--"); ok && !strings.Contains(name, "=") && !strings.HasPrefix(s, "-") {
+						redacted = t.redactFlagValue(name, redacted)
+					}
+					previous = s
+					list.Set(i, protoreflect.ValueOfString(redacted))
+				} else if fd.Message() != nil {
+					t.redactMessage(list.Get(i).Message())
+				}
+			}
+		case fd.IsMap():
+			mp := v.Map()
+			keys := []protoreflect.MapKey{}
+			mp.Range(func(k protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, k)
+				return true
+			})
+			for _, k := range keys {
+				if fd.MapValue().Kind() == protoreflect.StringKind {
+					mp.Set(k, protoreflect.ValueOfString(t.redactString(fd, mp.Get(k).String())))
+				} else if fd.MapValue().Message() != nil {
+					t.redactMessage(mp.Get(k).Message())
+				}
+			}
+		case fd.Kind() == protoreflect.StringKind:
+			m.Set(fd, protoreflect.ValueOfString(t.redactString(fd, v.String())))
+		case fd.Message() != nil:
+			t.redactMessage(v.Message())
+		}
+	}
+
+	// Structured command lines carry flags split into a name and a value.
+	if m.Descriptor().FullName() == "command_line.Option" {
+		nameField := m.Descriptor().Fields().ByName("option_name")
+		valueField := m.Descriptor().Fields().ByName("option_value")
+		name := m.Get(nameField).String()
+		value := m.Get(valueField).String()
+		if redacted := t.redactFlagValue(name, value); redacted != value {
+			m.Set(valueField, protoreflect.ValueOfString(redacted))
+		}
+	}
+}
+
+func (t *EventTransformer) redactString(fd protoreflect.FieldDescriptor, s string) string {
+	if fd.Name() == "uri" {
+		for _, r := range t.rewriteURIs {
+			s = r.Pattern.ReplaceAllString(s, r.Replacement)
+		}
+	}
+	if strings.HasPrefix(s, "--") {
+		if name, value, found := strings.Cut(s[2:], "="); found {
+			s = "--" + name + "=" + t.redactFlagValue(name, value)
+		}
+	}
+	for _, re := range t.redact {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+func (t *EventTransformer) redactFlagValue(name, value string) string {
+	for _, re := range t.redactFlags {
+		if re.MatchString(name) {
+			return Redacted
+		}
+	}
+	if _, ok := envFlags[name]; ok {
+		if envName, _, found := strings.Cut(value, "="); found {
+			for _, re := range t.redactEnv {
+				if re.MatchString(envName) {
+					return envName + "=" + Redacted
+				}
+			}
+		}
+	}
+	return value
+}
@@ -0,0 +1,243 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package besproxy
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	buildv1 "google.golang.org/genproto/googleapis/devtools/build/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	buildeventstream "github.com/aspect-build/aspect-cli/bazel/buildeventstream"
+	"github.com/aspect-build/aspect-cli/bazel/command_line"
+)
+
+func newBazelRequest(t *testing.T, sequenceNumber int64, event *buildeventstream.BuildEvent) *buildv1.PublishBuildToolEventStreamRequest {
+	b, err := anypb.New(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &buildv1.PublishBuildToolEventStreamRequest{
+		OrderedBuildEvent: &buildv1.OrderedBuildEvent{
+			SequenceNumber: sequenceNumber,
+			Event:          &buildv1.BuildEvent{Event: &buildv1.BuildEvent_BazelEvent{BazelEvent: b}},
+		},
+	}
+}
+
+func unmarshalBazelEvent(t *testing.T, req *buildv1.PublishBuildToolEventStreamRequest) *buildeventstream.BuildEvent {
+	event := &buildeventstream.BuildEvent{}
+	if err := req.OrderedBuildEvent.Event.GetBazelEvent().UnmarshalTo(event); err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func workspaceStatusId() *buildeventstream.BuildEventId {
+	return &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_WorkspaceStatus{WorkspaceStatus: &buildeventstream.BuildEventId_WorkspaceStatusId{}}}
+}
+
+func progressId() *buildeventstream.BuildEventId {
+	return &buildeventstream.BuildEventId{Id: &buildeventstream.BuildEventId_Progress{Progress: &buildeventstream.BuildEventId_ProgressId{}}}
+}
+
+func TestUnmarshalEventRules(t *testing.T) {
+	t.Run("parses every attribute", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules, err := UnmarshalEventRules([]interface{}{
+			map[string]interface{}{
+				"backend":      "grpcs://remote.buildbuddy.io",
+				"drop_events":  []interface{}{"workspace_status"},
+				"redact_env":   []interface{}{".*TOKEN.*"},
+				"redact_flags": []interface{}{"remote_header"},
+				"redact":       []interface{}{"ghp_[a-z]+"},
+				"rewrite_uris": []interface{}{
+					map[string]interface{}{"pattern": "^file:///home/ci/", "replacement": "file:///workspace/"},
+				},
+			},
+		})
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(rules).To(HaveLen(1))
+		g.Expect(rules[0].Backend).To(Equal("grpcs://remote.buildbuddy.io"))
+		g.Expect(rules[0].DropEvents).To(HaveKey("workspace_status"))
+		g.Expect(rules[0].RedactEnv).To(HaveLen(1))
+		g.Expect(rules[0].RedactFlags).To(HaveLen(1))
+		g.Expect(rules[0].Redact).To(HaveLen(1))
+		g.Expect(rules[0].RewriteURIs).To(HaveLen(1))
+	})
+
+	t.Run("rejects unknown event kinds", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := UnmarshalEventRules([]interface{}{
+			map[string]interface{}{"drop_events": []interface{}{"workspace_stats"}},
+		})
+
+		g.Expect(err).To(MatchError(ContainSubstring("unknown build event kind 'workspace_stats'")))
+	})
+
+	t.Run("rejects invalid patterns", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		_, err := UnmarshalEventRules([]interface{}{
+			map[string]interface{}{"redact": []interface{}{"("}},
+		})
+
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestEventTransformer(t *testing.T) {
+	t.Run("is nil when no rule applies to the backend", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		transformer := NewEventTransformer([]*EventRule{{Backend: "grpcs://other"}}, "grpcs://bes")
+
+		g.Expect(transformer).To(BeNil())
+	})
+
+	t.Run("drops events and renumbers the rest", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		transformer := NewEventTransformer([]*EventRule{
+			{DropEvents: map[string]struct{}{"workspace_status": {}}},
+		}, "grpcs://bes")
+
+		started := newBazelRequest(t, 1, &buildeventstream.BuildEvent{
+			Id:       progressId(),
+			Children: []*buildeventstream.BuildEventId{workspaceStatusId(), progressId()},
+		})
+		status := newBazelRequest(t, 2, &buildeventstream.BuildEvent{Id: workspaceStatusId()})
+		progress := newBazelRequest(t, 3, &buildeventstream.BuildEvent{Id: progressId()})
+
+		out, keep, err := transformer.Transform(started)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(keep).To(BeTrue())
+		g.Expect(unmarshalBazelEvent(t, out).Children).To(HaveLen(1))
+
+		_, keep, err = transformer.Transform(status)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(keep).To(BeFalse())
+
+		out, keep, err = transformer.Transform(progress)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(keep).To(BeTrue())
+		g.Expect(out.OrderedBuildEvent.SequenceNumber).To(Equal(int64(2)))
+		g.Expect(progress.OrderedBuildEvent.SequenceNumber).To(Equal(int64(3)))
+	})
+
+	t.Run("forwards a dropped last message without its payload", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		transformer := NewEventTransformer([]*EventRule{
+			{DropEvents: map[string]struct{}{"workspace_status": {}}},
+		}, "grpcs://bes")
+
+		status := newBazelRequest(t, 1, &buildeventstream.BuildEvent{
+			Id:          workspaceStatusId(),
+			LastMessage: true,
+			Payload: &buildeventstream.BuildEvent_WorkspaceStatus{WorkspaceStatus: &buildeventstream.WorkspaceStatus{
+				Item: []*buildeventstream.WorkspaceStatus_Item{{Key: "BUILD_USER", Value: "ci"}},
+			}},
+		})
+
+		out, keep, err := transformer.Transform(status)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(keep).To(BeTrue())
+		event := unmarshalBazelEvent(t, out)
+		g.Expect(event.LastMessage).To(BeTrue())
+		g.Expect(event.GetWorkspaceStatus()).To(BeNil())
+	})
+
+	t.Run("redacts flags, env vars and patterns and rewrites URIs", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		rules, err := UnmarshalEventRules([]interface{}{
+			map[string]interface{}{
+				"redact_env":   []interface{}{".*TOKEN"},
+				"redact_flags": []interface{}{"remote_header"},
+				"redact":       []interface{}{"ghp_[a-z]+"},
+				"rewrite_uris": []interface{}{
+					map[string]interface{}{"pattern": "^file:///home/ci/", "replacement": "file:///workspace/"},
+				},
+			},
+		})
+		g.Expect(err).To(Not(HaveOccurred()))
+		transformer := NewEventTransformer(rules, "grpcs://bes")
+
+		req := newBazelRequest(t, 1, &buildeventstream.BuildEvent{
+			Id: progressId(),
+			Payload: &buildeventstream.BuildEvent_OptionsParsed{OptionsParsed: &buildeventstream.OptionsParsed{
+				CmdLine: []string{
+					"--remote_header=x-api-key=secret",
+					"--remote_header",
+					"x-api-key=secret",
+					"--client_env=GH_TOKEN=secret",
+					"--client_env",
+					"GH_TOKEN=secret",
+					"--client_env=HOME=/home/ci",
+					"--define=key=ghp_abc",
+					"//pkg:target",
+				},
+			}},
+		})
+		out, _, err := transformer.Transform(req)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(unmarshalBazelEvent(t, out).GetOptionsParsed().CmdLine).To(Equal([]string{
+			"--remote_header=" + Redacted,
+			"--remote_header",
+			Redacted,
+			"--client_env=GH_TOKEN=" + Redacted,
+			"--client_env",
+			"GH_TOKEN=" + Redacted,
+			"--client_env=HOME=/home/ci",
+			"--define=key=" + Redacted,
+			"//pkg:target",
+		}))
+
+		req = newBazelRequest(t, 2, &buildeventstream.BuildEvent{
+			Id: progressId(),
+			Payload: &buildeventstream.BuildEvent_StructuredCommandLine{StructuredCommandLine: &command_line.CommandLine{
+				Sections: []*command_line.CommandLineSection{{
+					SectionType: &command_line.CommandLineSection_OptionList{OptionList: &command_line.OptionList{
+						Option: []*command_line.Option{{OptionName: "remote_header", OptionValue: "x-api-key=secret"}},
+					}},
+				}},
+			}},
+		})
+		out, _, err = transformer.Transform(req)
+		g.Expect(err).To(Not(HaveOccurred()))
+		option := unmarshalBazelEvent(t, out).GetStructuredCommandLine().Sections[0].GetOptionList().Option[0]
+		g.Expect(option.OptionValue).To(Equal(Redacted))
+
+		req = newBazelRequest(t, 3, &buildeventstream.BuildEvent{
+			Id: progressId(),
+			Payload: &buildeventstream.BuildEvent_NamedSetOfFiles{NamedSetOfFiles: &buildeventstream.NamedSetOfFiles{
+				Files: []*buildeventstream.File{{
+					Name: "out.txt",
+					File: &buildeventstream.File_Uri{Uri: "file:///home/ci/out.txt"},
+				}},
+			}},
+		})
+		out, _, err = transformer.Transform(req)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(unmarshalBazelEvent(t, out).GetNamedSetOfFiles().Files[0].GetUri()).To(Equal("file:///workspace/out.txt"))
+	})
+}
@@ -0,0 +1,444 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package besproxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	buildv1 "google.golang.org/genproto/googleapis/devtools/build/v1"
+
+	"github.com/aspect-build/aspect-cli/pkg/ioutils/cache"
+)
+
+const (
+	defaultSpoolMaxAttempts = 5
+	spoolInitialBackoff     = 500 * time.Millisecond
+	spoolMaxBackoff         = 30 * time.Second
+)
+
+// SpoolOptions configures the durable spooling mode of the BES proxy.
+type SpoolOptions struct {
+	// Dir is the directory the journals are written to.
+	Dir string
+	// MaxAttempts is the number of times an upload is attempted before the
+	// journal is left on disk for `aspect bes upload-pending`.
+	MaxAttempts int
+}
+
+// DefaultSpoolDir returns the directory under the Aspect cache dir where
+// build event journals are spooled.
+func DefaultSpoolDir() (string, error) {
+	aspectCacheDir, err := cache.AspectCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(aspectCacheDir, "bes-spool"), nil
+}
+
+// NewSpoolingBesProxy creates a BES proxy that writes every build event to an
+// on-disk journal before it is uploaded. Failed uploads are retried with
+// backoff, resuming from the last acknowledged sequence number, and any events
+// that still could not be uploaded stay on disk for a later invocation.
+func NewSpoolingBesProxy(host string, headers map[string]string, rules []*EventRule, opts SpoolOptions) *spoolingProxy {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultSpoolMaxAttempts
+	}
+	return &spoolingProxy{
+		host:        host,
+		headers:     headers,
+		transformer: NewEventTransformer(rules, host),
+		opts:        opts,
+		done:        make(chan struct{}),
+	}
+}
+
+type spoolingProxy struct {
+	client      buildv1.PublishBuildEventClient
+	host        string
+	headers     map[string]string
+	transformer *EventTransformer
+	opts        SpoolOptions
+
+	journal  *journal
+	metadata *JournalMetadata
+	ackMutex sync.Mutex
+
+	done chan struct{}
+}
+
+func (sp *spoolingProxy) Connect() error {
+	c, err := grpcDial(sp.host, sp.headers)
+	if err != nil {
+		return fmt.Errorf("failed to connect to build event stream backend %s: %w", sp.host, err)
+	}
+	sp.client = buildv1.NewPublishBuildEventClient(c)
+	return nil
+}
+
+func (sp *spoolingProxy) Host() string {
+	return sp.host
+}
+
+// PublishLifecycleEvent forwards the lifecycle event but never reports an
+// error back to Bazel; in spool mode only the build event stream is durable.
+func (sp *spoolingProxy) PublishLifecycleEvent(ctx context.Context, req *buildv1.PublishLifecycleEventRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	if sp.client == nil {
+		return &emptypb.Empty{}, fmt.Errorf("not connected to %v", sp.host)
+	}
+	if _, err := sp.client.PublishLifecycleEvent(ctx, req, opts...); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed to publish lifecycle event to %v: %s\n", color.YellowString("WARNING:"), sp.host, err.Error())
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// PublishBuildToolEventStream creates the journal and starts uploading it in
+// the background.
+func (sp *spoolingProxy) PublishBuildToolEventStream(ctx context.Context, opts ...grpc.CallOption) error {
+	if sp.client == nil {
+		return fmt.Errorf("not connected to %v", sp.host)
+	}
+	if err := os.MkdirAll(sp.opts.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create BES spool directory %s: %w", sp.opts.Dir, err)
+	}
+	path := filepath.Join(sp.opts.Dir, journalName(sp.host))
+	j, err := createJournal(path)
+	if err != nil {
+		return err
+	}
+	sp.metadata = &JournalMetadata{Backend: sp.host, Created: time.Now()}
+	if err := writeJournalMetadata(path, sp.metadata); err != nil {
+		j.Close()
+		j.Remove()
+		j.Unlock()
+		return fmt.Errorf("failed to write BES spool metadata: %w", err)
+	}
+	sp.journal = j
+	go sp.upload(ctx, opts...)
+	return nil
+}
+
+func (sp *spoolingProxy) StreamCreated() bool {
+	return sp.journal != nil
+}
+
+// Send appends the event to the journal; the upload happens asynchronously.
+// The configured event rules are applied before journaling so that a later
+// replay uploads exactly the same events.
+func (sp *spoolingProxy) Send(req *buildv1.PublishBuildToolEventStreamRequest) error {
+	if sp.journal == nil {
+		return fmt.Errorf("stream to %v not configured", sp.host)
+	}
+	req, keep, err := sp.transformer.Transform(req)
+	if err != nil {
+		return err
+	}
+	if !keep {
+		return nil
+	}
+	return sp.journal.Append(req)
+}
+
+// Recv blocks until the upload has finished and then returns io.EOF. Events
+// that could not be uploaded are left in the journal, so a failed upload is
+// reported as a warning rather than an error.
+func (sp *spoolingProxy) Recv() (*buildv1.PublishBuildToolEventStreamResponse, error) {
+	if sp.journal == nil {
+		return nil, fmt.Errorf("stream to %v not configured", sp.host)
+	}
+	<-sp.done
+	return nil, io.EOF
+}
+
+// CloseSend marks the end of the build event stream.
+func (sp *spoolingProxy) CloseSend() error {
+	if sp.journal == nil {
+		return nil
+	}
+	return sp.journal.Close()
+}
+
+func (sp *spoolingProxy) ack(sequenceNumber int64) {
+	sp.ackMutex.Lock()
+	defer sp.ackMutex.Unlock()
+	if sequenceNumber > sp.metadata.AckedSequenceNumber {
+		sp.metadata.AckedSequenceNumber = sequenceNumber
+	}
+}
+
+func (sp *spoolingProxy) acked() int64 {
+	sp.ackMutex.Lock()
+	defer sp.ackMutex.Unlock()
+	return sp.metadata.AckedSequenceNumber
+}
+
+func (sp *spoolingProxy) upload(ctx context.Context, opts ...grpc.CallOption) {
+	defer close(sp.done)
+	defer sp.journal.Unlock()
+	err := uploadWithRetries(ctx, sp.client, sp.journal, sp.host, sp.opts.MaxAttempts, sp.ack, sp.acked, opts...)
+	if err == nil {
+		if err := sp.journal.Remove(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed to remove BES spool journal %s: %s\n", color.YellowString("WARNING:"), sp.journal.path, err.Error())
+		}
+		return
+	}
+
+	// Wait for the rest of the stream so that the journal on disk is complete.
+	sp.journal.mu.Lock()
+	for !sp.journal.closed {
+		sp.journal.cond.Wait()
+	}
+	sp.journal.mu.Unlock()
+
+	sp.ackMutex.Lock()
+	metaErr := writeJournalMetadata(sp.journal.path, sp.metadata)
+	sp.ackMutex.Unlock()
+	if metaErr != nil {
+		fmt.Fprintf(os.Stderr, "%s failed to update BES spool metadata for %s: %s\n", color.YellowString("WARNING:"), sp.journal.path, metaErr.Error())
+	}
+	fmt.Fprintf(
+		os.Stderr,
+		"%s Failed to upload build event stream to %v: %s\n\tThe build events were spooled to %s. Run 'aspect bes upload-pending' to upload them.\n",
+		color.YellowString("WARNING:"),
+		sp.host,
+		err.Error(),
+		sp.journal.path,
+	)
+}
+
+// journalName returns a unique journal file name for an upload to host.
+func journalName(host string) string {
+	hash := sha256.Sum256([]byte(host))
+	return fmt.Sprintf("%d-%x%s", time.Now().UnixNano(), hash[:8], journalExt)
+}
+
+// uploadWithRetries uploads the journal, retrying with exponential backoff.
+// Each attempt resumes after the last acknowledged sequence number.
+func uploadWithRetries(
+	ctx context.Context,
+	client buildv1.PublishBuildEventClient,
+	j *journal,
+	host string,
+	maxAttempts int,
+	ack func(int64),
+	acked func() int64,
+	opts ...grpc.CallOption,
+) error {
+	backoff := spoolInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := uploadOnce(ctx, client, j, ack, acked, opts...)
+		if err == nil {
+			return nil
+		}
+		if attempt >= maxAttempts || ctx.Err() != nil {
+			return err
+		}
+		fmt.Fprintf(
+			os.Stderr,
+			"%s Build event stream upload to %v failed (attempt %d/%d), retrying in %v: %s\n",
+			color.YellowString("WARNING:"),
+			host,
+			attempt,
+			maxAttempts,
+			backoff,
+			err.Error(),
+		)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > spoolMaxBackoff {
+			backoff = spoolMaxBackoff
+		}
+	}
+}
+
+func uploadOnce(
+	ctx context.Context,
+	client buildv1.PublishBuildEventClient,
+	j *journal,
+	ack func(int64),
+	acked func() int64,
+	opts ...grpc.CallOption,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.PublishBuildToolEventStream(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	acksDone := make(chan error, 1)
+	go func() {
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				acksDone <- nil
+				return
+			}
+			if err != nil {
+				acksDone <- err
+				return
+			}
+			ack(res.GetSequenceNumber())
+		}
+	}()
+
+	r, err := j.NewReader()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	resumeAfter := acked()
+	var last int64
+	for {
+		req, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		last = req.GetOrderedBuildEvent().GetSequenceNumber()
+		if last <= resumeAfter {
+			continue
+		}
+		if err := stream.Send(req); err != nil {
+			// EOF indicates the server sent an error which must be received.
+			if err == io.EOF {
+				if recvErr := <-acksDone; recvErr != nil {
+					return recvErr
+				}
+			}
+			return err
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	if err := <-acksDone; err != nil {
+		return err
+	}
+	if acked() < last {
+		return fmt.Errorf("stream closed after acknowledging sequence number %d of %d", acked(), last)
+	}
+	return nil
+}
+
+// PendingJournal is a journal left behind by an earlier invocation whose
+// upload did not complete.
+type PendingJournal struct {
+	Path     string
+	Metadata JournalMetadata
+}
+
+// PendingJournals lists the journals in dir, oldest first. Journals that a
+// running invocation is still writing or uploading are skipped, as are journals
+// without readable metadata, such as those of an invocation that was killed
+// right after creating them.
+func PendingJournals(dir string) ([]PendingJournal, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read BES spool directory %s: %w", dir, err)
+	}
+	pending := []PendingJournal{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), journalExt) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if journalInUse(path) {
+			continue
+		}
+		m, err := readJournalMetadata(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s skipping BES spool journal %s since its metadata can't be read, delete it if it isn't needed: %s\n", color.YellowString("WARNING:"), path, err.Error())
+			continue
+		}
+		pending = append(pending, PendingJournal{Path: path, Metadata: *m})
+	}
+	sort.Slice(pending, func(i, k int) bool {
+		return pending[i].Metadata.Created.Before(pending[k].Metadata.Created)
+	})
+	return pending, nil
+}
+
+// UploadPendingJournal uploads a journal left behind by an earlier invocation,
+// resuming after its last acknowledged sequence number. The journal is removed
+// once every event has been acknowledged; otherwise its progress is recorded
+// so a later attempt can resume from there.
+func UploadPendingJournal(ctx context.Context, p PendingJournal, headers map[string]string, maxAttempts int) error {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultSpoolMaxAttempts
+	}
+	j, err := openJournal(p.Path)
+	if err != nil {
+		return err
+	}
+	defer j.Unlock()
+	c, err := grpcDial(p.Metadata.Backend, headers)
+	if err != nil {
+		return fmt.Errorf("failed to connect to build event stream backend %s: %w", p.Metadata.Backend, err)
+	}
+	defer c.Close()
+
+	metadata := p.Metadata
+	var mutex sync.Mutex
+	ack := func(sequenceNumber int64) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if sequenceNumber > metadata.AckedSequenceNumber {
+			metadata.AckedSequenceNumber = sequenceNumber
+		}
+	}
+	acked := func() int64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return metadata.AckedSequenceNumber
+	}
+
+	client := buildv1.NewPublishBuildEventClient(c)
+	if err := uploadWithRetries(ctx, client, j, p.Metadata.Backend, maxAttempts, ack, acked); err != nil {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if metaErr := writeJournalMetadata(p.Path, &metadata); metaErr != nil {
+			return fmt.Errorf("%w (and failed to record upload progress: %v)", err, metaErr)
+		}
+		return err
+	}
+	return j.Remove()
+}
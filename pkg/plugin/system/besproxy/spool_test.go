@@ -0,0 +1,251 @@
+/*
+ * Copyright 2026 Aspect Build Systems, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package besproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	buildv1 "google.golang.org/genproto/googleapis/devtools/build/v1"
+	"google.golang.org/grpc"
+)
+
+func newRequest(sequenceNumber int64) *buildv1.PublishBuildToolEventStreamRequest {
+	return &buildv1.PublishBuildToolEventStreamRequest{
+		OrderedBuildEvent: &buildv1.OrderedBuildEvent{
+			StreamId:       &buildv1.StreamId{InvocationId: "inv"},
+			SequenceNumber: sequenceNumber,
+		},
+	}
+}
+
+// fakeClient acks every event it receives and fails the stream after failAfter
+// events when failAfter is positive.
+type fakeClient struct {
+	buildv1.PublishBuildEventClient
+	mutex     sync.Mutex
+	received  []int64
+	failAfter int
+}
+
+func (c *fakeClient) PublishBuildToolEventStream(ctx context.Context, opts ...grpc.CallOption) (buildv1.PublishBuildEvent_PublishBuildToolEventStreamClient, error) {
+	return &fakeStream{client: c, acks: make(chan int64, 100)}, nil
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	client *fakeClient
+	acks   chan int64
+}
+
+func (s *fakeStream) Send(req *buildv1.PublishBuildToolEventStreamRequest) error {
+	s.client.mutex.Lock()
+	defer s.client.mutex.Unlock()
+	if s.client.failAfter > 0 && len(s.client.received) == s.client.failAfter {
+		s.client.failAfter = 0
+		close(s.acks)
+		return fmt.Errorf("connection reset")
+	}
+	s.client.received = append(s.client.received, req.OrderedBuildEvent.SequenceNumber)
+	s.acks <- req.OrderedBuildEvent.SequenceNumber
+	return nil
+}
+
+func (s *fakeStream) CloseSend() error {
+	close(s.acks)
+	return nil
+}
+
+func (s *fakeStream) Recv() (*buildv1.PublishBuildToolEventStreamResponse, error) {
+	seq, ok := <-s.acks
+	if !ok {
+		return nil, io.EOF
+	}
+	return &buildv1.PublishBuildToolEventStreamResponse{SequenceNumber: seq}, nil
+}
+
+func writeJournal(t *testing.T, path string, count int) *journal {
+	j, err := createJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= count; i++ {
+		if err := j.Append(newRequest(int64(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return j
+}
+
+func TestJournal(t *testing.T) {
+	t.Run("readers follow the journal until it is closed", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		j := writeJournal(t, filepath.Join(t.TempDir(), "a.journal"), 2)
+		r, err := j.NewReader()
+		g.Expect(err).To(Not(HaveOccurred()))
+		defer r.Close()
+
+		for i := int64(1); i <= 2; i++ {
+			req, err := r.Next()
+			g.Expect(err).To(Not(HaveOccurred()))
+			g.Expect(req.OrderedBuildEvent.SequenceNumber).To(Equal(i))
+		}
+
+		next := make(chan int64)
+		go func() {
+			req, _ := r.Next()
+			next <- req.GetOrderedBuildEvent().GetSequenceNumber()
+		}()
+		g.Expect(j.Append(newRequest(3))).To(Succeed())
+		g.Expect(<-next).To(Equal(int64(3)))
+
+		g.Expect(j.Close()).To(Succeed())
+		_, err = r.Next()
+		g.Expect(err).To(Equal(io.EOF))
+	})
+
+	t.Run("a truncated trailing record ends the journal", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		path := filepath.Join(t.TempDir(), "a.journal")
+		j := writeJournal(t, path, 2)
+		g.Expect(j.Close()).To(Succeed())
+		g.Expect(j.Unlock()).To(Succeed())
+		info, err := os.Stat(path)
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(os.Truncate(path, info.Size()-1)).To(Succeed())
+
+		j, err = openJournal(path)
+		g.Expect(err).To(Not(HaveOccurred()))
+		defer j.Unlock()
+		r, err := j.NewReader()
+		g.Expect(err).To(Not(HaveOccurred()))
+		defer r.Close()
+
+		_, err = r.Next()
+		g.Expect(err).To(Not(HaveOccurred()))
+		_, err = r.Next()
+		g.Expect(err).To(Equal(io.EOF))
+	})
+}
+
+func TestUploadWithRetries(t *testing.T) {
+	t.Run("resumes after the last acknowledged sequence number", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		j := writeJournal(t, filepath.Join(t.TempDir(), "a.journal"), 4)
+		g.Expect(j.Close()).To(Succeed())
+
+		var acked int64 = 2
+		client := &fakeClient{}
+		err := uploadWithRetries(context.Background(), client, j, "grpc://bes", 1,
+			func(seq int64) { acked = seq },
+			func() int64 { return acked },
+		)
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(client.received).To(Equal([]int64{3, 4}))
+		g.Expect(acked).To(Equal(int64(4)))
+	})
+
+	t.Run("retries a failed stream without resending acknowledged events", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		j := writeJournal(t, filepath.Join(t.TempDir(), "a.journal"), 3)
+		g.Expect(j.Close()).To(Succeed())
+
+		var mutex sync.Mutex
+		var acked int64
+		client := &fakeClient{failAfter: 2}
+		err := uploadWithRetries(context.Background(), client, j, "grpc://bes", 2,
+			func(seq int64) { mutex.Lock(); acked = seq; mutex.Unlock() },
+			func() int64 { mutex.Lock(); defer mutex.Unlock(); return acked },
+		)
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(client.received).To(ContainElements(int64(1), int64(2), int64(3)))
+		g.Expect(acked).To(Equal(int64(3)))
+	})
+}
+
+func TestPendingJournals(t *testing.T) {
+	t.Run("lists journals with their metadata", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, journalName("grpcs://bes.example.com"))
+		j := writeJournal(t, path, 1)
+		g.Expect(j.Close()).To(Succeed())
+		g.Expect(j.Unlock()).To(Succeed())
+		g.Expect(writeJournalMetadata(path, &JournalMetadata{Backend: "grpcs://bes.example.com", AckedSequenceNumber: 0})).To(Succeed())
+
+		pending, err := PendingJournals(dir)
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(pending).To(HaveLen(1))
+		g.Expect(pending[0].Path).To(Equal(path))
+		g.Expect(pending[0].Metadata.Backend).To(Equal("grpcs://bes.example.com"))
+	})
+
+	t.Run("skips journals without readable metadata", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		j := writeJournal(t, filepath.Join(dir, journalName("grpcs://bes.example.com")), 1)
+		g.Expect(j.Close()).To(Succeed())
+		g.Expect(j.Unlock()).To(Succeed())
+
+		pending, err := PendingJournals(dir)
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(pending).To(BeEmpty())
+	})
+
+	t.Run("skips journals that another invocation is still writing", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, journalName("grpcs://bes.example.com"))
+		j := writeJournal(t, path, 1)
+		defer j.Unlock()
+		g.Expect(writeJournalMetadata(path, &JournalMetadata{Backend: "grpcs://bes.example.com"})).To(Succeed())
+
+		pending, err := PendingJournals(dir)
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(pending).To(BeEmpty())
+
+		_, err = openJournal(path)
+		g.Expect(err).To(MatchError(errJournalInUse))
+	})
+
+	t.Run("returns nothing when the spool directory does not exist", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		pending, err := PendingJournals(filepath.Join(t.TempDir(), "missing"))
+
+		g.Expect(err).To(Not(HaveOccurred()))
+		g.Expect(pending).To(BeEmpty())
+	})
+}
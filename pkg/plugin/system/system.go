@@ -23,11 +23,14 @@ import (
 	"math"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 	"sigs.k8s.io/yaml"
@@ -35,12 +38,14 @@ import (
 	"github.com/aspect-build/aspect-cli/pkg/aspect/root/config"
 	rootFlags "github.com/aspect-build/aspect-cli/pkg/aspect/root/flags"
 	"github.com/aspect-build/aspect-cli/pkg/aspecterrors"
+	"github.com/aspect-build/aspect-cli/pkg/history"
 	"github.com/aspect-build/aspect-cli/pkg/interceptors"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils"
 	"github.com/aspect-build/aspect-cli/pkg/ioutils/prompt"
 	"github.com/aspect-build/aspect-cli/pkg/plugin/client"
 	"github.com/aspect-build/aspect-cli/pkg/plugin/sdk/v1alpha4/plugin"
 	"github.com/aspect-build/aspect-cli/pkg/plugin/system/bep"
+	"github.com/aspect-build/aspect-cli/pkg/plugin/system/besproxy"
 )
 
 // PluginSystem is the interface that defines all the methods for the aspect CLI
@@ -49,7 +54,7 @@ type PluginSystem interface {
 	Configure(streams ioutils.Streams, pluginsConfig interface{}) error
 	TearDown()
 	RegisterCustomCommands(cmd *cobra.Command, bazelStartupArgs []string) error
-	BESBackendInterceptor() interceptors.Interceptor
+	BESBackendInterceptor(needed ...func(cmd *cobra.Command) bool) interceptors.Interceptor
 	BESBackendSubscriberInterceptor() interceptors.Interceptor
 	BuildHooksInterceptor(streams ioutils.Streams) interceptors.Interceptor
 	TestHooksInterceptor(streams ioutils.Streams) interceptors.Interceptor
@@ -175,10 +180,12 @@ func (ps *pluginSystem) BESBackendSubscriberInterceptor() interceptors.Intercept
 }
 
 // BESBackendInterceptor sometimes starts a BES backend and injects it into the context.
-// It short-circuits and does nothing in cases where we think there is no subscriber.
+// It short-circuits and does nothing in cases where we think there is no subscriber: when no
+// plugin subscribes, history recording is disabled and none of needed reports that the command
+// subscribes to the build events for the flags it was given.
 // Use BESBackendSubscriberInterceptor if you always know there will be a subscriber.
 // It gracefully stops the server after the main command is executed.
-func (ps *pluginSystem) BESBackendInterceptor() interceptors.Interceptor {
+func (ps *pluginSystem) BESBackendInterceptor(needed ...func(cmd *cobra.Command) bool) interceptors.Interceptor {
 	return func(ctx context.Context, cmd *cobra.Command, args []string, next interceptors.RunEContextFn) error {
 		// Check if --aspect:force_bes_backend is set. This is primarily used for testing.
 		forceBesBackend, err := cmd.Root().Flags().GetBool(rootFlags.AspectForceBesBackendFlagName)
@@ -186,10 +193,19 @@ func (ps *pluginSystem) BESBackendInterceptor() interceptors.Interceptor {
 			return fmt.Errorf("failed to get value of --aspect:force_bes_backend: %w", err)
 		}
 
-		// If there are no plugins configured and --aspect:force_bes_backend is not set then short
-		// circuit here since we don't have any need to create a grpc server to consume the build event
-		// stream.
-		if !(forceBesBackend || ps.hasBESPlugins()) {
+		historyConfig, err := history.LoadConfig()
+		if err != nil {
+			return err
+		}
+
+		// If there are no plugins configured, history recording is disabled, the command doesn't
+		// subscribe to the build events and --aspect:force_bes_backend is not set then short
+		// circuit here since we don't have any need to create a grpc server to consume the build
+		// event stream.
+		commandSubscribes := slices.ContainsFunc(needed, func(n func(cmd *cobra.Command) bool) bool {
+			return n(cmd)
+		})
+		if !(forceBesBackend || ps.hasBESPlugins() || historyConfig.Enabled || commandSubscribes) {
 			return next(ctx, cmd, args)
 		}
 		if forceBesBackend {
@@ -218,6 +234,32 @@ func (ps *pluginSystem) createBesBackend(ctx context.Context, cmd *cobra.Command
 			besBackend.RegisterSubscriber(node.payload.BEPEventCallback, node.payload.MultiThreaded)
 		}
 	}
+
+	// Record the invocation to the local history store. The recorder is closed
+	// after the BES backend stops so that it sees every event.
+	historyConfig, err := history.LoadConfig()
+	if err != nil {
+		return err
+	}
+	if historyConfig.Enabled {
+		store, err := history.NewDefaultStore()
+		if err != nil {
+			return fmt.Errorf("failed to run BES backend: %w", err)
+		}
+		// The recorded events are redacted with the same rules as the ones
+		// forwarded to the user's BES backends, so they are never written to
+		// disk when the rules can't be loaded.
+		rules, err := besproxy.UnmarshalEventRules(viper.Get("bes.rules"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s Not recording the invocation to the local history: %s\n", color.YellowString("WARNING:"), err.Error())
+		} else {
+			recorder := history.NewRecorder(store, historyConfig.Retention)
+			recorder.RedactWith(rules)
+			besBackend.RegisterSubscriber(recorder.Callback, false)
+			defer recorder.Close()
+		}
+	}
+
 	opts := []grpc.ServerOption{
 		// Bazel doesn't seem to set a maximum send message size, therefore
 		// we match the default send message for Go, which should be enough